@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// archiveManifestName is the name of the manifest at the root of an --archive tarball
+const archiveManifestName = "manifest.json"
+
+// ArchiveManifest describes the contents of an --archive tarball
+type ArchiveManifest struct {
+	ChainProfile string        `json:"chainProfile"`
+	GenesisTime  string        `json:"genesisTime"`
+	Nodes        []ArchiveNode `json:"nodes"`
+}
+
+// ArchiveNode is a node directory of an --archive tarball
+type ArchiveNode struct {
+	Profile string        `json:"profile"`
+	Dir     string        `json:"dir"`
+	Files   []ArchiveFile `json:"files"`
+}
+
+// ArchiveFile is a file of a node directory, with its path relative to the directory
+type ArchiveFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// archiveEntry is a file on disk and the path it is stored under in the tarball
+type archiveEntry struct {
+	source string
+	name   string
+	info   fs.FileInfo
+}
+
+// listArchive walks the directories of the written nodes, returning the manifest and the files
+// to archive. Every regular file of a node directory is archived, including ones an earlier run
+// or --genesis-only left there, under a directory named like the node directory
+func listArchive(chainProfileName, genesisTime string, nodes []generatedNode) (ArchiveManifest, []archiveEntry, error) {
+	manifest := ArchiveManifest{ChainProfile: chainProfileName, GenesisTime: genesisTime, Nodes: []ArchiveNode{}}
+	var entries []archiveEntry
+	for _, node := range nodes {
+		archived := ArchiveNode{Profile: node.Profile, Dir: filepath.Base(node.Dir), Files: []ArchiveFile{}}
+		err := filepath.WalkDir(node.Dir, func(source string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.Type().IsRegular() {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			relative, err := filepath.Rel(node.Dir, source)
+			if err != nil {
+				return err
+			}
+			name := filepath.ToSlash(relative)
+			archived.Files = append(archived.Files, ArchiveFile{Name: name, Size: info.Size()})
+			entries = append(entries, archiveEntry{source: source, name: path.Join(archived.Dir, name), info: info})
+			return nil
+		})
+		if err != nil {
+			return ArchiveManifest{}, nil, fmt.Errorf("error listing %s: %w", node.Dir, err)
+		}
+		manifest.Nodes = append(manifest.Nodes, archived)
+	}
+	return manifest, entries, nil
+}
+
+// writeArchive packages the directories of the written nodes into a gzipped tarball at
+// archivePath, with manifest.json at the root and one directory per node. Files are streamed
+// from disk into the archive one at a time rather than read into memory
+func writeArchive(archivePath, chainProfileName, genesisTime string, nodes []generatedNode) error {
+	manifest, entries, err := listArchive(chainProfileName, genesisTime, nodes)
+	if err != nil {
+		return err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling %s: %w", archiveManifestName, err)
+	}
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", archivePath, err)
+	}
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = writeArchiveEntries(tarWriter, manifestData, entries)
+	if closeErr := tarWriter.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := gzipWriter.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", archivePath, err)
+	}
+	return nil
+}
+
+// writeArchiveEntries writes the manifest, then each file preceded by the headers of its
+// directories that aren't in the archive yet
+func writeArchiveEntries(tarWriter *tar.Writer, manifestData []byte, entries []archiveEntry) error {
+	header := &tar.Header{Name: archiveManifestName, Mode: 0644, Size: int64(len(manifestData)), ModTime: time.Now()}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tarWriter.Write(manifestData); err != nil {
+		return err
+	}
+
+	written := make(map[string]bool)
+	for _, entry := range entries {
+		var dirs []string
+		for dir := path.Dir(entry.name); dir != "." && !written[dir]; dir = path.Dir(dir) {
+			dirs = append([]string{dir}, dirs...)
+		}
+		for _, dir := range dirs {
+			header := &tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0755, ModTime: entry.info.ModTime()}
+			if err := tarWriter.WriteHeader(header); err != nil {
+				return err
+			}
+			written[dir] = true
+		}
+		if err := writeArchiveFile(tarWriter, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeArchiveFile copies a file from disk into the archive
+func writeArchiveFile(tarWriter *tar.Writer, entry archiveEntry) error {
+	header, err := tar.FileInfoHeader(entry.info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = entry.name
+	source, err := os.Open(entry.source)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tarWriter, source); err != nil {
+		return fmt.Errorf("error archiving %s: %w", entry.source, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteArchive(t *testing.T) {
+	outDir := t.TempDir()
+	nodes := []generatedNode{
+		{Profile: "node-1", Dir: nodeDir(outDir, "fixture", "node-1"), Files: []generatedFile{
+			{Name: "genesis.json", Data: []byte(`{"time":"t"}`)},
+			{Name: "config.json", Data: []byte(`{"chainId":1}`)},
+		}},
+		{Profile: "node-2", Dir: nodeDir(outDir, "fixture", "node-2"), Files: []generatedFile{
+			{Name: "config.json", Data: []byte(`{"chainId":2}`)},
+		}},
+	}
+	for _, node := range nodes {
+		if err := writeNode(node); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// a file an earlier run left in the node directory is archived too
+	if err := os.WriteFile(filepath.Join(nodes[1].Dir, "keystore.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "fixture.tar.gz")
+	if err := writeArchive(archivePath, "fixture", "2025-01-01 00:00:00", nodes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("archive is not gzipped: %v", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	var names []string
+	contents := make(map[string]string)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("archive is not a tarball: %v", err)
+		}
+		names = append(names, header.Name)
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		contents[header.Name] = string(data)
+	}
+
+	wantNames := []string{
+		"manifest.json",
+		"fixture-node-1/",
+		"fixture-node-1/config.json",
+		"fixture-node-1/genesis.json",
+		"fixture-node-2/",
+		"fixture-node-2/config.json",
+		"fixture-node-2/keystore.json",
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("archive entries = %v, want %v", names, wantNames)
+	}
+	if got := contents["fixture-node-2/config.json"]; got != `{"chainId":2}` {
+		t.Errorf("fixture-node-2/config.json = %q", got)
+	}
+
+	var manifest ArchiveManifest
+	if err := json.Unmarshal([]byte(contents["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("manifest.json is not a manifest: %v", err)
+	}
+	wantManifest := ArchiveManifest{ChainProfile: "fixture", GenesisTime: "2025-01-01 00:00:00", Nodes: []ArchiveNode{
+		{Profile: "node-1", Dir: "fixture-node-1", Files: []ArchiveFile{{Name: "config.json", Size: 13}, {Name: "genesis.json", Size: 12}}},
+		{Profile: "node-2", Dir: "fixture-node-2", Files: []ArchiveFile{{Name: "config.json", Size: 13}, {Name: "keystore.json", Size: 2}}},
+	}}
+	if !reflect.DeepEqual(manifest, wantManifest) {
+		t.Errorf("manifest = %+v, want %+v", manifest, wantManifest)
+	}
+
+	if err := writeArchive(filepath.Join(t.TempDir(), "missing", "fixture.tar.gz"), "fixture", "", nodes); err == nil {
+		t.Error("expected error for an archive path in a missing directory")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommitteeList is the committee IDs of a validator. In a chain profile it is either a list
+// (`committees: [0, 2, 5]`) or a comma-separated string (`committees: "0,2,5"`)
+type CommitteeList []int
+
+// UnmarshalYAML accepts both committee forms, rejects negative IDs and drops duplicates while
+// keeping the first occurrence of each ID
+func (c *CommitteeList) UnmarshalYAML(value *yaml.Node) error {
+	var ids []int
+	switch value.Kind {
+	case yaml.SequenceNode:
+		if err := value.Decode(&ids); err != nil {
+			return fmt.Errorf("line %d: committees must be integers: %w", value.Line, err)
+		}
+	case yaml.ScalarNode:
+		var err error
+		if ids, err = splitCommittees(value.Value); err != nil {
+			return fmt.Errorf("line %d: %w", value.Line, err)
+		}
+	default:
+		return fmt.Errorf("line %d: committees must be a list or a comma-separated string", value.Line)
+	}
+
+	list, err := newCommitteeList(ids)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*c = list
+	return nil
+}
+
+// UnmarshalTOML is UnmarshalYAML for TOML chain profiles
+func (c *CommitteeList) UnmarshalTOML(value interface{}) error {
+	var ids []int
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			id, ok := item.(int64)
+			if !ok {
+				return fmt.Errorf("committees must be integers, got %v", item)
+			}
+			ids = append(ids, int(id))
+		}
+	case string:
+		var err error
+		if ids, err = splitCommittees(value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("committees must be a list or a comma-separated string")
+	}
+
+	list, err := newCommitteeList(ids)
+	if err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// splitCommittees parses a comma-separated committee string, ignoring empty fields
+func splitCommittees(value string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid committee ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// newCommitteeList rejects negative IDs and drops duplicates, keeping the first occurrence of each
+func newCommitteeList(ids []int) (CommitteeList, error) {
+	seen := make(map[int]bool)
+	list := CommitteeList{}
+	for _, id := range ids {
+		if id < 0 {
+			return nil, fmt.Errorf("committee ID %d is negative", id)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		list = append(list, id)
+	}
+	return list, nil
+}
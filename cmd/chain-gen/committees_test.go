@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCommitteeListUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    CommitteeList
+		wantErr bool
+	}{
+		{name: "list", yaml: "committees: [0, 2, 5]", want: CommitteeList{0, 2, 5}},
+		{name: "block list", yaml: "committees:\n  - 1\n  - 3", want: CommitteeList{1, 3}},
+		{name: "comma-separated string", yaml: `committees: "0,2,5"`, want: CommitteeList{0, 2, 5}},
+		{name: "string with spaces", yaml: `committees: " 1 , 2 "`, want: CommitteeList{1, 2}},
+		{name: "single id", yaml: "committees: 4", want: CommitteeList{4}},
+		{name: "duplicates in list", yaml: "committees: [2, 1, 2]", want: CommitteeList{2, 1}},
+		{name: "duplicates in string", yaml: `committees: "1,1,3"`, want: CommitteeList{1, 3}},
+		{name: "negative in list", yaml: "committees: [1, -2]", wantErr: true},
+		{name: "negative in string", yaml: `committees: "1,-2"`, wantErr: true},
+		{name: "not a number", yaml: `committees: "1,two"`, wantErr: true},
+		{name: "mapping", yaml: "committees: {a: 1}", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var validator Validator
+			err := yaml.Unmarshal([]byte(test.yaml), &validator)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("yaml.Unmarshal() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(validator.Committees, test.want) {
+				t.Errorf("Committees = %v, want %v", validator.Committees, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// portProfiles are the node profiles getPortsForProfile has ports for
+var portProfiles = []string{"node-1", "node-2", "node-3"}
+
+// TemplateDefaults are the built-in values generate fills into config.json
+type TemplateDefaults struct {
+	Ports     map[string]DefaultPorts `json:"ports"`
+	P2PPort   string                  `json:"p2pPort"`
+	EthOracle map[string]interface{}  `json:"ethOracle"`
+}
+
+// DefaultPorts are the ports and listen host of a node profile. The p2p port depends on the
+// chain ID and is the same for every profile, so it is reported once in TemplateDefaults
+type DefaultPorts struct {
+	Wallet     int    `json:"wallet"`
+	Explorer   int    `json:"explorer"`
+	RPC        int    `json:"rpc"`
+	Admin      int    `json:"admin"`
+	ListenHost string `json:"listenHost"`
+}
+
+// templateDefaults collects the port mapping of every node profile and the eth oracle blocks
+// injected into eth_oracle validators
+func templateDefaults() (TemplateDefaults, error) {
+	defaults := TemplateDefaults{
+		Ports:     make(map[string]DefaultPorts, len(portProfiles)),
+		P2PPort:   "9000 + chain_id",
+		EthOracle: ethOracleDefaults(),
+	}
+	for _, profile := range portProfiles {
+		walletPort, explorerPort, rpcPort, adminPort, _, listenHost, err := getPortsForProfile(profile, 0)
+		if err != nil {
+			return TemplateDefaults{}, err
+		}
+		ports := DefaultPorts{ListenHost: listenHost}
+		for _, port := range []struct {
+			value string
+			dest  *int
+		}{
+			{walletPort, &ports.Wallet},
+			{explorerPort, &ports.Explorer},
+			{rpcPort, &ports.RPC},
+			{adminPort, &ports.Admin},
+		} {
+			value, err := strconv.Atoi(port.value)
+			if err != nil {
+				return TemplateDefaults{}, fmt.Errorf("invalid port %q of %s: %w", port.value, profile, err)
+			}
+			*port.dest = value
+		}
+		defaults.Ports[profile] = ports
+	}
+	return defaults, nil
+}
+
+// printTemplateDefaults prints the built-in port mapping and eth oracle config as JSON
+func printTemplateDefaults() error {
+	defaults, err := templateDefaults()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(defaults, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling template defaults: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateDefaults(t *testing.T) {
+	defaults, err := templateDefaults()
+	if err != nil {
+		t.Fatalf("templateDefaults: %v", err)
+	}
+
+	want := map[string]DefaultPorts{
+		"node-1": {Wallet: 50000, Explorer: 50001, RPC: 50002, Admin: 50003, ListenHost: "127.0.0.101"},
+		"node-2": {Wallet: 40000, Explorer: 40001, RPC: 40002, Admin: 40003, ListenHost: "127.0.0.102"},
+		"node-3": {Wallet: 30000, Explorer: 30001, RPC: 30002, Admin: 30003, ListenHost: "127.0.0.103"},
+	}
+	if !reflect.DeepEqual(defaults.Ports, want) {
+		t.Errorf("ports = %+v, want %+v", defaults.Ports, want)
+	}
+	for _, key := range oracleConfigKeys {
+		if _, ok := defaults.EthOracle[key]; !ok {
+			t.Errorf("eth oracle defaults missing %s", key)
+		}
+	}
+}
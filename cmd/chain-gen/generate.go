@@ -0,0 +1,649 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+	"gopkg.in/yaml.v3"
+)
+
+// inputs holds the templates and keys shared by every chain profile
+type inputs struct {
+	Genesis        Genesis
+	ConfigTemplate map[string]interface{}
+	ConfigOrder    *keyOrder
+	Keys           KeyOutput
+	Keystore       []byte
+}
+
+// options control how a chain profile is generated
+type options struct {
+	OutDir          string
+	TemplatesDir    string
+	GenesisTime     string
+	SharedKeystore  bool     // copy the full keystore into every node instead of only the node's own key
+	NoKeystore      bool     // write no keystore.json, for nodes that get their keys another way
+	KeyFormat       string   // validator_key.json format, keyFormatRawString or keyFormatJSONObject
+	NoSort          bool     // keep the config template's key order instead of sorting config.json
+	Only            []string // generate only these node profiles; the genesis still covers every validator
+	ContinueOnError bool     // record a node that fails to generate or write and move on to the next node
+	GenesisOnly     bool     // generate only genesis.json, leaving the other node files alone
+	ConfigOnly      bool     // generate only config.json, leaving the other node files alone
+	Roster          string   // path the validator roster JSON is written to, empty to skip
+	Prometheus      string   // path the prometheus scrape config is written to, empty to skip
+	Archive         string   // path the node directories are packaged into as a .tar.gz, empty to skip
+
+	EncryptValidatorKey  bool   // write validator_key.json as an encrypted keystore entry instead of plaintext
+	ValidatorKeyPassword string // password validator_key.json is encrypted with
+}
+
+const (
+	// keyFormatRawString writes validator_key.json as a quoted private key string
+	keyFormatRawString = "raw-string"
+	// keyFormatJSONObject writes validator_key.json as a {privateKey, publicKey, address} object
+	keyFormatJSONObject = "json-object"
+)
+
+const (
+	// defaultAccountAmount is the genesis balance of every key's account
+	defaultAccountAmount = 1000000000
+	// defaultStakedAmount is the genesis stake of every validator
+	defaultStakedAmount = 1000000000
+)
+
+// generatedFile is a single file generated for a node
+type generatedFile struct {
+	Name string
+	Data []byte
+}
+
+// generatedNode holds every file generated for a validator node
+type generatedNode struct {
+	Profile  string
+	Dir      string
+	Files    []generatedFile
+	Warnings []string
+	Err      error // set instead of Files when the node failed to generate with --continue-on-error
+}
+
+// fileNames lists the files of a node, e.g. "genesis.json, config.json and keystore.json"
+func (n generatedNode) fileNames() string {
+	names := make([]string, len(n.Files))
+	for i, file := range n.Files {
+		names[i] = file.Name
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// configTemplateNames are the accepted config template file names, in lookup order
+var configTemplateNames = []string{"config.json", "config.yaml", "config.yml"}
+
+// loadInputs reads and parses the templates and keys
+func loadInputs(templatesDir string) (*inputs, error) {
+	in := &inputs{}
+
+	genesisPath := filepath.Join(templatesDir, "genesis.json")
+	genesisData, err := ioutil.ReadFile(genesisPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", genesisPath, err)
+	}
+
+	configTemplatePath, err := findConfigTemplate(templatesDir)
+	if err != nil {
+		return nil, err
+	}
+	configTemplateData, err := ioutil.ReadFile(configTemplatePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", configTemplatePath, err)
+	}
+
+	keysData, err := ioutil.ReadFile("keys/node-bls.json")
+	if err != nil {
+		return nil, fmt.Errorf("error reading keys/node-bls.json: %w", err)
+	}
+
+	in.Keystore, err = ioutil.ReadFile("keys/keystore.json")
+	if err != nil {
+		return nil, fmt.Errorf("error reading keys/keystore.json: %w", err)
+	}
+
+	if err := json.Unmarshal(genesisData, &in.Genesis); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", genesisPath, err)
+	}
+
+	// Parse the config template as YAML or JSON based on its extension
+	switch filepath.Ext(configTemplatePath) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(configTemplateData, &in.ConfigTemplate)
+		if err == nil {
+			in.ConfigOrder, err = yamlKeyOrder(configTemplateData)
+		}
+	default:
+		err = json.Unmarshal(configTemplateData, &in.ConfigTemplate)
+		if err == nil {
+			in.ConfigOrder, err = jsonKeyOrder(configTemplateData)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", configTemplatePath, err)
+	}
+
+	if err := json.Unmarshal(keysData, &in.Keys); err != nil {
+		return nil, fmt.Errorf("error parsing keys/node-bls.json: %w", err)
+	}
+
+	if err := checkInputs(in, genesisPath, genesisData, configTemplatePath); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// genesisTemplateKeys are the top-level keys templates/genesis.json must have
+var genesisTemplateKeys = []string{"time", "accounts", "nonSigners", "validators", "params"}
+
+// checkInputs checks the shape of the parsed templates and keys, so a bad template is reported by
+// name before any node is generated instead of surfacing as a late or confusing error
+func checkInputs(in *inputs, genesisPath string, genesisData []byte, configTemplatePath string) error {
+	var genesis map[string]json.RawMessage
+	if err := json.Unmarshal(genesisData, &genesis); err != nil {
+		return fmt.Errorf("error parsing %s: %w", genesisPath, err)
+	}
+	var missing []string
+	for _, key := range genesisTemplateKeys {
+		if _, ok := genesis[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invalid %s: missing top-level keys %s", genesisPath, strings.Join(missing, ", "))
+	}
+	if _, ok := in.Genesis.Params.(map[string]interface{}); !ok {
+		return fmt.Errorf("invalid %s: params is not an object", genesisPath)
+	}
+	if len(in.ConfigTemplate) == 0 {
+		return fmt.Errorf("invalid %s: expected a non-empty object", configTemplatePath)
+	}
+	if len(in.Keys.Keys) == 0 {
+		return fmt.Errorf("invalid keys/node-bls.json: no keys")
+	}
+	return nil
+}
+
+// findConfigTemplate returns the path of the config template in the templates directory
+func findConfigTemplate(templatesDir string) (string, error) {
+	for _, name := range configTemplateNames {
+		path := filepath.Join(templatesDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no config template (%s) found in %s", strings.Join(configTemplateNames, ", "), templatesDir)
+}
+
+// generate builds the files of every validator node in a chain profile without touching the out-dir
+func generate(chainProfileName string, config Config, in *inputs, opts options) ([]generatedNode, error) {
+	genesis := in.Genesis
+	keyOutput := in.Keys
+
+	genesis.Time = opts.GenesisTime
+
+	params, err := genesisParams(in.Genesis.Params, config)
+	if err != nil {
+		return nil, err
+	}
+	genesis.Params = params
+	if err := chainParamsError(config); err != nil {
+		return nil, err
+	}
+
+	for _, validator := range config.Validators {
+		if err := validatorOverrideError(validator); err != nil {
+			return nil, fmt.Errorf("invalid validator %s: %w", validator.Profile, err)
+		}
+	}
+
+	genesis.Accounts = genesisAccounts(config, keyOutput)
+	if len(config.NonSigners) > 0 {
+		nonSigners, err := genesisNonSigners(config, keyOutput)
+		if err != nil {
+			return nil, err
+		}
+		genesis.NonSigners = nonSigners
+	}
+
+	if len(config.Validators) == 0 {
+		return nil, nil
+	}
+
+	// Build all validators first
+	mergedValidators := make([]Validator, len(config.Validators))
+	for i, configValidator := range config.Validators {
+		validator := Validator{
+			Committees:      configValidator.Committees,
+			NetAddress:      validatorNetAddress(configValidator),
+			StakedAmount:    validatorStake(configValidator),
+			MaxPausedHeight: 0,
+			UnstakingHeight: 0,
+			Delegate:        false,
+			Compound:        true,
+		}
+
+		// Use the key field to reference the correct key from node-bls.json
+		keyIndex := configValidator.Key
+		if keyIndex >= 0 && keyIndex < len(keyOutput.Keys) {
+			key := keyOutput.Keys[keyIndex]
+			validator.Address = key.Address
+			validator.PublicKey = key.PublicKey
+			validator.Output = key.Address
+		}
+
+		mergedValidators[i] = validator
+	}
+
+	// Set all validators in the genesis
+	genesis.Validators = mergedValidators
+
+	// Generate genesis.json, the same for all nodes of a chain
+	genesisOutputs := make(map[int][]byte)
+	genesisOutput := func(chainID int) ([]byte, error) {
+		if output, ok := genesisOutputs[chainID]; ok {
+			return output, nil
+		}
+		chainGenesis := genesis
+		chainGenesis.Params = chainGenesisParams(genesis.Params, config, chainID)
+		output, err := json.MarshalIndent(chainGenesis, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling genesis output: %w", err)
+		}
+		genesisOutputs[chainID] = output
+		return output, nil
+	}
+
+	selected, err := selectProfiles(chainProfileName, config, opts.Only)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate files for each validator node
+	var nodes []generatedNode
+	for i, configValidator := range config.Validators {
+		if selected != nil && !selected[configValidator.Profile] {
+			continue
+		}
+		chainGenesisOutput, err := genesisOutput(configValidator.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		node, err := generateNode(chainProfileName, config, in, opts, i, chainGenesisOutput)
+		if err != nil {
+			if !opts.ContinueOnError {
+				return nil, err
+			}
+			node = generatedNode{
+				Profile: configValidator.Profile,
+				Dir:     nodeDir(opts.OutDir, chainProfileName, configValidator.Profile),
+				Err:     err,
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// generateNode builds the files of the validator node at index in the chain profile
+func generateNode(chainProfileName string, config Config, in *inputs, opts options, index int, genesisOutput []byte) (generatedNode, error) {
+	configValidator := config.Validators[index]
+	keyOutput := in.Keys
+	var err error
+
+	node := generatedNode{
+		Profile: configValidator.Profile,
+		Dir:     nodeDir(opts.OutDir, chainProfileName, configValidator.Profile),
+	}
+	if !opts.ConfigOnly {
+		node.Files = append(node.Files, generatedFile{Name: "genesis.json", Data: genesisOutput})
+	}
+	if opts.GenesisOnly {
+		return node, nil
+	}
+
+	// Generate config.json (unique for each node), filling in ${VAR} placeholders
+	vars := nodeVars(chainProfileName, config, index)
+	unknown := make(map[string]bool)
+	nodeConfig := make(map[string]interface{})
+	for k, v := range in.ConfigTemplate {
+		nodeConfig[k] = substituteVars(v, vars, unknown)
+	}
+	node.Warnings = unknownVarWarnings(configValidator.Profile, unknown)
+
+	// Set node-specific ports and addresses
+	walletPort, explorerPort, rpcPort, adminPort, listenPort, listenAddr, err := getPortsForProfile(configValidator.Profile, configValidator.ChainID)
+	if err != nil {
+		return node, err
+	}
+	nodeConfig["walletPort"] = walletPort
+	nodeConfig["explorerPort"] = explorerPort
+	nodeConfig["rpcPort"] = rpcPort
+	nodeConfig["adminPort"] = adminPort
+	nodeConfig["listenAddress"] = nodeListenAddress(configValidator, listenAddr, listenPort)
+	nodeConfig["externalAddress"] = configValidator.Profile
+	nodeConfig["rpcURL"] = fmt.Sprintf("http://%s", net.JoinHostPort(configValidator.Profile, rpcPort))
+	nodeConfig["adminRPCUrl"] = fmt.Sprintf("http://%s", net.JoinHostPort(configValidator.Profile, adminPort))
+
+	// Set chainId from YAML configuration
+	nodeConfig["chainId"] = configValidator.ChainID
+
+	// Set runVDF based on nested flag
+	if configValidator.Nested {
+		nodeConfig["runVDF"] = false
+	}
+
+	// Add eth oracle configuration if enabled
+	if configValidator.EthOracle {
+		for key, block := range ethOracleDefaults() {
+			nodeConfig[key] = block
+		}
+	}
+
+	var configOutput []byte
+	if opts.NoSort {
+		configOutput, err = orderedConfig(nodeConfig, in.ConfigOrder)
+		if err != nil {
+			return generatedNode{}, fmt.Errorf("error marshaling config output: %w", err)
+		}
+	} else {
+		configOutput, err = json.MarshalIndent(nodeConfig, "", "  ")
+		if err != nil {
+			return generatedNode{}, fmt.Errorf("error marshaling config output: %w", err)
+		}
+
+		configOutput, err = sortConfig(configOutput)
+		if err != nil {
+			return generatedNode{}, err
+		}
+	}
+	node.Files = append(node.Files, generatedFile{Name: "config.json", Data: configOutput})
+	if opts.ConfigOnly {
+		return node, nil
+	}
+
+	// Generate validator.key file with private key
+	keyIndex := configValidator.Key
+	if keyIndex >= 0 && keyIndex < len(keyOutput.Keys) {
+		var keyContent []byte
+		if opts.EncryptValidatorKey {
+			keyContent, err = encryptedValidatorKeyFile(keyOutput.Keys[keyIndex], opts.ValidatorKeyPassword)
+		} else {
+			keyContent, err = validatorKeyFile(keyOutput.Keys[keyIndex], opts.KeyFormat)
+		}
+		if err != nil {
+			return generatedNode{}, fmt.Errorf("error building validator_key.json for %s: %w", configValidator.Profile, err)
+		}
+		node.Files = append(node.Files, generatedFile{Name: "validator_key.json", Data: keyContent})
+	}
+
+	if opts.NoKeystore {
+		// Keys are injected some other way, e.g. from a secret manager
+		return node, nil
+	}
+	if opts.SharedKeystore {
+		// Copy keystore.json to validator directory
+		node.Files = append(node.Files, generatedFile{Name: "keystore.json", Data: in.Keystore})
+	} else if keyIndex >= 0 && keyIndex < len(keyOutput.Keys) {
+		// Write a keystore holding only this validator's key
+		keystoreOutput, err := nodeKeystore(in.Keystore, keyOutput.Keys[keyIndex].Address)
+		if err != nil {
+			return generatedNode{}, fmt.Errorf("error building keystore for %s: %w", configValidator.Profile, err)
+		}
+		node.Files = append(node.Files, generatedFile{Name: "keystore.json", Data: keystoreOutput})
+	}
+
+	return node, nil
+}
+
+// selectProfiles returns the set of node profiles named by --only, nil when every node is
+// generated, and errors on names that aren't in the chain profile
+func selectProfiles(chainProfileName string, config Config, only []string) (map[string]bool, error) {
+	if len(only) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]bool)
+	for _, validator := range config.Validators {
+		known[validator.Profile] = true
+	}
+	selected := make(map[string]bool)
+	for _, profile := range only {
+		if !known[profile] {
+			return nil, fmt.Errorf("node %s is not in chain profile %s", profile, chainProfileName)
+		}
+		selected[profile] = true
+	}
+	return selected, nil
+}
+
+// validatorStake returns the genesis stake of a validator, defaulting to defaultStakedAmount
+func validatorStake(validator Validator) int64 {
+	if validator.StakedAmount != 0 {
+		return validator.StakedAmount
+	}
+	return defaultStakedAmount
+}
+
+// validatorNetAddress returns the net address of a validator, defaulting to its docker host
+func validatorNetAddress(validator Validator) string {
+	if validator.NetAddress != "" {
+		return validator.NetAddress
+	}
+	return fmt.Sprintf("tcp://%s", validator.Profile)
+}
+
+// genesisAccounts funds an account for every key, with the amount keygen tagged the key with or
+// defaultAccountAmount. A validator's account holds at least its stake plus the profile's stake
+// buffer, and the profile's explicit account amounts override all of these; explicit accounts for
+// addresses without a key are appended
+func genesisAccounts(config Config, keys KeyOutput) []Account {
+	explicit := make(map[string]int64)
+	for _, account := range config.Accounts {
+		explicit[account.Address] = account.Amount
+	}
+
+	stakes := make(map[string]int64)
+	for _, validator := range config.Validators {
+		if validator.Key >= 0 && validator.Key < len(keys.Keys) {
+			stakes[keys.Keys[validator.Key].Address] = validatorStake(validator)
+		}
+	}
+
+	var accounts []Account
+	funded := make(map[string]bool)
+	for _, key := range keys.Keys {
+		amount := int64(defaultAccountAmount)
+		if key.Amount > 0 {
+			amount = key.Amount
+		}
+		if stake, ok := stakes[key.Address]; ok && stake+config.StakeBuffer > amount {
+			amount = stake + config.StakeBuffer
+		}
+		if explicitAmount, ok := explicit[key.Address]; ok {
+			amount = explicitAmount
+		}
+		accounts = append(accounts, Account{Address: key.Address, Amount: amount})
+		funded[key.Address] = true
+	}
+
+	for _, account := range config.Accounts {
+		if !funded[account.Address] {
+			accounts = append(accounts, account)
+			funded[account.Address] = true
+		}
+	}
+	return accounts
+}
+
+// genesisNonSigner is the genesis JSON form of a canopy non-signer; the address is
+// base64-encoded bytes like the canopy fsm.NonSigner
+type genesisNonSigner struct {
+	Address []byte `json:"address"`
+	Counter uint64 `json:"counter"`
+}
+
+// genesisNonSigners builds the genesis non-signers of a chain profile
+func genesisNonSigners(config Config, keys KeyOutput) ([]genesisNonSigner, error) {
+	var nonSigners []genesisNonSigner
+	for _, nonSigner := range config.NonSigners {
+		if nonSigner.Key < 0 || nonSigner.Key >= len(keys.Keys) {
+			continue
+		}
+		address, err := hex.DecodeString(keys.Keys[nonSigner.Key].Address)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding address of non-signer key %d: %w", nonSigner.Key, err)
+		}
+		counter := nonSigner.Counter
+		if counter == 0 {
+			counter = 1
+		}
+		nonSigners = append(nonSigners, genesisNonSigner{Address: address, Counter: counter})
+	}
+	return nonSigners, nil
+}
+
+// validatorKeyFile renders validator_key.json in the given format and checks it is valid JSON
+func validatorKeyFile(key KeyPair, format string) ([]byte, error) {
+	var keyContent []byte
+	switch format {
+	case keyFormatRawString, "":
+		keyContent = []byte(fmt.Sprintf("\"%s\"", key.PrivateKey))
+	case keyFormatJSONObject:
+		var err error
+		keyContent, err = json.MarshalIndent(key, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown key format %q", format)
+	}
+	if !json.Valid(keyContent) {
+		return nil, fmt.Errorf("generated validator key is not valid JSON")
+	}
+	return keyContent, nil
+}
+
+// encryptedValidatorKeyFile renders validator_key.json as the keystore-format encrypted entry of
+// a single key
+func encryptedValidatorKeyFile(key KeyPair, password string) ([]byte, error) {
+	privateKey, err := hex.DecodeString(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding private key of %s: %w", key.Address, err)
+	}
+	publicKey, err := hex.DecodeString(key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key of %s: %w", key.Address, err)
+	}
+	encrypted, err := crypto.EncryptPrivateKey(publicKey, privateKey, []byte(password), key.Address)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting key of %s: %w", key.Address, err)
+	}
+	return json.MarshalIndent(encrypted, "", "  ")
+}
+
+// sameEncryptedValidatorKey reports whether an encrypted validator_key.json decrypts to the same
+// key as another; encryption is salted, so their bytes never match
+func sameEncryptedValidatorKey(a, b []byte, password string) bool {
+	var keys [2]crypto.EncryptedPrivateKey
+	for i, data := range [][]byte{a, b} {
+		if err := json.Unmarshal(data, &keys[i]); err != nil {
+			return false
+		}
+	}
+	first, err := crypto.DecryptPrivateKey(&keys[0], []byte(password))
+	if err != nil {
+		return false
+	}
+	second, err := crypto.DecryptPrivateKey(&keys[1], []byte(password))
+	if err != nil {
+		return false
+	}
+	return first.String() == second.String()
+}
+
+// nodeKeystore extracts the entry of a single address (and its nicknames) from a keystore
+func nodeKeystore(keystoreData []byte, address string) ([]byte, error) {
+	var keystore crypto.Keystore
+	if err := json.Unmarshal(keystoreData, &keystore); err != nil {
+		return nil, fmt.Errorf("error parsing keys/keystore.json: %w", err)
+	}
+
+	entry, ok := keystore.AddressMap[address]
+	if !ok {
+		return nil, fmt.Errorf("keys/keystore.json has no entry for %s", address)
+	}
+
+	nodeKeystore := crypto.Keystore{
+		AddressMap:  map[string]*crypto.EncryptedPrivateKey{address: entry},
+		NicknameMap: make(map[string]string),
+	}
+	for nickname, nicknameAddress := range keystore.NicknameMap {
+		if nicknameAddress == address {
+			nodeKeystore.NicknameMap[nickname] = address
+		}
+	}
+
+	return json.MarshalIndent(nodeKeystore, "", "  ")
+}
+
+// fallBackWithoutJQ switches opts to writing config.json unsorted, as with --no-sort, when jq
+// isn't on PATH, and reports whether it did. The unsorted config.json is still valid JSON
+func fallBackWithoutJQ(opts *options) bool {
+	if opts.NoSort {
+		return false
+	}
+	if _, err := exec.LookPath("jq"); err == nil {
+		return false
+	}
+	opts.NoSort = true
+	return true
+}
+
+// sortConfig sorts the top-level keys of config.json with jq. A jq failure reports what jq
+// printed to stderr, which is where it explains a malformed config
+func sortConfig(configOutput []byte) ([]byte, error) {
+	cmd := exec.Command("jq", "to_entries | sort_by(.key) | from_entries")
+	cmd.Stdin = bytes.NewReader(configOutput)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	sortedOutput, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("error sorting config.json with jq: %w: %s", err, message)
+		}
+		return nil, fmt.Errorf("error sorting config.json with jq: %w", err)
+	}
+	return sortedOutput, nil
+}
+
+// writeNode writes a generated node's files into its directory
+func writeNode(node generatedNode) error {
+	if err := os.MkdirAll(node.Dir, 0755); err != nil {
+		return fmt.Errorf("error creating directory %s: %w", node.Dir, err)
+	}
+	for _, file := range node.Files {
+		filePath := filepath.Join(node.Dir, file.Name)
+		if err := ioutil.WriteFile(filePath, file.Data, 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", filePath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,767 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/canopy-network/canopy/fsm"
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+// testKeys returns a KeyOutput holding n keys with addresses addr0, addr1, ...
+func testKeys(n int) KeyOutput {
+	var keys KeyOutput
+	for i := 0; i < n; i++ {
+		keys.Keys = append(keys.Keys, KeyPair{
+			PrivateKey: fmt.Sprintf("priv%d", i),
+			PublicKey:  fmt.Sprintf("pub%d", i),
+			Address:    fmt.Sprintf("addr%d", i),
+		})
+	}
+	return keys
+}
+
+func TestGenesisAccounts(t *testing.T) {
+	validators := []Validator{{Profile: "node-1", Key: 0}, {Profile: "node-2", Key: 0}, {Profile: "node-3", Key: 1}}
+	tests := []struct {
+		name   string
+		config Config
+		tagged map[int]int64 // key index -> amount keygen tagged the key with
+		want   []Account
+	}{
+		{
+			name:   "default funding",
+			config: Config{Validators: validators},
+			want: []Account{
+				{Address: "addr0", Amount: defaultAccountAmount},
+				{Address: "addr1", Amount: defaultAccountAmount},
+				{Address: "addr2", Amount: defaultAccountAmount},
+			},
+		},
+		{
+			name:   "stake buffer funds validator accounts only",
+			config: Config{Validators: validators, StakeBuffer: 5000},
+			want: []Account{
+				{Address: "addr0", Amount: defaultStakedAmount + 5000},
+				{Address: "addr1", Amount: defaultStakedAmount + 5000},
+				{Address: "addr2", Amount: defaultAccountAmount},
+			},
+		},
+		{
+			name: "explicit amounts override and extra accounts are appended",
+			config: Config{
+				Validators:  validators,
+				StakeBuffer: 5000,
+				Accounts:    []Account{{Address: "addr1", Amount: 10}, {Address: "extra", Amount: 42}},
+			},
+			want: []Account{
+				{Address: "addr0", Amount: defaultStakedAmount + 5000},
+				{Address: "addr1", Amount: 10},
+				{Address: "addr2", Amount: defaultAccountAmount},
+				{Address: "extra", Amount: 42},
+			},
+		},
+		{
+			name:   "tagged key amounts replace the default",
+			config: Config{Validators: validators, StakeBuffer: 5000, Accounts: []Account{{Address: "addr1", Amount: 10}}},
+			tagged: map[int]int64{0: 1, 1: 7, 2: 5000000000},
+			want: []Account{
+				// a validator account still covers its stake, and explicit amounts still win
+				{Address: "addr0", Amount: defaultStakedAmount + 5000},
+				{Address: "addr1", Amount: 10},
+				{Address: "addr2", Amount: 5000000000},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys := testKeys(3)
+			for index, amount := range test.tagged {
+				keys.Keys[index].Role = "faucet"
+				keys.Keys[index].Amount = amount
+			}
+			if got := genesisAccounts(test.config, keys); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("genesisAccounts() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFundingProblems(t *testing.T) {
+	validators := []Validator{{Profile: "node-1", Key: 0}, {Profile: "node-2", Key: 1}}
+	tests := []struct {
+		name   string
+		config Config
+		want   int
+	}{
+		{name: "default funding", config: Config{Validators: validators}, want: 0},
+		{name: "explicit amount covers stake", config: Config{Validators: validators, Accounts: []Account{{Address: "addr0", Amount: defaultStakedAmount}}}, want: 0},
+		{name: "explicit amount below stake", config: Config{Validators: validators, Accounts: []Account{{Address: "addr1", Amount: 1}}}, want: 1},
+		{name: "non-validator account below stake", config: Config{Validators: validators, Accounts: []Account{{Address: "addr2", Amount: 1}}}, want: 0},
+		{name: "negative buffer", config: Config{Validators: validators, StakeBuffer: -1}, want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fundingProblems(test.config, testKeys(3)); len(got) != test.want {
+				t.Errorf("fundingProblems() = %v, want %d problems", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEncryptedValidatorKeyFile(t *testing.T) {
+	privateKey, err := crypto.NewBLS12381PrivateKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := KeyPair{
+		PrivateKey: privateKey.String(),
+		PublicKey:  privateKey.PublicKey().String(),
+		Address:    privateKey.PublicKey().Address().String(),
+	}
+
+	first, err := encryptedValidatorKeyFile(key, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := encryptedValidatorKeyFile(key, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(first), key.PrivateKey) {
+		t.Error("encrypted validator key contains the plaintext private key")
+	}
+	if !sameEncryptedValidatorKey(first, second, "test") {
+		t.Error("two encryptions of the same key don't decrypt to the same key")
+	}
+	if sameEncryptedValidatorKey(first, second, "wrong") {
+		t.Error("encrypted validator keys compare equal with the wrong password")
+	}
+}
+
+func TestCommitteeProblems(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator Validator
+		want      int
+	}{
+		{name: "staked for its chain", validator: Validator{Profile: "node-1", ChainID: 1, Committees: CommitteeList{1}}},
+		{name: "staked for its chain among others", validator: Validator{Profile: "node-1", ChainID: 2, Committees: CommitteeList{1, 2}}},
+		{name: "staked for another chain", validator: Validator{Profile: "node-1", ChainID: 2, Committees: CommitteeList{1}}, want: 1},
+		{name: "no committees", validator: Validator{Profile: "node-1", ChainID: 1}, want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := committeeProblems(Config{Validators: []Validator{test.validator}})
+			if len(got) != test.want {
+				t.Errorf("committeeProblems() = %v, want %d problems", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProfileProblems(t *testing.T) {
+	valid := []Validator{
+		{Profile: "node-1", Key: 0, ChainID: 1, Committees: CommitteeList{1}},
+		{Profile: "node-2", Key: 0, ChainID: 2, Committees: CommitteeList{2}}, // a key may run several chains
+	}
+	tests := []struct {
+		name       string
+		validators []Validator
+		nonSigners []NonSigner
+		want       string
+	}{
+		{name: "valid", validators: valid},
+		{name: "no validators", want: "no validators"},
+		{name: "key out of range", validators: []Validator{{Profile: "node-1", Key: 5, ChainID: 1, Committees: CommitteeList{1}}}, want: "references key 5"},
+		{
+			name:       "key shared on a chain",
+			validators: append(valid, Validator{Profile: "node-3", Key: 0, ChainID: 1, Committees: CommitteeList{1}}),
+			want:       "validator node-3 uses key 0, already used by validator node-1 on chain 1",
+		},
+		{name: "non-signer listed twice", validators: valid, nonSigners: []NonSigner{{Key: 1}, {Key: 1}}, want: "non-signer key 1 is listed more than once"},
+		{name: "unknown profile", validators: []Validator{{Profile: "node-9", ChainID: 1, Committees: CommitteeList{1}}}, want: "node-9 has no built-in ports"},
+		{
+			name:       "profile listed twice",
+			validators: append(valid, Validator{Profile: "node-1", Key: 1, ChainID: 2, Committees: CommitteeList{2}}),
+			want:       "node-1 is listed more than once",
+		},
+		{
+			name: "listen address collision",
+			validators: []Validator{
+				{Profile: "node-1", Key: 0, ChainID: 1, Committees: CommitteeList{1}, ListenHost: "10.0.0.5"},
+				{Profile: "node-2", Key: 1, ChainID: 1, Committees: CommitteeList{1}, ListenHost: "10.0.0.5"},
+			},
+			want: "validator node-2 listens on 10.0.0.5:9001 like validator node-1",
+		},
+		{name: "committee mismatch", validators: []Validator{{Profile: "node-1", ChainID: 1, Committees: CommitteeList{2}}}, want: "runs chain 1"},
+		{name: "bad net address", validators: []Validator{{Profile: "node-1", ChainID: 1, Committees: CommitteeList{1}, NetAddress: "10.0.0.5"}}, want: "validator node-1: net_address"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := profileProblems(Config{Validators: test.validators, NonSigners: test.nonSigners}, &inputs{Keys: testKeys(3)})
+			if test.want == "" {
+				if len(got) != 0 {
+					t.Errorf("profileProblems() = %v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 || !strings.Contains(got[0], test.want) {
+				t.Errorf("profileProblems() = %v, want one problem containing %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenesisNonSigners(t *testing.T) {
+	keys := testKeys(0)
+	keys.Keys = append(keys.Keys,
+		KeyPair{Address: "851e90eaef1fa27debaee2c2591503bdeec1d123"},
+		KeyPair{Address: "02cd4e5eb53ea665702042a6ed6d31d616054dc5"})
+	config := Config{NonSigners: []NonSigner{{Key: 1, Counter: 3}, {Key: 0}, {Key: 5}}}
+
+	nonSigners, err := genesisNonSigners(config, keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.Marshal(nonSigners)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the genesis must decode as canopy non-signers
+	var decoded fsm.NonSigners
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("canopy can't decode non-signers %s: %v", data, err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("got %d non-signers, want 2 (out-of-range key skipped)", len(decoded))
+	}
+	if got := hex.EncodeToString(decoded[0].Address); got != keys.Keys[1].Address || decoded[0].Counter != 3 {
+		t.Errorf("first non-signer = %s/%d, want %s/3", got, decoded[0].Counter, keys.Keys[1].Address)
+	}
+	if decoded[1].Counter != 1 {
+		t.Errorf("default counter = %d, want 1", decoded[1].Counter)
+	}
+
+	if problems := nonSignerProblems(config, keys); len(problems) != 1 {
+		t.Errorf("nonSignerProblems() = %v, want 1 problem", problems)
+	}
+}
+
+func TestValidatorOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		validator   Validator
+		wantAddress string
+		wantStake   int64
+		wantErr     bool
+	}{
+		{name: "defaults", validator: Validator{Profile: "node-1"}, wantAddress: "tcp://node-1", wantStake: defaultStakedAmount},
+		{
+			name:        "overridden",
+			validator:   Validator{Profile: "node-1", NetAddress: "tcp://10.0.0.5:9001", StakedAmount: 5000},
+			wantAddress: "tcp://10.0.0.5:9001",
+			wantStake:   5000,
+		},
+		{name: "no scheme", validator: Validator{Profile: "node-1", NetAddress: "10.0.0.5:9001"}, wantErr: true},
+		{name: "no host", validator: Validator{Profile: "node-1", NetAddress: "tcp://"}, wantErr: true},
+		{name: "not a URL", validator: Validator{Profile: "node-1", NetAddress: "tcp://[::1"}, wantErr: true},
+		{name: "negative stake", validator: Validator{Profile: "node-1", StakedAmount: -1}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatorOverrideError(test.validator)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("validatorOverrideError() = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if got := validatorNetAddress(test.validator); got != test.wantAddress {
+				t.Errorf("validatorNetAddress() = %q, want %q", got, test.wantAddress)
+			}
+			if got := validatorStake(test.validator); got != test.wantStake {
+				t.Errorf("validatorStake() = %d, want %d", got, test.wantStake)
+			}
+		})
+	}
+}
+
+func TestGenesisAccountsStakedAmount(t *testing.T) {
+	config := Config{
+		Validators:  []Validator{{Profile: "node-1", Key: 0, StakedAmount: 3 * defaultStakedAmount}, {Profile: "node-2", Key: 1}},
+		StakeBuffer: 10,
+	}
+	want := []Account{
+		{Address: "addr0", Amount: 3*defaultStakedAmount + 10},
+		{Address: "addr1", Amount: defaultStakedAmount + 10},
+	}
+	if got := genesisAccounts(config, testKeys(2)); !reflect.DeepEqual(got, want) {
+		t.Errorf("genesisAccounts() = %v, want %v", got, want)
+	}
+
+	config.Accounts = []Account{{Address: "addr0", Amount: defaultStakedAmount}}
+	if problems := fundingProblems(config, testKeys(2)); len(problems) != 1 {
+		t.Errorf("fundingProblems() = %v, want 1 problem for the underfunded custom stake", problems)
+	}
+}
+
+func TestCheckInputs(t *testing.T) {
+	genesis := `{"time": "", "accounts": [], "nonSigners": [], "validators": [], "params": {"consensus": "fixture"}}`
+	tests := []struct {
+		name    string
+		genesis string
+		modify  func(in *inputs)
+		wantErr string
+	}{
+		{name: "valid", genesis: genesis},
+		{name: "missing keys", genesis: `{"time": "", "params": {}}`, wantErr: "templates/genesis.json: missing top-level keys accounts, nonSigners, validators"},
+		{name: "null genesis", genesis: `null`, wantErr: "missing top-level keys time"},
+		{
+			name:    "params not an object",
+			genesis: `{"time": "", "accounts": [], "nonSigners": [], "validators": [], "params": []}`,
+			modify:  func(in *inputs) { in.Genesis.Params = []interface{}{} },
+			wantErr: "templates/genesis.json: params is not an object",
+		},
+		{name: "empty config template", genesis: genesis, modify: func(in *inputs) { in.ConfigTemplate = nil }, wantErr: "templates/config.yaml: expected a non-empty object"},
+		{name: "no keys", genesis: genesis, modify: func(in *inputs) { in.Keys.Keys = nil }, wantErr: "keys/node-bls.json: no keys"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := testInputs(t, 2)
+			if test.modify != nil {
+				test.modify(in)
+			}
+			err := checkInputs(in, "templates/genesis.json", []byte(test.genesis), "templates/config.yaml")
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("checkInputs() = %v, want an error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// testInputs returns in-memory inputs for n keys, so generate runs without templates, keys on disk
+// or jq. The keystore has entries for every key except those listed in missing
+func testInputs(t *testing.T, n int, missing ...int) *inputs {
+	t.Helper()
+	keys := testKeys(n)
+	skip := make(map[int]bool)
+	for _, i := range missing {
+		skip[i] = true
+	}
+	addressMap := make(map[string]interface{})
+	nicknameMap := make(map[string]string)
+	for i, key := range keys.Keys {
+		if skip[i] {
+			continue
+		}
+		addressMap[key.Address] = map[string]string{"publicKey": key.PublicKey, "keyAddress": key.Address}
+		nicknameMap[fmt.Sprintf("nick-%d", i)] = key.Address
+	}
+	keystore, err := json.Marshal(map[string]interface{}{"addressMap": addressMap, "nicknameMap": nicknameMap})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &inputs{
+		Genesis:        Genesis{Params: map[string]interface{}{"consensus": "fixture"}},
+		ConfigTemplate: map[string]interface{}{"logLevel": "info", "dataDirPath": "/root/${PROFILE}"},
+		Keys:           keys,
+		Keystore:       keystore,
+	}
+}
+
+// generateFixture runs generate over in-memory inputs, keeping the template key order so jq isn't
+// needed
+func generateFixture(t *testing.T, config Config, in *inputs, opts options) ([]generatedNode, error) {
+	t.Helper()
+	opts.NoSort = true
+	opts.GenesisTime = "2025-01-01 00:00:00"
+	if opts.OutDir == "" {
+		opts.OutDir = "out"
+	}
+	return generate("fixture", config, in, opts)
+}
+
+// nodeFile returns a generated file of a node
+func nodeFile(t *testing.T, node generatedNode, name string) []byte {
+	t.Helper()
+	for _, file := range node.Files {
+		if file.Name == name {
+			return file.Data
+		}
+	}
+	t.Fatalf("%s has no %s", node.Profile, name)
+	return nil
+}
+
+// nodeConfigMap decodes the config.json a node was generated with
+func nodeConfigMap(t *testing.T, node generatedNode) map[string]interface{} {
+	t.Helper()
+	var config map[string]interface{}
+	if err := json.Unmarshal(nodeFile(t, node, "config.json"), &config); err != nil {
+		t.Fatalf("%s config.json: %v", node.Profile, err)
+	}
+	return config
+}
+
+func TestGenerateNodeConfigs(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", Key: 2, ChainID: 1, Committees: CommitteeList{1}},
+		{Profile: "node-2", Key: 0, ChainID: 2, RootChainID: 1, Nested: true, EthOracle: true, Committees: CommitteeList{1, 2}},
+	}}
+	nodes, err := generateFixture(t, config, testInputs(t, 3), options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("generated %d nodes, want 2", len(nodes))
+	}
+
+	tests := []struct {
+		node       generatedNode
+		want       map[string]interface{}
+		wantOracle bool
+		wantKey    string
+	}{
+		{
+			node: nodes[0],
+			want: map[string]interface{}{
+				"walletPort": "50000", "rpcPort": "50002", "adminPort": "50003",
+				"listenAddress": "127.0.0.101:9001", "externalAddress": "node-1",
+				"rpcURL": "http://node-1:50002", "chainId": 1.0, "dataDirPath": "/root/node-1", "logLevel": "info",
+			},
+			wantKey: `"priv2"`,
+		},
+		{
+			node: nodes[1],
+			want: map[string]interface{}{
+				"walletPort": "40000", "rpcPort": "40002", "adminPort": "40003",
+				"listenAddress": "127.0.0.102:9002", "chainId": 2.0, "runVDF": false, "dataDirPath": "/root/node-2",
+			},
+			wantOracle: true,
+			wantKey:    `"priv0"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.node.Profile, func(t *testing.T) {
+			if test.node.Dir != "out/fixture-"+test.node.Profile {
+				t.Errorf("Dir = %q", test.node.Dir)
+			}
+			nodeConfig := nodeConfigMap(t, test.node)
+			for key, want := range test.want {
+				if got := nodeConfig[key]; got != want {
+					t.Errorf("config %s = %v, want %v", key, got, want)
+				}
+			}
+			_, hasOracle := nodeConfig["oracleConfig"]
+			_, hasProvider := nodeConfig["ethBlockProviderConfig"]
+			if hasOracle != test.wantOracle || hasProvider != test.wantOracle {
+				t.Errorf("eth oracle config injected = %t/%t, want %t", hasOracle, hasProvider, test.wantOracle)
+			}
+			if got := string(nodeFile(t, test.node, "validator_key.json")); got != test.wantKey {
+				t.Errorf("validator_key.json = %s, want %s", got, test.wantKey)
+			}
+		})
+	}
+}
+
+func TestGenerateGenesis(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", Key: 1, ChainID: 1, Committees: CommitteeList{1}},
+		{Profile: "node-2", Key: 0, ChainID: 1, Committees: CommitteeList{1, 3}, NetAddress: "tcp://10.0.0.2:9001", StakedAmount: 7},
+	}}
+	nodes, err := generateFixture(t, config, testInputs(t, 2), options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// every node gets the same genesis
+	genesisData := nodeFile(t, nodes[0], "genesis.json")
+	if other := nodeFile(t, nodes[1], "genesis.json"); string(other) != string(genesisData) {
+		t.Error("nodes were generated with different genesis files")
+	}
+	var genesis Genesis
+	if err := json.Unmarshal(genesisData, &genesis); err != nil {
+		t.Fatalf("genesis.json: %v", err)
+	}
+
+	if genesis.Time != "2025-01-01 00:00:00" {
+		t.Errorf("Time = %q", genesis.Time)
+	}
+	want := []Validator{
+		{Address: "addr1", PublicKey: "pub1", Output: "addr1", Committees: CommitteeList{1}, NetAddress: "tcp://node-1", StakedAmount: defaultStakedAmount, Compound: true},
+		{Address: "addr0", PublicKey: "pub0", Output: "addr0", Committees: CommitteeList{1, 3}, NetAddress: "tcp://10.0.0.2:9001", StakedAmount: 7, Compound: true},
+	}
+	if !reflect.DeepEqual(genesis.Validators, want) {
+		t.Errorf("Validators = %+v, want %+v", genesis.Validators, want)
+	}
+	if len(genesis.Accounts) != 2 {
+		t.Errorf("Accounts = %v, want one per key", genesis.Accounts)
+	}
+	if params, _ := genesis.Params.(map[string]interface{}); params["consensus"] != "fixture" {
+		t.Errorf("Params = %v, want the template params", genesis.Params)
+	}
+}
+
+func TestGenerateNodeSelection(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", Key: 0, ChainID: 1},
+		{Profile: "node-2", Key: 1, ChainID: 1},
+		{Profile: "node-3", Key: 2, ChainID: 1},
+	}}
+	// node-2's key is missing from the keystore, so its keystore.json can't be built
+	in := testInputs(t, 3, 1)
+
+	tests := []struct {
+		name       string
+		opts       options
+		wantNodes  []string
+		wantFailed []string
+		wantErr    bool
+	}{
+		{name: "failure aborts", opts: options{}, wantErr: true},
+		{name: "continue on error", opts: options{ContinueOnError: true}, wantNodes: []string{"node-1", "node-2", "node-3"}, wantFailed: []string{"node-2"}},
+		{name: "only skips the failing node", opts: options{Only: []string{"node-3", "node-1"}}, wantNodes: []string{"node-1", "node-3"}},
+		{name: "only unknown node", opts: options{Only: []string{"node-9"}}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := generateFixture(t, config, in, test.opts)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("generate() error = %v, wantErr %v", err, test.wantErr)
+			}
+			var profiles, failed []string
+			for _, node := range nodes {
+				profiles = append(profiles, node.Profile)
+				if node.Err != nil {
+					failed = append(failed, node.Profile)
+					if len(node.Files) != 0 {
+						t.Errorf("failed node %s has files", node.Profile)
+					}
+				}
+			}
+			if strings.Join(profiles, ",") != strings.Join(test.wantNodes, ",") {
+				t.Errorf("nodes = %v, want %v", profiles, test.wantNodes)
+			}
+			if strings.Join(failed, ",") != strings.Join(test.wantFailed, ",") {
+				t.Errorf("failed nodes = %v, want %v", failed, test.wantFailed)
+			}
+		})
+	}
+}
+
+func TestGenerateUnknownProfile(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", Key: 0, ChainID: 1},
+		{Profile: "node-9", Key: 1, ChainID: 1},
+		{Profile: "node-3", Key: 2, ChainID: 1},
+	}}
+	in := testInputs(t, 3)
+
+	if _, err := generateFixture(t, config, in, options{}); err == nil || !strings.Contains(err.Error(), "node-9") {
+		t.Fatalf("generate() error = %v, want node-9's missing ports", err)
+	}
+
+	nodes, err := generateFixture(t, config, in, options{ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("nodes = %d, want 3", len(nodes))
+	}
+	for _, node := range nodes {
+		failed := node.Err != nil
+		if failed != (node.Profile == "node-9") {
+			t.Errorf("node %s error = %v", node.Profile, node.Err)
+		}
+		if !failed && len(node.Files) == 0 {
+			t.Errorf("node %s has no files", node.Profile)
+		}
+	}
+}
+
+func TestGenerateOnly(t *testing.T) {
+	config := Config{Validators: []Validator{{Profile: "node-1", Key: 0, ChainID: 1}}}
+	tests := []struct {
+		name     string
+		opts     options
+		want     string
+		wantKeys int
+	}{
+		{name: "all files", opts: options{}, want: "genesis.json, config.json, validator_key.json and keystore.json", wantKeys: 1},
+		{name: "genesis only", opts: options{GenesisOnly: true}, want: "genesis.json"},
+		{name: "config only", opts: options{ConfigOnly: true}, want: "config.json"},
+		{name: "no keystore", opts: options{NoKeystore: true}, want: "genesis.json, config.json and validator_key.json", wantKeys: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := generateFixture(t, config, testInputs(t, 1), test.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := nodes[0].fileNames(); got != test.want {
+				t.Errorf("files = %q, want %q", got, test.want)
+			}
+			// the summary only reports how validator keys were written when there are any
+			if got := validatorKeyCount(nodes); got != test.wantKeys {
+				t.Errorf("validatorKeyCount() = %d, want %d", got, test.wantKeys)
+			}
+		})
+	}
+}
+
+func TestGenerateKeystore(t *testing.T) {
+	config := Config{Validators: []Validator{{Profile: "node-1", Key: 0, ChainID: 1}}}
+	in := testInputs(t, 3)
+
+	nodes, err := generateFixture(t, config, in, options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var keystore crypto.Keystore
+	if err := json.Unmarshal(nodeFile(t, nodes[0], "keystore.json"), &keystore); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keystore.AddressMap) != 1 || keystore.AddressMap["addr0"] == nil || keystore.NicknameMap["nick-0"] != "addr0" {
+		t.Errorf("keystore.json = %+v, want only addr0 by default", keystore)
+	}
+
+	nodes, err = generateFixture(t, config, in, options{SharedKeystore: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := nodeFile(t, nodes[0], "keystore.json"); string(got) != string(in.Keystore) {
+		t.Errorf("keystore.json = %s, want the full keystore with --shared-keystore", got)
+	}
+}
+
+func TestTOMLProfileMatchesYAML(t *testing.T) {
+	yamlProfile := `
+stake_buffer: 5000
+vars:
+  LOG_LEVEL: debug
+accounts:
+  - address: extra-account
+    amount: 42
+validators:
+  - profile: node-1
+    key: 0
+    chainId: 1
+    rootChainId: 1
+    committees: [1, 2]
+  - profile: node-2
+    key: 1
+    chainId: 2
+    rootChainId: 1
+    nested: true
+    eth_oracle: true
+    committees: "1,2"
+    net_address: tcp://10.0.0.5:9001
+    staked_amount: 2000000000
+    listen_host: 0.0.0.0
+`
+	tomlProfile := `
+stake_buffer = 5000
+
+[vars]
+LOG_LEVEL = "debug"
+
+[[accounts]]
+address = "extra-account"
+amount = 42
+
+[[validators]]
+profile = "node-1"
+key = 0
+chainId = 1
+rootChainId = 1
+committees = [1, 2]
+
+[[validators]]
+profile = "node-2"
+key = 1
+chainId = 2
+rootChainId = 1
+nested = true
+eth_oracle = true
+committees = "1,2"
+net_address = "tcp://10.0.0.5:9001"
+staked_amount = 2000000000
+listen_host = "0.0.0.0"
+`
+	fromYAML, err := parseProfile("fixture.yaml", []byte(yamlProfile))
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	fromTOML, err := parseProfile("fixture.toml", []byte(tomlProfile))
+	if err != nil {
+		t.Fatalf("toml: %v", err)
+	}
+	if !reflect.DeepEqual(fromTOML, fromYAML) {
+		t.Fatalf("toml profile = %+v, want %+v", fromTOML, fromYAML)
+	}
+
+	in := testInputs(t, 2)
+	yamlNodes, err := generateFixture(t, fromYAML, in, options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tomlNodes, err := generateFixture(t, fromTOML, in, options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tomlNodes, yamlNodes) {
+		t.Error("toml and yaml profiles generated different nodes")
+	}
+
+	if _, err := parseProfile("fixture.toml", []byte(`[[validators]]
+committees = [1, "two"]`)); err == nil {
+		t.Error("expected error for a non-integer committee")
+	}
+}
+
+func TestSortConfigJQError(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq is not installed")
+	}
+	sorted, err := sortConfig([]byte(`{"b": 1, "a": 2}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(sorted), []byte("{\n  \"a\"")) {
+		t.Errorf("config not sorted: %s", sorted)
+	}
+
+	_, err = sortConfig([]byte(`{"a": `))
+	if err == nil {
+		t.Fatal("expected error for malformed json")
+	}
+	// jq's own complaint follows the exit status
+	if !strings.Contains(err.Error(), "exit status") || strings.HasSuffix(err.Error(), "exit status 2") {
+		t.Errorf("error %q doesn't include jq's stderr", err)
+	}
+}
+
+func TestFallBackWithoutJQ(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	opts := options{}
+	if !fallBackWithoutJQ(&opts) || !opts.NoSort {
+		t.Error("expected an unsorted config.json without jq on PATH")
+	}
+	if fallBackWithoutJQ(&opts) {
+		t.Error("--no-sort needs no fallback")
+	}
+}
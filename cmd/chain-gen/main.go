@@ -2,40 +2,52 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
 type Account struct {
-	Address string `json:"address" yaml:"address"`
-	Amount  int64  `json:"amount" yaml:"amount"`
+	Address string `json:"address" yaml:"address" toml:"address" schema:"required" desc:"Account address (hex, no 0x prefix)"`
+	Amount  int64  `json:"amount" yaml:"amount" toml:"amount" schema:"required" desc:"Account balance in uCNPY"`
 }
 
 type Validator struct {
-	Address         string `json:"address,omitempty"`
-	PublicKey       string `json:"publicKey,omitempty"`
-	Committees      []int  `json:"committees"`
-	NetAddress      string `json:"netAddress,omitempty"`
-	StakedAmount    int64  `json:"stakedAmount,omitempty"`
-	Output          string `json:"output,omitempty"`
-	MaxPausedHeight int64  `json:"maxPausedHeight,omitempty"`
-	UnstakingHeight int64  `json:"unstakingHeight,omitempty"`
-	Delegate        bool   `json:"delegate,omitempty"`
-	Compound        bool   `json:"compound,omitempty"`
-
-	Profile     string `yaml:"profile" json:"-"`
-	Key         int    `yaml:"key" json:"-"`
-	ChainID     int    `yaml:"chainId" json:"-"`
-	RootChainID int    `yaml:"rootChainId" json:"-"`
-	Nested      bool   `yaml:"nested" json:"-"`
-	EthOracle   bool   `yaml:"eth_oracle" json:"-"`
+	Address         string        `json:"address,omitempty"`
+	PublicKey       string        `json:"publicKey,omitempty"`
+	Committees      CommitteeList `json:"committees" yaml:"committees" toml:"committees" desc:"Committee IDs the validator is staked for, as a list or a comma-separated string"`
+	NetAddress      string        `json:"netAddress,omitempty" yaml:"net_address" toml:"net_address" desc:"Validator net address URL with a scheme, e.g. tcp://10.0.0.5:9001; defaults to tcp://<profile>"`
+	StakedAmount    int64         `json:"stakedAmount,omitempty" yaml:"staked_amount" toml:"staked_amount" desc:"Genesis stake in uCNPY; defaults to 1000000000"`
+	Output          string        `json:"output,omitempty"`
+	MaxPausedHeight int64         `json:"maxPausedHeight,omitempty"`
+	UnstakingHeight int64         `json:"unstakingHeight,omitempty"`
+	Delegate        bool          `json:"delegate,omitempty"`
+	Compound        bool          `json:"compound,omitempty"`
+
+	Profile     string `yaml:"profile" toml:"profile" json:"-" schema:"required" desc:"Node profile name (node-1, node-2, node-3); selects ports and the output directory"`
+	Key         int    `yaml:"key" toml:"key" json:"-" schema:"required" desc:"Index of the validator key in keys/node-bls.json"`
+	ChainID     int    `yaml:"chainId" toml:"chainId" json:"-" schema:"required" desc:"Chain ID the node runs"`
+	RootChainID int    `yaml:"rootChainId" toml:"rootChainId" json:"-" desc:"Root chain ID of the node's chain"`
+	Nested      bool   `yaml:"nested" toml:"nested" json:"-" desc:"Nested chain; disables runVDF in the node config"`
+	EthOracle   bool   `yaml:"eth_oracle" toml:"eth_oracle" json:"-" desc:"Inject the eth oracle configuration into the node config"`
+	ListenHost  string `yaml:"listen_host" toml:"listen_host" json:"-" desc:"P2P listen host (IPv4, IPv6 or hostname); defaults to the profile's loopback address"`
+	MetricsPort int    `yaml:"metrics_port" toml:"metrics_port" json:"-" desc:"Port --prometheus scrapes the node's metrics on; defaults to the admin port"`
+}
+
+// NonSigner is a chain-profile entry marking a validator key as a genesis non-signer
+type NonSigner struct {
+	Key     int    `yaml:"key" toml:"key" schema:"required" desc:"Index of the validator key in keys/node-bls.json"`
+	Counter uint64 `yaml:"counter" toml:"counter" desc:"Blocks the validator has not signed in the current non-sign window; defaults to 1"`
 }
 
 type Genesis struct {
@@ -50,6 +62,8 @@ type KeyPair struct {
 	PrivateKey string `json:"privateKey"`
 	PublicKey  string `json:"publicKey"`
 	Address    string `json:"address"`
+	Role       string `json:"role,omitempty"`   // role keygen tagged the key with, informational
+	Amount     int64  `json:"amount,omitempty"` // genesis amount keygen tagged the key with, replacing defaultAccountAmount
 }
 
 type KeyOutput struct {
@@ -57,228 +71,376 @@ type KeyOutput struct {
 	Keys      []KeyPair `json:"keys"`
 }
 
+// genesisTimeFormat is the layout of the genesis time
+const genesisTimeFormat = "2006-01-02 15:04:05"
+
 type Config struct {
-	Accounts   []Account   `yaml:"accounts"`
-	Validators []Validator `yaml:"validators"`
+	Accounts    []Account                         `yaml:"accounts" toml:"accounts" desc:"Explicit genesis account amounts by address; every key in keys/node-bls.json is funded by default"`
+	Validators  []Validator                       `yaml:"validators" toml:"validators" schema:"required" desc:"Validator nodes to generate"`
+	StakeBuffer int64                             `yaml:"stake_buffer" toml:"stake_buffer" desc:"uCNPY funded to each validator's account on top of its stake, covering fees"`
+	NonSigners  []NonSigner                       `yaml:"non_signers" toml:"non_signers" desc:"Validators recorded as non-signers in the genesis"`
+	Vars        map[string]string                 `yaml:"vars" toml:"vars" desc:"Values of ${VAR} placeholders in config template strings; PROFILE, CHAIN_PROFILE, CHAIN_ID, ROOT_CHAIN_ID and NODE_INDEX are derived per node"`
+	ParamsFile  string                            `yaml:"params_file" toml:"params_file" desc:"JSON file of genesis params merged over templates/genesis.json's, relative to the chain profile"`
+	Params      map[string]interface{}            `yaml:"params" toml:"params" desc:"Genesis params merged over params_file and templates/genesis.json, e.g. {fee: {sendFee: 10000}}"`
+	ChainParams map[string]map[string]interface{} `yaml:"chain_params" toml:"chain_params" desc:"Genesis params by chain ID, merged over params in the genesis of the nodes running that chain"`
 }
 
-func getPortsForProfile(profile string, chainId int) (string, string, string, string, string, string) {
+// getPortsForProfile returns the wallet, explorer, rpc, admin and p2p listen ports and the listen
+// host of a node profile, or an error for a profile without built-in ports
+func getPortsForProfile(profile string, chainId int) (string, string, string, string, string, string, error) {
 	listenPort := fmt.Sprintf("%d", 9000+chainId)
 	
 	switch profile {
 	case "node-1":
-		return "50000", "50001", "50002", "50003", listenPort, "127.0.0.101"
+		return "50000", "50001", "50002", "50003", listenPort, "127.0.0.101", nil
 	case "node-2":
-		return "40000", "40001", "40002", "40003", listenPort, "127.0.0.102"
+		return "40000", "40001", "40002", "40003", listenPort, "127.0.0.102", nil
 	case "node-3":
-		return "30000", "30001", "30002", "30003", listenPort, "127.0.0.103"
+		return "30000", "30001", "30002", "30003", listenPort, "127.0.0.103", nil
 	default:
-		panic("can't use default ports")
+		return "", "", "", "", "", "", fmt.Errorf("node profile %s has no built-in ports, expected one of %s",
+			profile, strings.Join(portProfiles, ", "))
 	}
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s <chain-profile-name>", os.Args[0])
-	}
-	chainProfileName := os.Args[1]
+// profilesDir is the directory chain profiles are read from
+const profilesDir = "chain-profiles"
 
-	genesisData, err := ioutil.ReadFile("templates/genesis.json")
-	if err != nil {
-		log.Fatalf("Error reading genesis.json: %v", err)
-	}
+// profileExtensions are the chain profile formats, by file extension
+var profileExtensions = []string{".yaml", ".toml"}
+
+// loadProfile reads and parses chain-profiles/<name>.yaml or chain-profiles/<name>.toml
+func loadProfile(name string) (Config, error) {
+	return loadProfileFrom(profilesDir, name)
+}
 
-	configTemplateData, err := ioutil.ReadFile("templates/config.json")
+// loadProfileFrom reads and parses the chain profile name in dir
+func loadProfileFrom(dir, name string) (Config, error) {
+	var config Config
+	configPath, err := profilePath(dir, name)
 	if err != nil {
-		log.Fatalf("Error reading templates/config.json: %v", err)
+		return config, err
 	}
-
-	configPath := fmt.Sprintf("chain-profiles/%s.yaml", chainProfileName)
 	configData, err := ioutil.ReadFile(configPath)
 	if err != nil {
-		log.Fatalf("Error reading %s: %v", configPath, err)
+		return config, fmt.Errorf("error reading %s: %w", configPath, err)
 	}
+	return parseProfile(configPath, configData)
+}
 
-	keysData, err := ioutil.ReadFile("keys/node-bls.json")
-	if err != nil {
-		log.Fatalf("Error reading keys/node-bls.json: %v", err)
+// profilePath returns the path of the chain profile name in dir, in whichever format exists. A
+// missing profile resolves to the YAML path, so the read error names the usual file
+func profilePath(dir, name string) (string, error) {
+	var found []string
+	for _, ext := range profileExtensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return filepath.Join(dir, name+profileExtensions[0]), nil
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("chain profile %s is ambiguous: %s", name, strings.Join(found, " and "))
 	}
+}
 
-	keystoreData, err := ioutil.ReadFile("keys/keystore.json")
+// parseProfile parses a chain profile in the format of its file extension
+func parseProfile(configPath string, configData []byte) (Config, error) {
+	var config Config
+	var err error
+	if filepath.Ext(configPath) == ".toml" {
+		err = toml.Unmarshal(configData, &config)
+	} else {
+		err = yaml.Unmarshal(configData, &config)
+	}
 	if err != nil {
-		log.Fatalf("Error reading keys/keystore.json: %v", err)
+		return config, fmt.Errorf("error parsing %s: %w", configPath, err)
 	}
-
-	var genesis Genesis
-	if err := json.Unmarshal(genesisData, &genesis); err != nil {
-		log.Fatalf("Error parsing genesis.json: %v", err)
+	// params_file is relative to the chain profile that names it
+	if config.ParamsFile != "" && !filepath.IsAbs(config.ParamsFile) {
+		config.ParamsFile = filepath.Join(filepath.Dir(configPath), config.ParamsFile)
 	}
+	return config, nil
+}
 
-	var configTemplate map[string]interface{}
-	if err := json.Unmarshal(configTemplateData, &configTemplate); err != nil {
-		log.Fatalf("Error parsing templates/config.json: %v", err)
+// profileNames returns the names of the chain profiles in dir, sorted
+func profileNames(dir string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, ext := range profileExtensions {
+		paths, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			name := strings.TrimSuffix(filepath.Base(path), ext)
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
 	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no chain profiles in %s", dir)
+	}
+	sort.Strings(names)
+	return names, nil
+}
 
-	var config Config
-	if err := yaml.Unmarshal(configData, &config); err != nil {
-		log.Fatalf("Error parsing default.yaml: %v", err)
+// nodeDir returns the output directory of a validator node for a chain profile
+func nodeDir(outDir, chainProfileName, profile string) string {
+	return filepath.Join(outDir, fmt.Sprintf("%s-%s", chainProfileName, profile))
+}
+
+// nodeListenAddress returns the p2p listen address of a validator node, preferring the
+// profile's listen_host (an IPv4/IPv6 literal or hostname) over the default loopback address
+func nodeListenAddress(validator Validator, listenAddr, listenPort string) string {
+	host := listenAddr
+	if validator.ListenHost != "" {
+		host = strings.Trim(validator.ListenHost, "[]")
 	}
+	return net.JoinHostPort(host, listenPort)
+}
 
-	var keyOutput KeyOutput
-	if err := json.Unmarshal(keysData, &keyOutput); err != nil {
-		log.Fatalf("Error parsing keys/node-bls.json: %v", err)
+func main() {
+	outDir := flag.String("out-dir", "data-dir", "Directory the node data-dirs are generated into")
+	templatesDir := flag.String("templates-dir", "templates", "Directory holding genesis.json and the config template (config.json, config.yaml or config.yml)")
+	printValidators := flag.String("print-validators", "", "Print the genesis validator set generated for a chain profile and exit")
+	listProfiles := flag.Bool("list-profiles", false, "List the chain profiles with their validator count, chain IDs and eth oracle use and exit")
+	printSchema := flag.Bool("print-schema", false, "Print the JSON Schema of the chain-profile format and exit")
+	printOracle := flag.String("print-oracle-config", "", "Print the eth oracle config each eth_oracle validator of a chain profile receives, without writing files, and exit")
+	printDefaults := flag.Bool("template-defaults", false, "Print the built-in port mapping of each node profile and the default eth oracle config as JSON and exit")
+	verify := flag.String("verify", "", "Regenerate a chain profile in memory and report drift from the files in the out-dir")
+	validate := flag.String("validate", "", "Check a chain profile for key, port, committee and funding problems without writing files, exiting non-zero if any are found")
+	noKeystore := flag.Bool("no-keystore", false, "Don't write keystore.json into the node directories, for nodes that load their keys another way")
+	sharedKeystore := flag.Bool("shared-keystore", false, "Copy the full keys/keystore.json into every node instead of only the node's own key, for the E2E suite signing as other keystore entries through a node")
+	keyFormat := flag.String("key-format", keyFormatRawString, "validator_key.json format: raw-string or json-object")
+	noSort := flag.Bool("no-sort", false, "Keep the config template's key order instead of sorting config.json")
+	lenient := flag.Bool("lenient", false, "Report out-of-range validator keys as warnings instead of errors")
+	encryptValidatorKey := flag.Bool("encrypt-validator-key", false, "Write validator_key.json as an encrypted keystore entry instead of the plaintext private key")
+	validatorKeyPassword := flag.String("validator-key-password", "test", "Password validator_key.json is encrypted with (default matches keygen)")
+	genesisOnly := flag.Bool("genesis-only", false, "Write only genesis.json to each node, leaving config.json, validator_key.json and keystore.json alone")
+	configOnly := flag.Bool("config-only", false, "Write only config.json to each node, leaving genesis.json, validator_key.json and keystore.json alone")
+	prometheus := flag.String("prometheus", "", "Also write a prometheus.yml scrape_configs block with one target per node to this path")
+	archive := flag.String("archive", "", "After generating, package the node directories and a manifest.json into this .tar.gz")
+	roster := flag.String("roster", "", "Also write a JSON roster of the validators' profile, address, public key, committees and ports to this path")
+	all := flag.Bool("all", false, "Generate every chain profile (.yaml or .toml) in chain-profiles/ instead of a single named profile")
+	continueOnError := flag.Bool("continue-on-error", false, "Keep generating the remaining nodes when one fails, then exit non-zero listing the failed nodes")
+	only := flag.String("only", "", "Comma-separated node profiles to generate (e.g. node-2); the genesis still covers every validator")
+	genesisTime := flag.String("genesis-time", "", "Pin the genesis time (\"2006-01-02 15:04:05\"); defaults to now, or to the on-disk genesis time with --verify")
+	flag.Parse()
+
+	if *printSchema {
+		schemaOutput, err := json.MarshalIndent(profileSchema(), "", "  ")
+		if err != nil {
+			log.Fatalf("Error marshaling schema: %v", err)
+		}
+		fmt.Println(string(schemaOutput))
+		return
 	}
 
-	genesis.Time = time.Now().Format("2006-01-02 15:04:05")
+	if *printDefaults {
+		if err := printTemplateDefaults(); err != nil {
+			log.Fatalf("Error printing template defaults: %v", err)
+		}
+		return
+	}
 
-	// Create accounts from all BLS keys
-	var accounts []Account
-	for _, key := range keyOutput.Keys {
-		account := Account{
-			Address: key.Address,
-			Amount:  1000000000,
+	if *listProfiles {
+		if err := printProfileList(profilesDir); err != nil {
+			log.Fatalf("Error listing chain profiles: %v", err)
 		}
-		accounts = append(accounts, account)
-	}
-	genesis.Accounts = accounts
-
-	if len(config.Validators) > 0 {
-		// Build all validators first
-		mergedValidators := make([]Validator, len(config.Validators))
-		for i, configValidator := range config.Validators {
-			validator := Validator{
-				Committees:      configValidator.Committees,
-				NetAddress:      fmt.Sprintf("tcp://%s", configValidator.Profile),
-				StakedAmount:    1000000000,
-				MaxPausedHeight: 0,
-				UnstakingHeight: 0,
-				Delegate:        false,
-				Compound:        true,
-			}
+		return
+	}
 
-			// Use the key field to reference the correct key from node-bls.json
-			keyIndex := configValidator.Key
-			if keyIndex >= 0 && keyIndex < len(keyOutput.Keys) {
-				key := keyOutput.Keys[keyIndex]
-				validator.Address = key.Address
-				validator.PublicKey = key.PublicKey
-				validator.Output = key.Address
-			}
+	if *printValidators != "" {
+		if err := printGenesisValidators(*outDir, *printValidators); err != nil {
+			log.Fatalf("Error printing validators: %v", err)
+		}
+		return
+	}
 
-			mergedValidators[i] = validator
+	opts := options{
+		OutDir:          *outDir,
+		TemplatesDir:    *templatesDir,
+		GenesisTime:     *genesisTime,
+		SharedKeystore:  *sharedKeystore,
+		NoKeystore:      *noKeystore,
+		KeyFormat:       *keyFormat,
+		NoSort:          *noSort,
+		ContinueOnError: *continueOnError,
+		GenesisOnly:     *genesisOnly,
+		ConfigOnly:      *configOnly,
+		Roster:          *roster,
+		Prometheus:      *prometheus,
+		Archive:         *archive,
+
+		EncryptValidatorKey:  *encryptValidatorKey,
+		ValidatorKeyPassword: *validatorKeyPassword,
+	}
+	if *only != "" {
+		for _, profile := range strings.Split(*only, ",") {
+			opts.Only = append(opts.Only, strings.TrimSpace(profile))
 		}
+	}
+
+	if opts.KeyFormat != keyFormatRawString && opts.KeyFormat != keyFormatJSONObject {
+		log.Fatalf("Invalid --key-format %q: expected %s or %s", opts.KeyFormat, keyFormatRawString, keyFormatJSONObject)
+	}
+	if opts.EncryptValidatorKey && opts.KeyFormat != keyFormatRawString {
+		log.Fatalf("--encrypt-validator-key replaces --key-format %s", opts.KeyFormat)
+	}
+	if fallBackWithoutJQ(&opts) {
+		log.Printf("Warning: jq isn't on PATH, writing config.json in the template's key order as with --no-sort")
+	}
+	if opts.NoKeystore && opts.SharedKeystore {
+		log.Fatalf("--no-keystore and --shared-keystore can't be combined")
+	}
+	if opts.GenesisOnly && opts.ConfigOnly {
+		log.Fatalf("--genesis-only and --config-only can't be combined")
+	}
+	if *all && len(opts.Only) > 0 {
+		log.Fatalf("--only selects nodes of a single chain profile and can't be combined with --all")
+	}
+	if *all && opts.Roster != "" {
+		log.Fatalf("--roster describes a single chain profile and can't be combined with --all")
+	}
+	if *all && opts.Prometheus != "" {
+		log.Fatalf("--prometheus describes a single chain profile and can't be combined with --all")
+	}
+	if *all && opts.Archive != "" {
+		log.Fatalf("--archive packages a single chain profile and can't be combined with --all")
+	}
 
-		// Set all validators in the genesis
-		genesis.Validators = mergedValidators
+	if *printOracle != "" {
+		if err := printOracleConfig(*printOracle, opts); err != nil {
+			log.Fatalf("Error printing oracle config of %s: %v", *printOracle, err)
+		}
+		return
+	}
 
-		// Generate files for each validator node
-		for _, configValidator := range config.Validators {
-			// Create directory structure
-			dirName := fmt.Sprintf("%s-%s", chainProfileName, configValidator.Profile)
-			dirPath := filepath.Join("data-dir", dirName)
+	if *verify != "" {
+		if err := verifyProfile(*verify, opts); err != nil {
+			log.Fatalf("Error verifying %s: %v", *verify, err)
+		}
+		return
+	}
 
-			err := os.MkdirAll(dirPath, 0755)
-			if err != nil {
-				log.Fatalf("Error creating directory %s: %v", dirPath, err)
-			}
+	if *validate != "" {
+		if err := validateProfile(*validate, opts); err != nil {
+			log.Fatalf("Error validating %s: %v", *validate, err)
+		}
+		return
+	}
 
-			// Generate genesis.json (same for all nodes)
-			genesisOutput, err := json.MarshalIndent(genesis, "", "  ")
-			if err != nil {
-				log.Fatalf("Error marshaling genesis output: %v", err)
-			}
+	if !*all && flag.NArg() < 1 {
+		log.Fatalf("Usage: %s [flags] <chain-profile-name> (or --all)", os.Args[0])
+	}
 
-			genesisFilePath := filepath.Join(dirPath, "genesis.json")
-			err = ioutil.WriteFile(genesisFilePath, genesisOutput, 0644)
-			if err != nil {
-				log.Fatalf("Error writing genesis.json to %s: %v", genesisFilePath, err)
-			}
+	in, err := loadInputs(opts.TemplatesDir)
+	if err != nil {
+		log.Fatalf("Error loading inputs: %v", err)
+	}
 
-			// Generate config.json (unique for each node)
-			nodeConfig := make(map[string]interface{})
-			for k, v := range configTemplate {
-				nodeConfig[k] = v
-			}
+	if opts.GenesisTime == "" {
+		opts.GenesisTime = time.Now().Format(genesisTimeFormat)
+	}
 
-			// Set node-specific ports and addresses
-			walletPort, explorerPort, rpcPort, adminPort, listenPort, listenAddr := getPortsForProfile(configValidator.Profile, configValidator.ChainID)
-			nodeConfig["walletPort"] = walletPort
-			nodeConfig["explorerPort"] = explorerPort
-			nodeConfig["rpcPort"] = rpcPort
-			nodeConfig["adminPort"] = adminPort
-			nodeConfig["listenAddress"] = fmt.Sprintf("%s:%s", listenAddr, listenPort)
-			nodeConfig["externalAddress"] = configValidator.Profile
-			nodeConfig["rpcURL"] = fmt.Sprintf("http://%s:%s", configValidator.Profile, rpcPort)
-			nodeConfig["adminRPCUrl"] = fmt.Sprintf("http://%s:%s", configValidator.Profile, adminPort)
-
-			// Set chainId from YAML configuration
-			nodeConfig["chainId"] = configValidator.ChainID
-
-			// Set runVDF based on nested flag
-			if configValidator.Nested {
-				nodeConfig["runVDF"] = false
-			}
+	if !*all {
+		chainProfileName := flag.Arg(0)
+		if err := runProfile(chainProfileName, in, opts, *lenient); err != nil {
+			log.Fatalf("Error generating %s: %v", chainProfileName, err)
+		}
+		return
+	}
 
-			// Add eth oracle configuration if enabled
-			if configValidator.EthOracle {
-				nodeConfig["ethBlockProviderConfig"] = map[string]interface{}{
-					"ethNodeUrl":              "http://anvil:8545",
-					"ethNodeWsUrl":            "ws://anvil:8545",
-					"ethChainId":              1,
-					"retryDelay":              5,
-					"safeBlockConfirmations":  5,
-				}
-				nodeConfig["oracleConfig"] = map[string]interface{}{
-					"stateSaveFile":       "last_block_height.txt",
-					"orderResubmitDelay":  2,
-					"committee":           2,
-				}
-			}
+	// Generate every chain profile with the same keys, templates and genesis time
+	names, err := profileNames(profilesDir)
+	if err != nil {
+		log.Fatalf("Error listing chain profiles: %v", err)
+	}
+	results := make([]error, len(names))
+	for i, name := range names {
+		fmt.Printf("\n== %s ==\n", name)
+		results[i] = runProfile(name, in, opts, *lenient)
+		if results[i] != nil {
+			log.Printf("Error generating %s: %v", name, results[i])
+		}
+	}
+	if failed := printProfileResults(names, results); failed > 0 {
+		log.Fatalf("%d of %d chain profiles failed", failed, len(names))
+	}
+}
 
-			configOutput, err := json.MarshalIndent(nodeConfig, "", "  ")
-			if err != nil {
-				log.Fatalf("Error marshaling config output: %v", err)
-			}
+// runProfile generates and writes the node files of a chain profile and prints its summary
+func runProfile(chainProfileName string, in *inputs, opts options, lenient bool) error {
+	config, err := loadProfile(chainProfileName)
+	if err != nil {
+		return fmt.Errorf("error loading chain profile: %w", err)
+	}
 
-			configFilePath := filepath.Join(dirPath, "config.json")
-			err = ioutil.WriteFile(configFilePath, configOutput, 0644)
-			if err != nil {
-				log.Fatalf("Error writing config.json to %s: %v", configFilePath, err)
-			}
+	warnings := append(keyIndexProblems(config, in.Keys), nonSignerProblems(config, in.Keys)...)
+	if len(warnings) > 0 && !lenient {
+		return fmt.Errorf("invalid chain profile (use --lenient to continue):\n  %s", strings.Join(warnings, "\n  "))
+	}
+	warnings = append(warnings, fundingProblems(config, in.Keys)...)
+	warnings = append(warnings, committeeProblems(config)...)
 
-			// Sort config.json with jq
-			cmd := exec.Command("jq", "to_entries | sort_by(.key) | from_entries", configFilePath)
-			sortedOutput, err := cmd.Output()
-			if err != nil {
-				log.Fatalf("Error sorting config.json with jq: %v", err)
-			}
+	nodes, err := generate(chainProfileName, config, in, opts)
+	if err != nil {
+		return err
+	}
 
-			err = ioutil.WriteFile(configFilePath, sortedOutput, 0644)
-			if err != nil {
-				log.Fatalf("Error writing sorted config.json: %v", err)
+	filesWritten := 0
+	var failed []string
+	var written []generatedNode
+	for _, node := range nodes {
+		err := node.Err
+		if err == nil {
+			err = writeNode(node)
+		}
+		if err != nil {
+			if !opts.ContinueOnError {
+				return fmt.Errorf("error writing %s: %w", node.Profile, err)
 			}
+			log.Printf("Error generating %s, continuing: %v", node.Profile, err)
+			failed = append(failed, fmt.Sprintf("%s: %v", node.Profile, err))
+			continue
+		}
+		filesWritten += len(node.Files)
+		written = append(written, node)
+		warnings = append(warnings, node.Warnings...)
+		fmt.Printf("Generated %s for %s in %s\n", node.fileNames(), node.Profile, node.Dir)
+	}
 
-			// Generate validator.key file with private key
-			keyIndex := configValidator.Key
-			if keyIndex >= 0 && keyIndex < len(keyOutput.Keys) {
-				privateKey := keyOutput.Keys[keyIndex].PrivateKey
-				keyContent := fmt.Sprintf("\"%s\"", privateKey)
-
-				keyFilePath := filepath.Join(dirPath, "validator_key.json")
-				err = ioutil.WriteFile(keyFilePath, []byte(keyContent), 0644)
-				if err != nil {
-					log.Fatalf("Error writing validator.key to %s: %v", keyFilePath, err)
-				}
-			}
+	if opts.Roster != "" {
+		if err := writeRoster(opts.Roster, config, in.Keys); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote validator roster to %s\n", opts.Roster)
+	}
 
-			// Copy keystore.json to validator directory
-			keystoreFilePath := filepath.Join(dirPath, "keystore.json")
-			err = ioutil.WriteFile(keystoreFilePath, keystoreData, 0644)
-			if err != nil {
-				log.Fatalf("Error writing keystore.json to %s: %v", keystoreFilePath, err)
-			}
+	if opts.Prometheus != "" {
+		if err := writePrometheusConfig(opts.Prometheus, chainProfileName, config); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote prometheus scrape config to %s\n", opts.Prometheus)
+	}
 
-			fmt.Printf("Generated genesis.json, config.json, validator.key, and keystore.json for %s in %s\n", configValidator.Profile, dirPath)
+	if opts.Archive != "" {
+		if err := writeArchive(opts.Archive, chainProfileName, opts.GenesisTime, written); err != nil {
+			return err
 		}
+		fmt.Printf("Archived %d node directories to %s\n", len(written), opts.Archive)
+	}
+
+	printSummary(len(nodes)-len(failed), filesWritten, validatorKeyCount(written), opts, warnings)
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d nodes failed:\n  %s", len(failed), len(nodes), strings.Join(failed, "\n  "))
 	}
+	return nil
 }
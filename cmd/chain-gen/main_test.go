@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNodeListenAddress(t *testing.T) {
+	tests := []struct {
+		name       string
+		listenHost string
+		want       string
+	}{
+		{name: "default loopback", listenHost: "", want: "127.0.0.101:9001"},
+		{name: "ipv4 override", listenHost: "0.0.0.0", want: "0.0.0.0:9001"},
+		{name: "ipv6 literal", listenHost: "::1", want: "[::1]:9001"},
+		{name: "bracketed ipv6 literal", listenHost: "[fd00::101]", want: "[fd00::101]:9001"},
+		{name: "bare hostname", listenHost: "node-1", want: "node-1:9001"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := Validator{Profile: "node-1", ListenHost: test.listenHost}
+			if got := nodeListenAddress(validator, "127.0.0.101", "9001"); got != test.want {
+				t.Errorf("nodeListenAddress() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"eth-oracle.yaml", "default.yaml", "shared.toml", "notes.txt", "old.yaml.bak"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := profileNames(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"default", "eth-oracle", "shared"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("profileNames() = %v, want %v", names, want)
+	}
+
+	if _, err := profileNames(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without chain profiles")
+	}
+
+	if path, err := profilePath(dir, "shared"); err != nil || path != filepath.Join(dir, "shared.toml") {
+		t.Errorf("profilePath(shared) = %q, %v, want the toml profile", path, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shared.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profilePath(dir, "shared"); err == nil {
+		t.Error("expected error for a profile in both formats")
+	}
+}
+
+func TestSummarizeProfile(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", ChainID: 2},
+		{Profile: "node-2", ChainID: 1, EthOracle: true},
+		{Profile: "node-3", ChainID: 2},
+	}}
+	want := profileSummary{Validators: 3, ChainIDs: []int{1, 2}, EthOracle: true}
+	if got := summarizeProfile(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeProfile() = %+v, want %+v", got, want)
+	}
+	if got := summarizeProfile(Config{}); got.Validators != 0 || got.ChainIDs != nil || got.EthOracle {
+		t.Errorf("summarizeProfile(empty) = %+v", got)
+	}
+}
+
+func TestGeneratedGenesisFiles(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", ChainID: 1},
+		{Profile: "node-2", ChainID: 1},
+		{Profile: "node-3", ChainID: 2},
+	}}
+	outDir := t.TempDir()
+	if _, err := generatedGenesisFiles(outDir, "profile", config); err == nil {
+		t.Error("expected an error without any generated genesis.json")
+	}
+
+	// node-1 was left out, e.g. with --only node-2,node-3
+	var want []string
+	for _, profile := range []string{"node-2", "node-3"} {
+		dir := nodeDir(outDir, "profile", profile)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "genesis.json")
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, path)
+	}
+	got, err := generatedGenesisFiles(outDir, "profile", config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("generatedGenesisFiles() = %v, want %v", got, want)
+	}
+}
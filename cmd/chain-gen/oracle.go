@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// oracleConfigKeys are the config.json blocks injected into eth_oracle validators
+var oracleConfigKeys = []string{"ethBlockProviderConfig", "oracleConfig"}
+
+// ethOracleDefaults returns the config.json blocks injected into every eth_oracle validator
+func ethOracleDefaults() map[string]interface{} {
+	return map[string]interface{}{
+		"ethBlockProviderConfig": map[string]interface{}{
+			"ethNodeUrl":             "http://anvil:8545",
+			"ethNodeWsUrl":           "ws://anvil:8545",
+			"ethChainId":             1,
+			"retryDelay":             5,
+			"safeBlockConfirmations": 5,
+		},
+		"oracleConfig": map[string]interface{}{
+			"stateSaveFile":      "last_block_height.txt",
+			"orderResubmitDelay": 2,
+			"committee":          2,
+		},
+	}
+}
+
+// nodeOracleConfig is the eth oracle configuration a node's config.json was generated with
+type nodeOracleConfig struct {
+	Profile string
+	Config  map[string]interface{}
+}
+
+// oracleConfigs returns the oracle blocks of each generated eth_oracle node, decoded from its final
+// config.json so template values and injected values are shown the way the node reads them
+func oracleConfigs(config Config, nodes []generatedNode) ([]nodeOracleConfig, error) {
+	ethOracle := make(map[string]bool)
+	for _, validator := range config.Validators {
+		ethOracle[validator.Profile] = validator.EthOracle
+	}
+	var configs []nodeOracleConfig
+	for _, node := range nodes {
+		if !ethOracle[node.Profile] {
+			continue
+		}
+		for _, file := range node.Files {
+			if file.Name != "config.json" {
+				continue
+			}
+			var nodeConfig map[string]interface{}
+			if err := json.Unmarshal(file.Data, &nodeConfig); err != nil {
+				return nil, fmt.Errorf("error parsing config.json of %s: %w", node.Profile, err)
+			}
+			blocks := make(map[string]interface{})
+			for _, key := range oracleConfigKeys {
+				if block, ok := nodeConfig[key]; ok {
+					blocks[key] = block
+				}
+			}
+			configs = append(configs, nodeOracleConfig{Profile: node.Profile, Config: blocks})
+		}
+	}
+	return configs, nil
+}
+
+// printOracleConfig generates a chain profile's config.json files in memory and prints the eth
+// oracle configuration each eth_oracle validator receives, without writing anything
+func printOracleConfig(chainProfileName string, opts options) error {
+	config, err := loadProfile(chainProfileName)
+	if err != nil {
+		return err
+	}
+
+	in, err := loadInputs(opts.TemplatesDir)
+	if err != nil {
+		return err
+	}
+
+	// only config.json is needed, and the genesis time doesn't reach it
+	opts.ConfigOnly, opts.GenesisOnly = true, false
+	opts.ContinueOnError = false
+	if opts.GenesisTime == "" {
+		opts.GenesisTime = time.Now().Format(genesisTimeFormat)
+	}
+	nodes, err := generate(chainProfileName, config, in, opts)
+	if err != nil {
+		return err
+	}
+
+	configs, err := oracleConfigs(config, nodes)
+	if err != nil {
+		return err
+	}
+	if len(configs) == 0 {
+		return fmt.Errorf("chain profile %s has no eth_oracle validators to print", chainProfileName)
+	}
+	for _, nodeConfig := range configs {
+		output, err := json.MarshalIndent(nodeConfig.Config, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling oracle config of %s: %w", nodeConfig.Profile, err)
+		}
+		fmt.Printf("== %s ==\n%s\n", nodeConfig.Profile, output)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOracleConfigs(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", Key: 0, ChainID: 1},
+		{Profile: "node-2", Key: 1, ChainID: 2, EthOracle: true},
+	}}
+	in := testInputs(t, 2)
+	// the template's own block reaches node-1 but is replaced by the injected one on node-2
+	in.ConfigTemplate["oracleConfig"] = map[string]interface{}{"committee": 9.0}
+
+	nodes, err := generateFixture(t, config, in, options{ConfigOnly: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	configs, err := oracleConfigs(config, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configs) != 1 || configs[0].Profile != "node-2" {
+		t.Fatalf("oracle configs = %+v, want one for node-2", configs)
+	}
+	nodeConfig := nodeConfigMap(t, nodes[1])
+	for _, key := range oracleConfigKeys {
+		if !reflect.DeepEqual(configs[0].Config[key], nodeConfig[key]) {
+			t.Errorf("%s = %v, want %v", key, configs[0].Config[key], nodeConfig[key])
+		}
+	}
+	if got := configs[0].Config["oracleConfig"].(map[string]interface{})["committee"]; got != 2.0 {
+		t.Errorf("oracleConfig committee = %v, want 2", got)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keyOrder records the key order of a template object and of the objects nested in it
+type keyOrder struct {
+	Keys     []string             // object keys in template order
+	Children map[string]*keyOrder // order of nested values by object key
+	Items    []*keyOrder          // order of nested values by array index
+}
+
+// child returns the order of the value under an object key, nil if unknown
+func (o *keyOrder) child(key string) *keyOrder {
+	if o == nil {
+		return nil
+	}
+	return o.Children[key]
+}
+
+// item returns the order of the value at an array index, nil if unknown
+func (o *keyOrder) item(i int) *keyOrder {
+	if o == nil || i >= len(o.Items) {
+		return nil
+	}
+	return o.Items[i]
+}
+
+// orderedKeys returns the keys of m in template order followed by any other keys sorted
+func (o *keyOrder) orderedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool)
+	if o != nil {
+		for _, key := range o.Keys {
+			if _, ok := m[key]; ok && !seen[key] {
+				keys = append(keys, key)
+				seen[key] = true
+			}
+		}
+	}
+	var appended []string
+	for key := range m {
+		if !seen[key] {
+			appended = append(appended, key)
+		}
+	}
+	sort.Strings(appended)
+	return append(keys, appended...)
+}
+
+// jsonKeyOrder reads the key order of a JSON document
+func jsonKeyOrder(data []byte) (*keyOrder, error) {
+	return decodeKeyOrder(json.NewDecoder(bytes.NewReader(data)))
+}
+
+// decodeKeyOrder reads the key order of the next JSON value in the decoder
+func decodeKeyOrder(dec *json.Decoder) (*keyOrder, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil, nil
+	}
+
+	order := &keyOrder{Children: make(map[string]*keyOrder)}
+	for dec.More() {
+		if delim == '{' {
+			keyToken, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyToken.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", keyToken)
+			}
+			child, err := decodeKeyOrder(dec)
+			if err != nil {
+				return nil, err
+			}
+			order.Keys = append(order.Keys, key)
+			order.Children[key] = child
+		} else {
+			child, err := decodeKeyOrder(dec)
+			if err != nil {
+				return nil, err
+			}
+			order.Items = append(order.Items, child)
+		}
+	}
+
+	// consume the closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// yamlKeyOrder reads the key order of a YAML document
+func yamlKeyOrder(data []byte) (*keyOrder, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	return nodeKeyOrder(document.Content[0]), nil
+}
+
+// nodeKeyOrder reads the key order of a YAML node
+func nodeKeyOrder(node *yaml.Node) *keyOrder {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return nodeKeyOrder(node.Alias)
+	case yaml.MappingNode:
+		order := &keyOrder{Children: make(map[string]*keyOrder)}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			order.Keys = append(order.Keys, key)
+			order.Children[key] = nodeKeyOrder(node.Content[i+1])
+		}
+		return order
+	case yaml.SequenceNode:
+		order := &keyOrder{}
+		for _, item := range node.Content {
+			order.Items = append(order.Items, nodeKeyOrder(item))
+		}
+		return order
+	default:
+		return nil
+	}
+}
+
+// orderedObject marshals a map with its keys in template order
+type orderedObject struct {
+	values map[string]interface{}
+	order  *keyOrder
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.order.orderedKeys(o.values) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueBytes, err := json.Marshal(withKeyOrder(o.values[key], o.order.child(key)))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyBytes)
+		buf.WriteByte(':')
+		buf.Write(valueBytes)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// withKeyOrder wraps the maps in a value so they marshal in template key order
+func withKeyOrder(value interface{}, order *keyOrder) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return orderedObject{values: v, order: order}
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = withKeyOrder(item, order.item(i))
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+// orderedConfig marshals a node config keeping the template's key order, with keys not in the
+// template appended in sorted order
+func orderedConfig(nodeConfig map[string]interface{}, order *keyOrder) ([]byte, error) {
+	configOutput, err := json.MarshalIndent(withKeyOrder(nodeConfig, order), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(configOutput, '\n'), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+// genesisParams returns the genesis params of a chain profile: the template's params, overlaid by
+// the profile's params_file, overlaid by the profile's own params. Overlays merge into nested
+// objects key by key, so a profile only lists the params it changes
+func genesisParams(template interface{}, config Config) (interface{}, error) {
+	if config.ParamsFile == "" && len(config.Params) == 0 {
+		return template, nil
+	}
+
+	params, _ := template.(map[string]interface{})
+	if config.ParamsFile != "" {
+		fileParams, err := loadParamsFile(config.ParamsFile)
+		if err != nil {
+			return nil, err
+		}
+		params = mergeParams(params, fileParams)
+	}
+	return mergeParams(params, config.Params), nil
+}
+
+// loadParamsFile reads a JSON object of genesis params
+func loadParamsFile(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading params_file %s: %w", path, err)
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("error parsing params_file %s: %w", path, err)
+	}
+	return params, nil
+}
+
+// mergeParams returns a copy of base with overlay merged in. Where both hold an object the objects
+// are merged recursively; any other overlay value replaces the base value. Neither map is modified
+func mergeParams(base, overlay map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overlay {
+		baseObject, baseIsObject := merged[key].(map[string]interface{})
+		overlayObject, overlayIsObject := value.(map[string]interface{})
+		if baseIsObject && overlayIsObject {
+			merged[key] = mergeParams(baseObject, overlayObject)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// chainGenesisParams returns the genesis params of the nodes running chainID: params overlaid by
+// the profile's chain_params for that chain, if any
+func chainGenesisParams(params interface{}, config Config, chainID int) interface{} {
+	overlay, ok := config.ChainParams[strconv.Itoa(chainID)]
+	if !ok {
+		return params
+	}
+	base, _ := params.(map[string]interface{})
+	return mergeParams(base, overlay)
+}
+
+// chainParamsError checks that every chain_params key is the chain ID of a validator in the
+// profile, since params for a chain no node runs would be silently dropped
+func chainParamsError(config Config) error {
+	chainIDs := make(map[int]bool)
+	for _, validator := range config.Validators {
+		chainIDs[validator.ChainID] = true
+	}
+	for key := range config.ChainParams {
+		chainID, err := strconv.Atoi(key)
+		if err != nil {
+			return fmt.Errorf("chain_params key %q is not a chain ID", key)
+		}
+		if !chainIDs[chainID] {
+			return fmt.Errorf("chain_params sets params for chain %d, but no validator runs it", chainID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenesisParams(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("overlay.json", `{"consensus": {"blockSize": 3}, "fee": {"sendFee": 20}}`)
+	write("broken.json", `{"consensus":`)
+	write("profile.yaml", "params_file: overlay.json\nparams:\n  fee:\n    sendFee: 30\n")
+
+	config, err := loadProfileFrom(dir, "profile")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, "overlay.json"); config.ParamsFile != want {
+		t.Errorf("params_file = %q, want it resolved to %q", config.ParamsFile, want)
+	}
+
+	template := map[string]interface{}{
+		"consensus":  map[string]interface{}{"blockSize": 1.0, "rounds": 2.0},
+		"fee":        map[string]interface{}{"sendFee": 10.0},
+		"governance": map[string]interface{}{"daoRewardPercentage": 5.0},
+	}
+	params, err := genesisParams(template, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the profile's params win over params_file, which wins over the template
+	want := map[string]interface{}{
+		"consensus":  map[string]interface{}{"blockSize": 3.0, "rounds": 2.0},
+		"fee":        map[string]interface{}{"sendFee": 30},
+		"governance": map[string]interface{}{"daoRewardPercentage": 5.0},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %v, want %v", params, want)
+	}
+	if got := template["fee"].(map[string]interface{})["sendFee"]; got != 10.0 {
+		t.Errorf("template sendFee changed to %v", got)
+	}
+
+	if got, err := genesisParams(template, Config{}); err != nil || !reflect.DeepEqual(got, template) {
+		t.Errorf("genesisParams() without overlays = %v, %v, want the template", got, err)
+	}
+	for _, name := range []string{"missing.json", "broken.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := genesisParams(template, Config{ParamsFile: path}); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("genesisParams() with %s = %v, want an error naming the file", name, err)
+		}
+	}
+}
+
+func TestChainParams(t *testing.T) {
+	validators := []Validator{
+		{Profile: "node-1", Key: 0, ChainID: 1},
+		{Profile: "node-2", Key: 1, ChainID: 2, RootChainID: 1, Nested: true},
+		{Profile: "node-3", Key: 2, ChainID: 2, RootChainID: 1, Nested: true},
+	}
+	config := Config{
+		Validators:  validators,
+		Params:      map[string]interface{}{"fee": map[string]interface{}{"sendFee": 30}},
+		ChainParams: map[string]map[string]interface{}{"2": {"consensus": map[string]interface{}{"blockSize": 7}}},
+	}
+	nodes, err := generateFixture(t, config, testInputs(t, 3), options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := make(map[string]interface{})
+	for _, node := range nodes {
+		var genesis struct {
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := json.Unmarshal(nodeFile(t, node, "genesis.json"), &genesis); err != nil {
+			t.Fatalf("%s genesis.json: %v", node.Profile, err)
+		}
+		params[node.Profile] = genesis.Params
+	}
+	root := map[string]interface{}{"consensus": "fixture", "fee": map[string]interface{}{"sendFee": 30.0}}
+	nested := map[string]interface{}{"consensus": map[string]interface{}{"blockSize": 7.0}, "fee": map[string]interface{}{"sendFee": 30.0}}
+	want := map[string]interface{}{"node-1": root, "node-2": nested, "node-3": nested}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("genesis params = %v, want %v", params, want)
+	}
+
+	for _, test := range []struct {
+		key     string
+		wantErr string
+	}{
+		{key: "3", wantErr: "no validator runs it"},
+		{key: "root", wantErr: "not a chain ID"},
+	} {
+		config.ChainParams = map[string]map[string]interface{}{test.key: {}}
+		if _, err := generateFixture(t, config, testInputs(t, 3), options{}); err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("chain_params %q: error = %v, want %q", test.key, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PrometheusConfig is the part of a prometheus.yml that scrapes the generated nodes
+type PrometheusConfig struct {
+	ScrapeConfigs []ScrapeConfig `yaml:"scrape_configs"`
+}
+
+// ScrapeConfig is a prometheus scrape job
+type ScrapeConfig struct {
+	JobName       string         `yaml:"job_name"`
+	StaticConfigs []StaticConfig `yaml:"static_configs"`
+}
+
+// StaticConfig is a labeled group of prometheus scrape targets
+type StaticConfig struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// buildPrometheusConfig builds one scrape job for a chain profile with a target per node, at the
+// host of the node's net_address, or its docker host, and its metrics_port, or its admin port when
+// none is set
+func buildPrometheusConfig(chainProfileName string, config Config) (PrometheusConfig, error) {
+	job := ScrapeConfig{JobName: "canopy-" + chainProfileName, StaticConfigs: []StaticConfig{}}
+	for _, validator := range config.Validators {
+		_, _, _, port, _, _, err := getPortsForProfile(validator.Profile, validator.ChainID)
+		if err != nil {
+			return PrometheusConfig{}, err
+		}
+		if validator.MetricsPort != 0 {
+			if validator.MetricsPort < 0 || validator.MetricsPort > 65535 {
+				return PrometheusConfig{}, fmt.Errorf("metrics_port %d of %s is out of range", validator.MetricsPort, validator.Profile)
+			}
+			port = strconv.Itoa(validator.MetricsPort)
+		}
+		address, err := url.Parse(validatorNetAddress(validator))
+		if err != nil || address.Hostname() == "" {
+			return PrometheusConfig{}, fmt.Errorf("net_address %q of %s has no host", validator.NetAddress, validator.Profile)
+		}
+		job.StaticConfigs = append(job.StaticConfigs, StaticConfig{
+			Targets: []string{net.JoinHostPort(address.Hostname(), port)},
+			Labels:  map[string]string{"profile": validator.Profile, "chain_profile": chainProfileName},
+		})
+	}
+	return PrometheusConfig{ScrapeConfigs: []ScrapeConfig{job}}, nil
+}
+
+// writePrometheusConfig writes the prometheus scrape config of a chain profile to path
+func writePrometheusConfig(path, chainProfileName string, config Config) error {
+	prometheusConfig, err := buildPrometheusConfig(chainProfileName, config)
+	if err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(prometheusConfig); err != nil {
+		return fmt.Errorf("error marshaling prometheus config: %w", err)
+	}
+	if err := ioutil.WriteFile(path, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWritePrometheusConfig(t *testing.T) {
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", Key: 0, ChainID: 1},
+		{Profile: "node-2", Key: 1, ChainID: 2, MetricsPort: 9090},
+		{Profile: "node-3", Key: 2, ChainID: 1, NetAddress: "tcp://10.0.0.5:9001"},
+	}}
+	path := filepath.Join(t.TempDir(), "prometheus.yml")
+	if err := writePrometheusConfig(path, "fixture", config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got PrometheusConfig
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("prometheus.yml is not a scrape config: %v", err)
+	}
+	want := PrometheusConfig{ScrapeConfigs: []ScrapeConfig{{
+		JobName: "canopy-fixture",
+		StaticConfigs: []StaticConfig{
+			{Targets: []string{"node-1:50003"}, Labels: map[string]string{"profile": "node-1", "chain_profile": "fixture"}},
+			{Targets: []string{"node-2:9090"}, Labels: map[string]string{"profile": "node-2", "chain_profile": "fixture"}},
+			// scraped at the host of its external address, on its own admin port rather than the p2p one
+			{Targets: []string{"10.0.0.5:30003"}, Labels: map[string]string{"profile": "node-3", "chain_profile": "fixture"}},
+		},
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("prometheus config = %+v, want %+v", got, want)
+	}
+
+	config.Validators[1].MetricsPort = 70000
+	if err := writePrometheusConfig(path, "fixture", config); err == nil {
+		t.Error("expected error for an out of range metrics_port")
+	}
+}
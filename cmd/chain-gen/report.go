@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// printGenesisValidators prints the validator set from the genesis.json generated for each chain of a
+// chain profile, read from the first node of the chain found in outDir
+func printGenesisValidators(outDir, chainProfileName string) error {
+	config, err := loadProfile(chainProfileName)
+	if err != nil {
+		return err
+	}
+	if len(config.Validators) == 0 {
+		return fmt.Errorf("chain profile %s has no validators", chainProfileName)
+	}
+
+	genesisFilePaths, err := generatedGenesisFiles(outDir, chainProfileName, config)
+	if err != nil {
+		return err
+	}
+	missing, total := 0, 0
+	for i, genesisFilePath := range genesisFilePaths {
+		if i > 0 {
+			fmt.Println()
+		}
+		chainMissing, chainTotal, err := printGenesisFileValidators(genesisFilePath)
+		if err != nil {
+			return err
+		}
+		missing += chainMissing
+		total += chainTotal
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("%d of %d validators are missing an address or public key", missing, total)
+	}
+	return nil
+}
+
+// generatedGenesisFiles returns the genesis.json of the first node of each chain in config that has
+// one in outDir. Nodes of a chain share a genesis, so one copy per chain is representative, and a
+// node left out with --only or --continue-on-error is passed over for the next
+func generatedGenesisFiles(outDir, chainProfileName string, config Config) ([]string, error) {
+	var paths []string
+	found := make(map[int]bool)
+	for _, validator := range config.Validators {
+		if found[validator.ChainID] {
+			continue
+		}
+		path := filepath.Join(nodeDir(outDir, chainProfileName, validator.Profile), "genesis.json")
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		found[validator.ChainID] = true
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no node of chain profile %s has a genesis.json in %s", chainProfileName, outDir)
+	}
+	return paths, nil
+}
+
+// printGenesisFileValidators prints the validators of a genesis.json and returns how many are
+// missing an address or public key, out of how many
+func printGenesisFileValidators(genesisFilePath string) (int, int, error) {
+	genesisData, err := ioutil.ReadFile(genesisFilePath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading %s: %w", genesisFilePath, err)
+	}
+
+	var genesis Genesis
+	if err := json.Unmarshal(genesisData, &genesis); err != nil {
+		return 0, 0, fmt.Errorf("error parsing %s: %w", genesisFilePath, err)
+	}
+
+	fmt.Printf("Validators in %s:\n\n", genesisFilePath)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tADDRESS\tPUBLIC KEY\tCOMMITTEES\tSTAKED")
+	missing := 0
+	for i, validator := range genesis.Validators {
+		address, publicKey := validator.Address, validator.PublicKey
+		if address == "" || publicKey == "" {
+			missing++
+		}
+		if address == "" {
+			address = "<missing>"
+		}
+		if publicKey == "" {
+			publicKey = "<missing>"
+		}
+		committees := make([]string, len(validator.Committees))
+		for j, committee := range validator.Committees {
+			committees[j] = fmt.Sprintf("%d", committee)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\n", i, address, publicKey, strings.Join(committees, ","), validator.StakedAmount)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, 0, err
+	}
+	return missing, len(genesis.Validators), nil
+}
+
+// printSummary prints the closing report of a generation run. The validator key line is only
+// printed when the run wrote validator_key.json files
+func printSummary(nodes, filesWritten, validatorKeys int, opts options, warnings []string) {
+	fmt.Println("\nSummary:")
+	fmt.Printf("  Nodes generated: %d\n", nodes)
+	fmt.Printf("  Files written:   %d\n", filesWritten)
+	fmt.Printf("  Out dir:         %s\n", opts.OutDir)
+	fmt.Printf("  Genesis time:    %s\n", opts.GenesisTime)
+	if validatorKeys > 0 {
+		if opts.EncryptValidatorKey {
+			fmt.Println("  Validator keys:  encrypted")
+		} else {
+			fmt.Println("  Validator keys:  plaintext; anyone with the out-dir can read them (use --encrypt-validator-key)")
+		}
+	}
+	if opts.NoKeystore {
+		fmt.Println("  Keystores:       skipped (--no-keystore)")
+	}
+	if len(warnings) > 0 {
+		fmt.Printf("  Warnings (%d):\n", len(warnings))
+		for _, warning := range warnings {
+			fmt.Printf("    - %s\n", warning)
+		}
+	}
+}
+
+// validatorKeyCount counts the validator_key.json files among the files of nodes
+func validatorKeyCount(nodes []generatedNode) int {
+	count := 0
+	for _, node := range nodes {
+		for _, file := range node.Files {
+			if file.Name == "validator_key.json" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// printProfileResults prints whether each chain profile of an --all run was generated and returns
+// the number that failed
+func printProfileResults(names []string, results []error) int {
+	failed := 0
+	fmt.Println("\nChain profiles:")
+	for i, name := range names {
+		if results[i] != nil {
+			failed++
+			fmt.Printf("  %-20s FAILED: %v\n", name, results[i])
+			continue
+		}
+		fmt.Printf("  %-20s OK\n", name)
+	}
+	return failed
+}
+
+// profileSummary describes a chain profile without generating it
+type profileSummary struct {
+	Validators int
+	ChainIDs   []int
+	EthOracle  bool
+}
+
+// summarizeProfile counts a chain profile's validators and collects the chain IDs they run
+func summarizeProfile(config Config) profileSummary {
+	summary := profileSummary{Validators: len(config.Validators)}
+	seen := make(map[int]bool)
+	for _, validator := range config.Validators {
+		if !seen[validator.ChainID] {
+			seen[validator.ChainID] = true
+			summary.ChainIDs = append(summary.ChainIDs, validator.ChainID)
+		}
+		summary.EthOracle = summary.EthOracle || validator.EthOracle
+	}
+	sort.Ints(summary.ChainIDs)
+	return summary
+}
+
+// printProfileList prints a line per chain profile in dir. A profile that fails to parse is listed
+// with its error so the rest can still be browsed
+func printProfileList(dir string) error {
+	names, err := profileNames(dir)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tVALIDATORS\tCHAIN IDS\tETH ORACLE")
+	for _, name := range names {
+		config, err := loadProfileFrom(dir, name)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t(%v)\n", name, err)
+			continue
+		}
+		summary := summarizeProfile(config)
+		chainIDs := make([]string, len(summary.ChainIDs))
+		for i, chainID := range summary.ChainIDs {
+			chainIDs[i] = fmt.Sprintf("%d", chainID)
+		}
+		ethOracle := "no"
+		if summary.EthOracle {
+			ethOracle = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", name, summary.Validators, strings.Join(chainIDs, ","), ethOracle)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+// RosterEntry identifies a generated validator for monitoring and alerting tools
+type RosterEntry struct {
+	Profile    string      `json:"profile"`
+	Address    string      `json:"address"`
+	PublicKey  string      `json:"publicKey"`
+	Committees []int       `json:"committees"`
+	Ports      RosterPorts `json:"ports"`
+}
+
+// RosterPorts are the ports a validator node listens on
+type RosterPorts struct {
+	Wallet   int `json:"wallet"`
+	Explorer int `json:"explorer"`
+	RPC      int `json:"rpc"`
+	Admin    int `json:"admin"`
+	P2P      int `json:"p2p"`
+}
+
+// buildRoster lists the validators of a chain profile with the address and public key of their
+// key and the ports of their node
+func buildRoster(config Config, keys KeyOutput) ([]RosterEntry, error) {
+	roster := make([]RosterEntry, 0, len(config.Validators))
+	for _, validator := range config.Validators {
+		entry := RosterEntry{Profile: validator.Profile, Committees: []int(validator.Committees)}
+		if entry.Committees == nil {
+			entry.Committees = []int{}
+		}
+		if validator.Key >= 0 && validator.Key < len(keys.Keys) {
+			entry.Address = keys.Keys[validator.Key].Address
+			entry.PublicKey = keys.Keys[validator.Key].PublicKey
+		}
+
+		walletPort, explorerPort, rpcPort, adminPort, listenPort, _, err := getPortsForProfile(validator.Profile, validator.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range []struct {
+			value string
+			dest  *int
+		}{
+			{walletPort, &entry.Ports.Wallet},
+			{explorerPort, &entry.Ports.Explorer},
+			{rpcPort, &entry.Ports.RPC},
+			{adminPort, &entry.Ports.Admin},
+			{listenPort, &entry.Ports.P2P},
+		} {
+			value, err := strconv.Atoi(port.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port %q of %s: %w", port.value, validator.Profile, err)
+			}
+			*port.dest = value
+		}
+		roster = append(roster, entry)
+	}
+	return roster, nil
+}
+
+// writeRoster writes the validator roster of a chain profile to path as a JSON array
+func writeRoster(path string, config Config, keys KeyOutput) error {
+	roster, err := buildRoster(config, keys)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(roster, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling roster: %w", err)
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
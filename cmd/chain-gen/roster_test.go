@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRoster(t *testing.T) {
+	keys := testKeys(2)
+	config := Config{Validators: []Validator{
+		{Profile: "node-1", Key: 1, ChainID: 1, Committees: CommitteeList{1, 2}},
+		{Profile: "node-2", Key: 5, ChainID: 1},
+	}}
+	path := filepath.Join(t.TempDir(), "roster.json")
+	if err := writeRoster(path, config, keys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var roster []RosterEntry
+	if err := json.Unmarshal(data, &roster); err != nil {
+		t.Fatalf("roster is not a JSON array of entries: %v", err)
+	}
+	want := []RosterEntry{
+		{
+			Profile:    "node-1",
+			Address:    keys.Keys[1].Address,
+			PublicKey:  keys.Keys[1].PublicKey,
+			Committees: []int{1, 2},
+			Ports:      RosterPorts{Wallet: 50000, Explorer: 50001, RPC: 50002, Admin: 50003, P2P: 9001},
+		},
+		{
+			// a key index outside keys/node-bls.json leaves the identity empty
+			Profile:    "node-2",
+			Committees: []int{},
+			Ports:      RosterPorts{Wallet: 40000, Explorer: 40001, RPC: 40002, Admin: 40003, P2P: 9001},
+		},
+	}
+	if !reflect.DeepEqual(roster, want) {
+		t.Errorf("roster = %+v, want %+v", roster, want)
+	}
+}
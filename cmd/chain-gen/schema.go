@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema needed to describe a chain profile
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	AnyOf       []*jsonSchema          `json:"anyOf,omitempty"`
+}
+
+// profileSchema builds the JSON Schema for chain-profile YAML files from the Config struct tags.
+// Only fields with a yaml tag are part of the profile format; `schema:"required"` marks required
+// fields and `desc` provides the description.
+func profileSchema() *jsonSchema {
+	schema := schemaFor(reflect.TypeOf(Config{}))
+	schema.Schema = "https://json-schema.org/draft/2020-12/schema"
+	schema.Title = "chain-gen chain profile"
+	return schema
+}
+
+// schemaFor returns the schema of a Go type
+func schemaFor(t reflect.Type) *jsonSchema {
+	// committees are decoded from either a list or a comma-separated string
+	if t == reflect.TypeOf(CommitteeList{}) {
+		return &jsonSchema{AnyOf: []*jsonSchema{
+			{Type: "array", Items: &jsonSchema{Type: "integer"}},
+			{Type: "string"},
+		}}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object"}
+	case reflect.Ptr:
+		return schemaFor(t.Elem())
+	case reflect.Struct:
+		schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			property := schemaFor(field.Type)
+			property.Description = field.Tag.Get("desc")
+			schema.Properties[name] = property
+			if field.Tag.Get("schema") == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return schema
+	default:
+		return &jsonSchema{}
+	}
+}
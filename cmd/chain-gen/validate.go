@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// validateProfile runs every validation pass over a chain profile without writing any files,
+// printing each problem found, and returns an error when there are any
+func validateProfile(chainProfileName string, opts options) error {
+	config, err := loadProfile(chainProfileName)
+	if err != nil {
+		return err
+	}
+	in, err := loadInputs(opts.TemplatesDir)
+	if err != nil {
+		return err
+	}
+
+	problems := profileProblems(config, in)
+	if len(problems) == 0 {
+		fmt.Printf("Chain profile %s is valid: %d validators\n", chainProfileName, len(config.Validators))
+		return nil
+	}
+	for _, problem := range problems {
+		fmt.Printf("%s: %s\n", chainProfileName, problem)
+	}
+	return fmt.Errorf("%d problems found", len(problems))
+}
+
+// profileProblems collects the problems of every validation pass over a chain profile, the ones
+// generation fails on and the ones it only warns about
+func profileProblems(config Config, in *inputs) []string {
+	if len(config.Validators) == 0 {
+		return []string{"no validators"}
+	}
+	problems := append(keyIndexProblems(config, in.Keys), nonSignerProblems(config, in.Keys)...)
+	problems = append(problems, duplicateKeyProblems(config)...)
+	problems = append(problems, portProblems(config)...)
+	problems = append(problems, committeeProblems(config)...)
+	problems = append(problems, fundingProblems(config, in.Keys)...)
+	for _, validator := range config.Validators {
+		if err := validatorOverrideError(validator); err != nil {
+			problems = append(problems, fmt.Sprintf("validator %s: %v", validator.Profile, err))
+		}
+	}
+	if err := chainParamsError(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := genesisParams(in.Genesis.Params, config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// keyIndexProblems reports validators referencing a key index outside keys/node-bls.json
+func keyIndexProblems(config Config, keys KeyOutput) []string {
+	var problems []string
+	for _, validator := range config.Validators {
+		if validator.Key < 0 || validator.Key >= len(keys.Keys) {
+			problems = append(problems, fmt.Sprintf("validator %s references key %d but keys/node-bls.json has %d keys",
+				validator.Profile, validator.Key, len(keys.Keys)))
+		}
+	}
+	return problems
+}
+
+// fundingProblems reports validators whose genesis account doesn't cover their stake, which only
+// happens when the profile sets the account amount explicitly
+func fundingProblems(config Config, keys KeyOutput) []string {
+	var problems []string
+	if config.StakeBuffer < 0 {
+		problems = append(problems, fmt.Sprintf("stake_buffer %d is negative", config.StakeBuffer))
+	}
+
+	amounts := make(map[string]int64)
+	for _, account := range genesisAccounts(config, keys) {
+		amounts[account.Address] = account.Amount
+	}
+	for _, validator := range config.Validators {
+		if validator.Key < 0 || validator.Key >= len(keys.Keys) {
+			continue
+		}
+		address := keys.Keys[validator.Key].Address
+		if stake := validatorStake(validator); amounts[address] < stake {
+			problems = append(problems, fmt.Sprintf("validator %s account %s holds %d, below its stake of %d",
+				validator.Profile, address, amounts[address], stake))
+		}
+	}
+	return problems
+}
+
+// committeeProblems reports validators whose node runs a chain ID they aren't staked for in
+// genesis, a node that can't produce or certify blocks for its own chain
+func committeeProblems(config Config) []string {
+	var problems []string
+	for _, validator := range config.Validators {
+		committed := false
+		for _, committee := range validator.Committees {
+			if committee == validator.ChainID {
+				committed = true
+				break
+			}
+		}
+		if !committed {
+			problems = append(problems, fmt.Sprintf("validator %s runs chain %d but is only staked for committees %v",
+				validator.Profile, validator.ChainID, []int(validator.Committees)))
+		}
+	}
+	return problems
+}
+
+// duplicateKeyProblems reports validators of the same chain sharing a key, which stake the same
+// address twice, and keys listed as non-signers more than once. A key may run several chains
+func duplicateKeyProblems(config Config) []string {
+	type chainKey struct{ chainID, key int }
+	var problems []string
+	owners := make(map[chainKey]string)
+	for _, validator := range config.Validators {
+		id := chainKey{validator.ChainID, validator.Key}
+		if owner, ok := owners[id]; ok {
+			problems = append(problems, fmt.Sprintf("validator %s uses key %d, already used by validator %s on chain %d",
+				validator.Profile, validator.Key, owner, validator.ChainID))
+			continue
+		}
+		owners[id] = validator.Profile
+	}
+	nonSigners := make(map[int]bool)
+	for _, nonSigner := range config.NonSigners {
+		if nonSigners[nonSigner.Key] {
+			problems = append(problems, fmt.Sprintf("non-signer key %d is listed more than once", nonSigner.Key))
+		}
+		nonSigners[nonSigner.Key] = true
+	}
+	return problems
+}
+
+// portProblems reports validators whose profile has no built-in ports, profiles used by more than
+// one validator, which bind the same ports and write the same directory, and validators whose p2p
+// listen address is another's
+func portProblems(config Config) []string {
+	var problems []string
+	known := make(map[string]bool)
+	for _, profile := range portProfiles {
+		known[profile] = true
+	}
+	profiles := make(map[string]bool)
+	listeners := make(map[string]string)
+	for _, validator := range config.Validators {
+		if !known[validator.Profile] {
+			problems = append(problems, fmt.Sprintf("validator %s has no built-in ports, expected one of %s",
+				validator.Profile, strings.Join(portProfiles, ", ")))
+			continue
+		}
+		if profiles[validator.Profile] {
+			problems = append(problems, fmt.Sprintf("validator %s is listed more than once; the copies bind the same ports and write the same directory",
+				validator.Profile))
+			continue
+		}
+		profiles[validator.Profile] = true
+
+		_, _, _, _, listenPort, listenAddr, err := getPortsForProfile(validator.Profile, validator.ChainID)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		address := nodeListenAddress(validator, listenAddr, listenPort)
+		if owner, ok := listeners[address]; ok {
+			problems = append(problems, fmt.Sprintf("validator %s listens on %s like validator %s", validator.Profile, address, owner))
+			continue
+		}
+		listeners[address] = validator.Profile
+	}
+	return problems
+}
+
+// nonSignerProblems reports non-signers referencing a key index outside keys/node-bls.json
+func nonSignerProblems(config Config, keys KeyOutput) []string {
+	var problems []string
+	for _, nonSigner := range config.NonSigners {
+		if nonSigner.Key < 0 || nonSigner.Key >= len(keys.Keys) {
+			problems = append(problems, fmt.Sprintf("non-signer references key %d but keys/node-bls.json has %d keys",
+				nonSigner.Key, len(keys.Keys)))
+		}
+	}
+	return problems
+}
+
+// validatorOverrideError checks the net_address and staked_amount a validator overrides
+func validatorOverrideError(validator Validator) error {
+	if validator.StakedAmount < 0 {
+		return fmt.Errorf("staked_amount %d is negative", validator.StakedAmount)
+	}
+	if validator.NetAddress == "" {
+		return nil
+	}
+	parsed, err := url.Parse(validator.NetAddress)
+	if err != nil {
+		return fmt.Errorf("net_address %q is not a URL: %w", validator.NetAddress, err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("net_address %q needs a scheme and host, e.g. tcp://10.0.0.5:9001", validator.NetAddress)
+	}
+	return nil
+}
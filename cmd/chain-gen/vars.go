@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// placeholderPattern matches ${VAR} placeholders in config template strings
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// nodeVars returns the placeholder values of a validator node: the profile's vars overlaid with
+// the derived PROFILE, CHAIN_PROFILE, CHAIN_ID, ROOT_CHAIN_ID and NODE_INDEX values
+func nodeVars(chainProfileName string, config Config, index int) map[string]string {
+	vars := make(map[string]string, len(config.Vars)+5)
+	for name, value := range config.Vars {
+		vars[name] = value
+	}
+	validator := config.Validators[index]
+	vars["PROFILE"] = validator.Profile
+	vars["CHAIN_PROFILE"] = chainProfileName
+	vars["CHAIN_ID"] = strconv.Itoa(validator.ChainID)
+	vars["ROOT_CHAIN_ID"] = strconv.Itoa(validator.RootChainID)
+	vars["NODE_INDEX"] = strconv.Itoa(index)
+	return vars
+}
+
+// substituteVars returns a copy of a template value with the ${VAR} placeholders in its strings
+// replaced. Unknown placeholders are left intact and their names added to unknown
+func substituteVars(value interface{}, vars map[string]string, unknown map[string]bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		return placeholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			if replacement, ok := vars[name]; ok {
+				return replacement
+			}
+			unknown[name] = true
+			return placeholder
+		})
+	case map[string]interface{}:
+		substituted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			substituted[key] = substituteVars(item, vars, unknown)
+		}
+		return substituted
+	case []interface{}:
+		substituted := make([]interface{}, len(v))
+		for i, item := range v {
+			substituted[i] = substituteVars(item, vars, unknown)
+		}
+		return substituted
+	default:
+		return value
+	}
+}
+
+// unknownVarWarnings formats the unknown placeholders found in a node's config
+func unknownVarWarnings(profile string, unknown map[string]bool) []string {
+	var names []string
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for _, name := range names {
+		warnings = append(warnings, fmt.Sprintf("%s config has unknown placeholder ${%s}, left intact", profile, name))
+	}
+	return warnings
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubstituteVars(t *testing.T) {
+	config := Config{
+		Vars:       map[string]string{"LOG_LEVEL": "debug", "PROFILE": "overridden"},
+		Validators: []Validator{{Profile: "node-1", ChainID: 1}, {Profile: "node-2", ChainID: 2, RootChainID: 1}},
+	}
+	vars := nodeVars("default", config, 1)
+
+	template := map[string]interface{}{
+		"logLevel": "${LOG_LEVEL}",
+		"dataDir":  "/data/${CHAIN_PROFILE}-${PROFILE}",
+		"nested":   map[string]interface{}{"chain": "${CHAIN_ID}/${ROOT_CHAIN_ID}", "index": "${NODE_INDEX}"},
+		"list":     []interface{}{"${MISSING}", 5.0},
+		"literal":  "$PROFILE {PROFILE}",
+		"port":     9000.0,
+	}
+	want := map[string]interface{}{
+		"logLevel": "debug",
+		"dataDir":  "/data/default-node-2",
+		"nested":   map[string]interface{}{"chain": "2/1", "index": "1"},
+		"list":     []interface{}{"${MISSING}", 5.0},
+		"literal":  "$PROFILE {PROFILE}",
+		"port":     9000.0,
+	}
+
+	unknown := make(map[string]bool)
+	got := substituteVars(template, vars, unknown)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("substituteVars() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(unknown, map[string]bool{"MISSING": true}) {
+		t.Errorf("unknown = %v, want MISSING", unknown)
+	}
+	if template["logLevel"] != "${LOG_LEVEL}" || template["nested"].(map[string]interface{})["chain"] != "${CHAIN_ID}/${ROOT_CHAIN_ID}" {
+		t.Error("substituteVars modified the template")
+	}
+}
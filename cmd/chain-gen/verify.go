@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// verifyProfile regenerates a chain profile in memory and compares each file by content hash
+// against the out-dir, reporting drift per file. When no genesis time is pinned the time recorded
+// in the on-disk genesis.json is reused so the comparison is deterministic.
+func verifyProfile(chainProfileName string, opts options) error {
+	config, err := loadProfile(chainProfileName)
+	if err != nil {
+		return err
+	}
+	if len(config.Validators) == 0 {
+		return fmt.Errorf("chain profile %s has no validators", chainProfileName)
+	}
+
+	in, err := loadInputs(opts.TemplatesDir)
+	if err != nil {
+		return err
+	}
+
+	if opts.GenesisTime == "" {
+		opts.GenesisTime, err = onDiskGenesisTime(nodeDir(opts.OutDir, chainProfileName, config.Validators[0].Profile))
+		if err != nil {
+			return err
+		}
+	}
+
+	// a node that fails to generate can't be compared, so fail the whole verification
+	opts.ContinueOnError = false
+	nodes, err := generate(chainProfileName, config, in, opts)
+	if err != nil {
+		return err
+	}
+
+	drifted := 0
+	for _, node := range nodes {
+		for _, file := range node.Files {
+			filePath := filepath.Join(node.Dir, file.Name)
+			status := "OK"
+			onDisk, err := ioutil.ReadFile(filePath)
+			switch {
+			case os.IsNotExist(err):
+				status = "MISSING"
+			case err != nil:
+				return fmt.Errorf("error reading %s: %w", filePath, err)
+			case opts.EncryptValidatorKey && file.Name == "validator_key.json":
+				if !sameEncryptedValidatorKey(onDisk, file.Data, opts.ValidatorKeyPassword) {
+					status = "MODIFIED"
+				}
+			case sha256.Sum256(onDisk) != sha256.Sum256(file.Data):
+				status = "MODIFIED"
+			}
+			if status != "OK" {
+				drifted++
+			}
+			fmt.Printf("%-8s %s\n", status, filePath)
+		}
+	}
+
+	if drifted > 0 {
+		return fmt.Errorf("%d files drifted from the generated output", drifted)
+	}
+	fmt.Printf("All files for %s match the generated output\n", chainProfileName)
+	return nil
+}
+
+// onDiskGenesisTime reads the genesis time from a node's generated genesis.json
+func onDiskGenesisTime(dirPath string) (string, error) {
+	genesisFilePath := filepath.Join(dirPath, "genesis.json")
+	genesisData, err := ioutil.ReadFile(genesisFilePath)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", genesisFilePath, err)
+	}
+	var genesis Genesis
+	if err := json.Unmarshal(genesisData, &genesis); err != nil {
+		return "", fmt.Errorf("error parsing %s: %w", genesisFilePath, err)
+	}
+	return genesis.Time, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+// BenchmarkNewBLSKey measures BLS key generation with this build of lib/crypto. Besides ns/op it
+// reports keys/s, to estimate how long keygen takes for a large testnet. Run it with:
+//
+//	go test -run '^$' -bench NewBLSKey -benchmem ./cmd/keygen
+func BenchmarkNewBLSKey(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := crypto.NewBLS12381PrivateKey(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportKeysPerSecond(b)
+}
+
+// BenchmarkNewBLSKeyParallel is BenchmarkNewBLSKey generating keys on every core, to see how
+// generation scales for a parallel keygen. Compare keys/s across -cpu values, e.g. -cpu 1,2,4,8
+func BenchmarkNewBLSKeyParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := crypto.NewBLS12381PrivateKey(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	reportKeysPerSecond(b)
+}
+
+// reportKeysPerSecond reports the keys generated per second of wall time
+func reportKeysPerSecond(b *testing.B) {
+	if seconds := b.Elapsed().Seconds(); seconds > 0 {
+		b.ReportMetric(float64(b.N)/seconds, "keys/s")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+// listKeystore prints the nickname and address of every entry in the keystore under dataDir.
+// Only public information is printed; nothing is decrypted
+func listKeystore(dataDir string) error {
+	k, err := crypto.NewKeystoreFromFile(dataDir)
+	if err != nil {
+		return fmt.Errorf("loading keystore: %w", err)
+	}
+	if len(k.AddressMap) == 0 {
+		fmt.Printf("No keys in %s\n", dataDir+crypto.KeyStoreName)
+		return nil
+	}
+
+	// resolve nicknames through the nickname map, which is authoritative over the entries
+	nicknames := make(map[string]string, len(k.NicknameMap))
+	for nickname, address := range k.NicknameMap {
+		nicknames[address] = nickname
+	}
+
+	addresses := make([]string, 0, len(k.AddressMap))
+	for address := range k.AddressMap {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if nicknames[addresses[i]] != nicknames[addresses[j]] {
+			return nicknames[addresses[i]] < nicknames[addresses[j]]
+		}
+		return addresses[i] < addresses[j]
+	})
+
+	fmt.Printf("%-20s %s\n", "NICKNAME", "ADDRESS")
+	for _, address := range addresses {
+		nickname := nicknames[address]
+		if nickname == "" {
+			nickname = "-"
+		}
+		fmt.Printf("%-20s %s\n", nickname, address)
+	}
+	fmt.Printf("\n%d keys in %s\n", len(addresses), dataDir+crypto.KeyStoreName)
+	return nil
+}
@@ -2,23 +2,32 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"time"
 
+	"canopy-testing/internal/password"
 	"github.com/canopy-network/canopy/lib/crypto"
 )
 
-const password = "test"
+// defaultPassword is the password keys are imported with unless --password-file is given
+const defaultPassword = "test"
 const dataDirPath = "keys/"
 const nickPrefix = "nick"
 
+// keyFilePath is the plaintext key file chain-gen builds genesis from
+const keyFilePath = "keys/node-bls.json"
+
 type KeyPair struct {
 	PrivateKey string `json:"privateKey"`
 	PublicKey  string `json:"publicKey"`
 	Address    string `json:"address"`
+	Role       string `json:"role,omitempty"`   // what the key is for, e.g. faucet or validator
+	Amount     int64  `json:"amount,omitempty"` // genesis amount chain-gen funds the key's account with
 }
 
 type KeyOutput struct {
@@ -27,6 +36,89 @@ type KeyOutput struct {
 }
 
 func main() {
+	keystoreOnly := flag.Bool("keystore-only", false, "Only populate keys/keystore.json; skip writing the plaintext node-bls.json and print addresses only. Refuses to run while a keys/node-bls.json exists, as chain-gen would build genesis from its old keys")
+	count := flag.Int("count", 12, "Number of keys to generate")
+	profile := flag.String("profile", "", "Generate one key per validator in this chain-gen profile (YAML or TOML) instead of --count")
+	runSelfTest := flag.Bool("self-test", false, "Check BLS sign/verify and key reload round-trips with this build of lib/crypto and exit")
+	list := flag.Bool("list", false, "Print the nickname and address of every key in keys/keystore.json and exit")
+	passwordFile := flag.String("password-file", "", "Read the keystore password from this file instead of the default \""+defaultPassword+"\"")
+	tagSpec := flag.String("tag", "", "Comma-separated index=role[:amount] tags recorded in node-bls.json (e.g. 0=faucet:5000000000); chain-gen funds tagged amounts")
+	vanity := flag.String("vanity", "", "Generate every key with an address starting with this hex prefix; each character makes the search 16x slower")
+	vanityTimeout := flag.Duration("vanity-timeout", time.Minute, "Give up on a --vanity key after searching this long")
+	flag.Parse()
+
+	if *list {
+		if err := listKeystore(dataDirPath); err != nil {
+			log.Fatalf("Error listing keystore: %v", err)
+		}
+		return
+	}
+
+	if *runSelfTest {
+		if err := selfTest(); err != nil {
+			log.Fatalf("Self-test failed: %v", err)
+		}
+		fmt.Println("Self-test passed")
+		return
+	}
+
+	if *profile != "" {
+		profileCount, err := keyCountFromProfile(*profile)
+		if err != nil {
+			log.Fatalf("Error reading profile: %v", err)
+		}
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "count" && *count != profileCount {
+				log.Fatalf("--count %d conflicts with the %d keys %s needs", *count, profileCount, *profile)
+			}
+		})
+		*count = profileCount
+	}
+	if *count < 1 {
+		log.Fatalf("--count must be at least 1")
+	}
+
+	tags, err := parseKeyTags(*tagSpec, *count)
+	if err != nil {
+		log.Fatalf("Error parsing --tag: %v", err)
+	}
+	if *keystoreOnly && len(tags) > 0 {
+		log.Fatalf("--tag is recorded in node-bls.json, which --keystore-only doesn't write")
+	}
+	if *keystoreOnly {
+		if err := checkNoKeyFile(keyFilePath); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	vanityPrefix, err := parseVanityPrefix(*vanity)
+	if err != nil {
+		log.Fatalf("Error parsing --vanity: %v", err)
+	}
+	if len(vanityPrefix) >= vanitySlowPrefix {
+		log.Printf("Warning: a %d character --vanity prefix takes about %.0f keys per match, exponentially more with every character",
+			len(vanityPrefix), vanityAttempts(vanityPrefix))
+	}
+
+	keystorePass, err := keystorePassword(*passwordFile)
+	if err != nil {
+		log.Fatalf("Error reading --password-file: %v", err)
+	}
+
+	// search every vanity key before touching the keystore, so a timeout leaves it as it was
+	var vanityKeys []crypto.PrivateKeyI
+	if vanityPrefix != "" {
+		for i := 0; i < *count; i++ {
+			start := time.Now()
+			blsKey, attempts, err := vanityKey(vanityPrefix, *vanityTimeout)
+			if err != nil {
+				log.Fatalf("Error generating --vanity key %d: %v", i, err)
+			}
+			fmt.Printf("Found key %d with prefix %s after %d keys in %s\n", i, vanityPrefix, attempts, time.Since(start).Round(time.Millisecond))
+			vanityKeys = append(vanityKeys, blsKey)
+		}
+	}
+
 	var keys []KeyPair
 
 	os.Remove(dataDirPath + "/keystore.json")
@@ -37,25 +129,36 @@ func main() {
 		panic(e)
 	}
 
-	for i := 0; i < 12; i++ {
-		blsKey, _ := crypto.NewBLS12381PrivateKey()
+	for i := 0; i < *count; i++ {
+		var blsKey crypto.PrivateKeyI
+		if vanityKeys != nil {
+			blsKey = vanityKeys[i]
+		} else {
+			blsKey, _ = crypto.NewBLS12381PrivateKey()
+		}
 		blsPub := blsKey.PublicKey()
 
 		keyPair := KeyPair{
 			PrivateKey: blsKey.String(),
 			PublicKey:  blsPub.String(),
 			Address:    blsPub.Address().String(),
+			Role:       tags[i].Role,
+			Amount:     tags[i].Amount,
 		}
 		keys = append(keys, keyPair)
 
 		// import each key to keystore with same password
-		address, e := k.ImportRaw(blsKey.Bytes(), password, crypto.ImportRawOpts{
+		address, e := k.ImportRaw(blsKey.Bytes(), keystorePass, crypto.ImportRawOpts{
 			Nickname: fmt.Sprintf("%s-%d", nickPrefix, i),
 		})
 		if e != nil {
 			log.Fatal(e.Error())
 		}
-		fmt.Printf("Imported validator key %s to keystore\n", address)
+		if *keystoreOnly {
+			fmt.Println(address)
+		} else {
+			fmt.Printf("Imported validator key %s to keystore\n", address)
+		}
 	}
 
 	// save keystore to file once after all imports
@@ -63,6 +166,21 @@ func main() {
 		panic(e)
 	}
 
+	// a nickname collision or partial import leaves fewer entries than keys generated
+	saved, err := countKeystoreKeys(dataDirPath, nickPrefix)
+	if err != nil {
+		log.Fatalf("Error verifying keystore: %v", err)
+	}
+	if saved != *count {
+		log.Fatalf("Keystore has %d %s-* keys after importing %d", saved, nickPrefix, *count)
+	}
+	fmt.Printf("Keystore holds %d %s-* keys\n", saved, nickPrefix)
+
+	// keep the private keys off disk and out of the terminal
+	if *keystoreOnly {
+		return
+	}
+
 	output := KeyOutput{
 		Timestamp: time.Now().Format("2006-01-02T15:04:05Z"),
 		Keys:      keys,
@@ -75,10 +193,49 @@ func main() {
 
 	fmt.Println(string(jsonData))
 
-	err = ioutil.WriteFile("keys/node-bls.json", jsonData, 0644)
+	err = ioutil.WriteFile(keyFilePath, jsonData, 0644)
 	if err != nil {
 		log.Fatalf("Error writing to file: %v", err)
 	}
 
-	fmt.Printf("\nKeys saved to: %s\n", "/keys/node-bls.json")
+	fmt.Printf("\nKeys saved to: /%s\n", keyFilePath)
+}
+
+// countKeystoreKeys reloads the keystore under dataDir and counts the entries whose nickname
+// starts with prefix and that have a key behind them
+func countKeystoreKeys(dataDir, prefix string) (int, error) {
+	k, err := crypto.NewKeystoreFromFile(dataDir)
+	if err != nil {
+		return 0, fmt.Errorf("loading keystore: %w", err)
+	}
+	count := 0
+	for nickname, address := range k.NicknameMap {
+		if !strings.HasPrefix(nickname, prefix+"-") {
+			continue
+		}
+		if _, ok := k.AddressMap[address]; ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// keystorePassword returns the password read from passwordFile, or defaultPassword when no
+// --password-file is given
+func keystorePassword(passwordFile string) (string, error) {
+	if passwordFile == "" {
+		return defaultPassword, nil
+	}
+	return password.Read(passwordFile)
+}
+
+// checkNoKeyFile fails when path exists. --keystore-only leaves node-bls.json alone, so an older one
+// would no longer match the keystore while chain-gen still builds genesis from it
+func checkNoKeyFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("--keystore-only would leave %s out of step with the new keystore; move or delete it first", path)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("checking %s: %w", path, err)
+	}
+	return nil
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+func TestCountKeystoreKeys(t *testing.T) {
+	dir := t.TempDir() + "/"
+	k, err := crypto.NewKeystoreFromFile(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, nickname := range []string{"nick-0", "nick-1", "other-0", "nickname-0"} {
+		key, err := crypto.NewBLS12381PrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := k.ImportRaw(key.Bytes(), defaultPassword, crypto.ImportRawOpts{Nickname: nickname}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// a nickname without a key behind it isn't counted
+	k.NicknameMap["nick-2"] = strings.Repeat("ab", 20)
+	if err := k.SaveToFile(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, err := countKeystoreKeys(dir, nickPrefix); err != nil || got != 2 {
+		t.Errorf("countKeystoreKeys() = %d, %v, want 2", got, err)
+	}
+	if got, err := countKeystoreKeys(dir, "other"); err != nil || got != 1 {
+		t.Errorf("countKeystoreKeys(other) = %d, %v, want 1", got, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "keystore.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := countKeystoreKeys(dir, nickPrefix); err == nil || !strings.Contains(err.Error(), "loading keystore") {
+		t.Errorf("countKeystoreKeys() with a corrupt keystore = %v, want a loading error", err)
+	}
+}
+
+func TestKeystorePassword(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "no file", want: defaultPassword},
+		{name: "trailing newline", content: "secret\n", want: "secret"},
+		{name: "empty", content: "", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := ""
+			if test.name != "no file" {
+				path = filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_"))
+				if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := keystorePassword(path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("keystorePassword() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("keystorePassword() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckNoKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node-bls.json")
+	if err := checkNoKeyFile(path); err != nil {
+		t.Fatalf("unexpected error without a key file: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkNoKeyFile(path); err == nil {
+		t.Error("expected an error for an existing key file")
+	}
+}
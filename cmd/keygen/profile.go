@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// profileValidator is the part of a chain-gen profile validator that keygen needs
+type profileValidator struct {
+	Profile string `yaml:"profile" toml:"profile"`
+	Key     int    `yaml:"key" toml:"key"`
+}
+
+// keyCountFromProfile reads a chain-gen profile, TOML when its extension is .toml and YAML otherwise,
+// and returns the number of keys it needs, one per validator, erroring when a validator references a
+// key index beyond that count
+func keyCountFromProfile(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	var profile struct {
+		Validators []profileValidator `yaml:"validators" toml:"validators"`
+	}
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &profile)
+	} else {
+		err = yaml.Unmarshal(data, &profile)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	count := len(profile.Validators)
+	if count == 0 {
+		return 0, fmt.Errorf("%s has no validators", path)
+	}
+	for _, validator := range profile.Validators {
+		if validator.Key < 0 || validator.Key >= count {
+			return 0, fmt.Errorf("validator %s in %s references key %d but the profile's %d validators imply keys 0-%d",
+				validator.Profile, path, validator.Key, count, count-1)
+		}
+	}
+	return count, nil
+}
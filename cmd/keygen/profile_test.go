@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyCountFromProfile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    int
+		wantErr string
+	}{
+		{
+			name:    "yaml",
+			file:    "chain.yaml",
+			content: "validators:\n  - profile: node-1\n    key: 1\n  - profile: node-2\n    key: 0\n",
+			want:    2,
+		},
+		{
+			name:    "toml",
+			file:    "chain.toml",
+			content: "[[validators]]\nprofile = \"node-1\"\nkey = 0\n\n[[validators]]\nprofile = \"node-2\"\nkey = 1\n\n[[validators]]\nprofile = \"node-3\"\nkey = 2\n",
+			want:    3,
+		},
+		{name: "missing", file: "missing.yaml", wantErr: "error reading"},
+		{name: "bad yaml", file: "bad.yaml", content: "validators: [", wantErr: "error parsing"},
+		// TOML is only parsed as TOML by its extension
+		{name: "toml as yaml", file: "chain.yml", content: "[[validators]]\nprofile = \"node-1\"\n", wantErr: "error parsing"},
+		{name: "no validators", file: "empty.yaml", content: "chain_id: 1\n", wantErr: "has no validators"},
+		{
+			name:    "key out of range",
+			file:    "range.toml",
+			content: "[[validators]]\nprofile = \"node-1\"\nkey = 1\n",
+			wantErr: "validator node-1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.file)
+			if test.content != "" {
+				if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := keyCountFromProfile(path)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("keyCountFromProfile() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("keyCountFromProfile() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+// selfTestMessage is the fixed message signed by the self-test
+var selfTestMessage = []byte("canopy keygen self-test")
+
+// selfTest checks the BLS key generation, sign/verify and reload round-trips of this build of
+// lib/crypto, printing each step that passes
+func selfTest() error {
+	blsKey, err := crypto.NewBLS12381PrivateKey()
+	if err != nil {
+		return fmt.Errorf("generating key: %w", err)
+	}
+	fmt.Printf("OK generate key %s\n", blsKey.PublicKey().Address())
+	return checkKeyRoundTrips(blsKey, blsKey.PublicKey())
+}
+
+// checkKeyRoundTrips signs with blsKey and checks the signature, and the keys reloaded from their
+// string forms, against blsPub
+func checkKeyRoundTrips(blsKey crypto.PrivateKeyI, blsPub crypto.PublicKeyI) error {
+	address := blsPub.Address()
+	signature := blsKey.Sign(selfTestMessage)
+	if err := checkSignature(blsPub, signature); err != nil {
+		return err
+	}
+	fmt.Println("OK sign and verify")
+
+	reloadedKey, err := crypto.StringToBLS12381PrivateKey(blsKey.String())
+	if err != nil {
+		return fmt.Errorf("reloading private key: %w", err)
+	}
+	if !bytes.Equal(reloadedKey.Bytes(), blsKey.Bytes()) {
+		return fmt.Errorf("reloaded private key differs from the generated key")
+	}
+	if got := reloadedKey.PublicKey().Address(); !got.Equals(address) {
+		return fmt.Errorf("address of reloaded private key is %s, expected %s", got, address)
+	}
+
+	reloadedPub, err := crypto.StringToBLS12381Public(blsPub.String())
+	if err != nil {
+		return fmt.Errorf("reloading public key: %w", err)
+	}
+	if got := reloadedPub.Address(); !got.Equals(address) {
+		return fmt.Errorf("address of reloaded public key is %s, expected %s", got, address)
+	}
+	if !reloadedPub.VerifyBytes(selfTestMessage, signature) {
+		return fmt.Errorf("signature does not verify with the reloaded public key")
+	}
+	fmt.Println("OK reload keys with a stable address")
+
+	return nil
+}
+
+// checkSignature checks signature verifies selfTestMessage with blsPub and no other message
+func checkSignature(blsPub crypto.PublicKeyI, signature []byte) error {
+	address := blsPub.Address()
+	if !blsPub.VerifyBytes(selfTestMessage, signature) {
+		return fmt.Errorf("signature by %s does not verify with its public key", address)
+	}
+	if blsPub.VerifyBytes(append([]byte("tampered "), selfTestMessage...), signature) {
+		return fmt.Errorf("signature by %s verifies a different message", address)
+	}
+	return nil
+}
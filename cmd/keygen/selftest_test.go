@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := selfTest(); err != nil {
+		t.Fatalf("selfTest() = %v", err)
+	}
+}
+
+func TestCheckKeyRoundTrips(t *testing.T) {
+	blsKey, err := crypto.NewBLS12381PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKeyRoundTrips(blsKey, blsKey.PublicKey()); err != nil {
+		t.Fatalf("checkKeyRoundTrips() = %v", err)
+	}
+
+	// a public key that doesn't belong to the private key, as a corrupted key would
+	otherKey, err := crypto.NewBLS12381PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKeyRoundTrips(blsKey, otherKey.PublicKey()); err == nil {
+		t.Error("expected an error for a mismatched public key")
+	}
+}
+
+func TestCheckSignature(t *testing.T) {
+	blsKey, err := crypto.NewBLS12381PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := blsKey.Sign(selfTestMessage)
+	if err := checkSignature(blsKey.PublicKey(), signature); err != nil {
+		t.Fatalf("checkSignature() = %v", err)
+	}
+
+	corrupted := append([]byte(nil), signature...)
+	corrupted[len(corrupted)-1] ^= 0x01
+	if err := checkSignature(blsKey.PublicKey(), corrupted); err == nil {
+		t.Error("expected an error for a corrupted signature")
+	}
+	if err := checkSignature(blsKey.PublicKey(), blsKey.Sign([]byte("another message"))); err == nil {
+		t.Error("expected an error for a signature of another message")
+	}
+}
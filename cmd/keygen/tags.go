@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keyTag is the role and intended genesis amount keygen records for a key in node-bls.json
+type keyTag struct {
+	Role   string
+	Amount int64
+}
+
+// parseKeyTags parses a --tag spec of comma-separated index=role[:amount] entries, e.g.
+// "0=faucet:5000000000,1=validator". An entry without an amount leaves the key at chain-gen's
+// default amount
+func parseKeyTags(spec string, count int) (map[int]keyTag, error) {
+	tags := make(map[int]keyTag)
+	if strings.TrimSpace(spec) == "" {
+		return tags, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		indexPart, tagPart, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("tag %q is not index=role[:amount]", entry)
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(indexPart))
+		if err != nil {
+			return nil, fmt.Errorf("tag %q has an invalid key index: %w", entry, err)
+		}
+		if index < 0 || index >= count {
+			return nil, fmt.Errorf("tag %q references key %d but only keys 0-%d are generated", entry, index, count-1)
+		}
+		if _, dup := tags[index]; dup {
+			return nil, fmt.Errorf("key %d is tagged more than once", index)
+		}
+
+		role, amountPart, hasAmount := strings.Cut(tagPart, ":")
+		tag := keyTag{Role: strings.TrimSpace(role)}
+		if tag.Role == "" {
+			return nil, fmt.Errorf("tag %q has an empty role", entry)
+		}
+		if hasAmount {
+			tag.Amount, err = strconv.ParseInt(strings.TrimSpace(amountPart), 10, 64)
+			if err != nil || tag.Amount <= 0 {
+				return nil, fmt.Errorf("tag %q has an invalid amount %q", entry, amountPart)
+			}
+		}
+		tags[index] = tag
+	}
+	return tags, nil
+}
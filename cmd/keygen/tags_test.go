@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		count   int
+		want    map[int]keyTag
+		wantErr string
+	}{
+		{name: "empty", spec: " ", count: 3, want: map[int]keyTag{}},
+		{
+			name:  "roles and amounts",
+			spec:  "0=faucet:5000000000, 2 = validator",
+			count: 3,
+			want:  map[int]keyTag{0: {Role: "faucet", Amount: 5000000000}, 2: {Role: "validator"}},
+		},
+		{name: "no index", spec: "faucet", count: 3, wantErr: "is not index=role"},
+		{name: "bad index", spec: "x=faucet", count: 3, wantErr: "invalid key index"},
+		{name: "index out of range", spec: "3=faucet", count: 3, wantErr: "only keys 0-2"},
+		{name: "negative index", spec: "-1=faucet", count: 3, wantErr: "only keys 0-2"},
+		{name: "tagged twice", spec: "1=faucet,1=validator", count: 3, wantErr: "key 1 is tagged more than once"},
+		{name: "empty role", spec: "1=:100", count: 3, wantErr: "empty role"},
+		{name: "bad amount", spec: "1=faucet:lots", count: 3, wantErr: "invalid amount"},
+		{name: "zero amount", spec: "1=faucet:0", count: 3, wantErr: "invalid amount"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseKeyTags(test.spec, test.count)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("parseKeyTags() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseKeyTags() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+// vanitySlowPrefix is the prefix length from which keygen warns that the search is slow; every
+// hex character makes it 16 times slower
+const vanitySlowPrefix = 5
+
+// parseVanityPrefix normalizes a --vanity prefix to the lowercase hex addresses are printed in
+func parseVanityPrefix(prefix string) (string, error) {
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(prefix, "0x"), "0X"))
+	if len(prefix) > crypto.AddressSize*2 {
+		return "", fmt.Errorf("%q is longer than an address", prefix)
+	}
+	for _, c := range prefix {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", fmt.Errorf("%q is not hex", prefix)
+		}
+	}
+	return prefix, nil
+}
+
+// vanityAttempts is the average number of keys generated per match of a prefix
+func vanityAttempts(prefix string) float64 {
+	return math.Pow(16, float64(len(prefix)))
+}
+
+// vanityKey generates BLS keys on every CPU until one's address starts with prefix, giving up
+// after timeout. It returns the key and how many keys were generated
+func vanityKey(prefix string, timeout time.Duration) (crypto.PrivateKeyI, uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var (
+		attempts atomic.Uint64
+		once     sync.Once
+		found    crypto.PrivateKeyI
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				key, err := crypto.NewBLS12381PrivateKey()
+				if err != nil {
+					continue
+				}
+				attempts.Add(1)
+				if strings.HasPrefix(key.PublicKey().Address().String(), prefix) {
+					once.Do(func() {
+						found = key
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if found == nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, attempts.Load(), fmt.Errorf("no address starting with %s after %d keys in %s", prefix, attempts.Load(), timeout)
+		}
+		return nil, attempts.Load(), ctx.Err()
+	}
+	return found, attempts.Load(), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVanityPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", prefix: "", want: ""},
+		{name: "lowercased", prefix: "0xBeEf", want: "beef"},
+		{name: "upper 0X", prefix: "0XA1", want: "a1"},
+		{name: "full address", prefix: strings.Repeat("ab", 20), want: strings.Repeat("ab", 20)},
+		{name: "longer than an address", prefix: strings.Repeat("ab", 20) + "a", wantErr: true},
+		{name: "not hex", prefix: "cafez", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseVanityPrefix(test.prefix)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseVanityPrefix() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("parseVanityPrefix() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestVanityKey(t *testing.T) {
+	// a single character matches about every 16 keys
+	key, attempts, err := vanityKey("a", 30*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if address := key.PublicKey().Address().String(); !strings.HasPrefix(address, "a") {
+		t.Errorf("vanityKey() address %s doesn't start with a", address)
+	}
+	if attempts == 0 {
+		t.Error("vanityKey() reported no attempts")
+	}
+
+	// a whole address never matches within the timeout
+	start := time.Now()
+	if _, _, err := vanityKey(strings.Repeat("0", 40), 50*time.Millisecond); err == nil || !strings.Contains(err.Error(), "no address starting with") {
+		t.Errorf("vanityKey() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("vanityKey() gave up after %s, want about the 50ms timeout", elapsed)
+	}
+}
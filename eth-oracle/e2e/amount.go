@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// usdcDecimals is the number of decimal places of the USDC token
+	usdcDecimals = 6
+	// cnpyDecimals is the number of decimal places of CNPY (1 CNPY = 1000000 uCNPY)
+	cnpyDecimals = 6
+)
+
+// amountSuffixes maps a token suffix to its number of decimals
+var amountSuffixes = map[string]int{
+	"usdc": usdcDecimals,
+	"cnpy": cnpyDecimals,
+}
+
+// parseAmount parses an amount in smallest units ("1500000") or a human-readable token
+// amount ("1.5usdc", "2cnpy"). Values with more precision than the token supports are
+// rejected rather than rounded.
+func parseAmount(value string) (uint64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+
+	// Raw smallest-unit integers
+	if s[len(s)-1] >= '0' && s[len(s)-1] <= '9' {
+		amount, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q: expected an integer in smallest units or a value like 1.5usdc", value)
+		}
+		return amount, nil
+	}
+
+	// Suffixed token amounts
+	idx := strings.IndexFunc(s, func(r rune) bool { return r >= 'a' && r <= 'z' })
+	number, suffix := strings.TrimSpace(s[:idx]), s[idx:]
+	decimals, ok := amountSuffixes[suffix]
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q: unknown suffix %q (expected usdc or cnpy)", value, suffix)
+	}
+
+	whole, fraction, _ := strings.Cut(number, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q: missing number", value)
+	}
+	if len(fraction) > decimals {
+		return 0, fmt.Errorf("invalid amount %q: %s supports at most %d decimal places", value, suffix, decimals)
+	}
+	digits := whole + fraction + strings.Repeat("0", decimals-len(fraction))
+	if strings.ContainsFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) {
+		return 0, fmt.Errorf("invalid amount %q: not a decimal number", value)
+	}
+	amount, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	return amount, nil
+}
+
+// amountValue is a flag.Value accepting amounts in the formats understood by parseAmount
+type amountValue uint64
+
+func (a *amountValue) String() string {
+	return strconv.FormatUint(uint64(*a), 10)
+}
+
+func (a *amountValue) Set(value string) error {
+	amount, err := parseAmount(value)
+	if err != nil {
+		return err
+	}
+	*a = amountValue(amount)
+	return nil
+}
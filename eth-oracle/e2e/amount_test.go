@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		// raw smallest units
+		{value: "1000000", want: 1000000},
+		{value: "0", want: 0},
+		{value: "18446744073709551615", want: 18446744073709551615},
+		{value: "18446744073709551616", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "1.5", wantErr: true},
+		// whole token amounts
+		{value: "1usdc", want: 1000000},
+		{value: "2cnpy", want: 2000000},
+		{value: "2 CNPY", want: 2000000},
+		// fractional token amounts
+		{value: "1.5usdc", want: 1500000},
+		{value: "0.000001usdc", want: 1},
+		{value: ".25usdc", want: 250000},
+		{value: "3.usdc", want: 3000000},
+		// precision beyond the token's decimals is rejected, not rounded
+		{value: "0.0000001usdc", wantErr: true},
+		{value: "1.0000005cnpy", wantErr: true},
+		// invalid suffixes and numbers
+		{value: "1eth", wantErr: true},
+		{value: "1usd", wantErr: true},
+		{value: "1.5usdcx", wantErr: true},
+		{value: "usdc", wantErr: true},
+		{value: ".usdc", wantErr: true},
+		{value: "1.2.3usdc", wantErr: true},
+		{value: "-1usdc", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseAmount(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("parseAmount(%q) = %d, want error", test.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAmount(%q) unexpected error: %v", test.value, err)
+			}
+			if got != test.want {
+				t.Errorf("parseAmount(%q) = %d, want %d", test.value, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+// buyerTxs is the number of eth transactions the buyer of a test case sends: the lock and the close
+const buyerTxs = 2
+
+// BalanceSnapshot records the USDC and CNPY balances of every test account at a point in time
+type BalanceSnapshot struct {
+	Taken time.Time
+	USDC  map[string]*big.Int // lowercase eth address -> USDC balance
+	CNPY  map[string]uint64   // canopy address -> CNPY balance
+}
+
+// snapshotBalances records the balance of every eth and canopy account
+func (e *EthOracleE2E) snapshotBalances() *BalanceSnapshot {
+	snapshot := &BalanceSnapshot{
+		Taken: time.Now(),
+		USDC:  make(map[string]*big.Int),
+		CNPY:  make(map[string]uint64),
+	}
+
+	for _, account := range ethAccounts {
+		if account == "" {
+			continue
+		}
+		balance, err := e.getUSDCBalance(account)
+		if err != nil {
+			e.logger.Warnf("Failed to snapshot USDC balance of %s: %v", account, err)
+			continue
+		}
+		snapshot.USDC[strings.ToLower(account)] = balance
+	}
+
+	for _, account := range canopyAccounts {
+		balance, err := e.getCNPYBalance(account)
+		if err != nil {
+			e.logger.Warnf("Failed to snapshot CNPY balance of %s: %v", account, err)
+			continue
+		}
+		snapshot.CNPY[account] = balance
+	}
+
+	return snapshot
+}
+
+// expectedBalanceChanges sums the balance changes the verified test cases should have produced
+func (e *EthOracleE2E) expectedBalanceChanges() (map[string]*big.Int, map[string]int64) {
+	e.testResults.mutex.RLock()
+	defer e.testResults.mutex.RUnlock()
+
+	usdc := make(map[string]*big.Int)
+	cnpy := make(map[string]int64)
+	add := func(account string, amount *big.Int) {
+		account = strings.ToLower(account)
+		if usdc[account] == nil {
+			usdc[account] = new(big.Int)
+		}
+		usdc[account].Add(usdc[account], amount)
+	}
+
+	for _, testCase := range e.testResults.testCases {
+		if testCase.Status != StatusVerified {
+			continue
+		}
+		buyer, transfer, released := expectedBalanceDeltas(testCase)
+		add(testCase.BuyerAddress, buyer)
+		if testCase.CloseRecipient != "" {
+			// a wrong close pays the recipient and releases nothing
+			add(testCase.CloseRecipient, transfer)
+			continue
+		}
+		add(testCase.SellerAddress, transfer)
+		cnpy[testCase.CanopyReceiveAddress] += int64(released)
+	}
+
+	return usdc, cnpy
+}
+
+// printBalanceDiff prints the change of every account between two snapshots and warns about
+// accounts whose change isn't explained by the verified test cases
+func (e *EthOracleE2E) printBalanceDiff(before, after *BalanceSnapshot) {
+	expectedUSDC, expectedCNPY := e.expectedBalanceChanges()
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("BALANCE CHANGES (%s)\n", after.Taken.Sub(before.Taken).Round(time.Second))
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Printf("%-44s %-5s %20s %20s\n", "ACCOUNT", "TOKEN", "CHANGE", "EXPECTED")
+
+	senders := e.sendingAccounts()
+	var warnings []string
+	for _, account := range ethAccounts {
+		key := strings.ToLower(account)
+		initial, ok1 := before.USDC[key]
+		final, ok2 := after.USDC[key]
+		if !ok1 || !ok2 {
+			continue
+		}
+		change := new(big.Int).Sub(final, initial)
+		expected := expectedUSDC[key]
+		if expected == nil {
+			expected = new(big.Int)
+		}
+		fmt.Printf("%-44s %-5s %20s %20s\n", account, "USDC", e.formatUSDCBalance(change), e.formatUSDCBalance(expected))
+		if change.Cmp(expected) != 0 {
+			warnings = append(warnings, fmt.Sprintf("%s USDC changed by %s, expected %s",
+				account, e.formatUSDCBalance(change), e.formatUSDCBalance(expected)))
+		}
+	}
+
+	for _, account := range canopyAccounts {
+		initial, ok1 := before.CNPY[account]
+		final, ok2 := after.CNPY[account]
+		if !ok1 || !ok2 {
+			continue
+		}
+		change := int64(final) - int64(initial)
+		if senders[strings.ToLower(account)] {
+			// the order escrow and the create and delete fees come out of this account
+			fmt.Printf("%-44s %-5s %20d %20s\n", account, "CNPY", change, "(sender)")
+			continue
+		}
+		expected := expectedCNPY[account]
+		fmt.Printf("%-44s %-5s %20d %20d\n", account, "CNPY", change, expected)
+		if change != expected {
+			warnings = append(warnings, fmt.Sprintf("%s CNPY changed by %d, expected %d", account, change, expected))
+		}
+	}
+
+	for _, warning := range warnings {
+		e.logger.Warnf("Unreconciled balance: %s", warning)
+	}
+	fmt.Println(strings.Repeat("=", 80))
+}
+
+// sendingAccounts returns the lowercase canopy addresses the suite creates and deletes orders from.
+// Their CNPY pays the order escrow and fees, so printBalanceDiff doesn't reconcile their change
+func (e *EthOracleE2E) sendingAccounts() map[string]bool {
+	e.testResults.mutex.RLock()
+	defer e.testResults.mutex.RUnlock()
+
+	senders := make(map[string]bool)
+	nicks := []string{os.Getenv("E2E_FROM_NICK"), e.sellerNick}
+	for _, testCase := range e.testResults.testCases {
+		nicks = append(nicks, testCase.SellerNick)
+		if address, err := parseCanopyAddress(testCase.CanopySendAddress); err == nil {
+			senders[string(address)] = true
+		}
+	}
+
+	keystore, err := crypto.NewKeystoreFromFile(e.dataDir)
+	if err != nil {
+		e.logger.Warnf("Failed to load keystore, order senders are reconciled like any account: %v", err)
+		return senders
+	}
+	for _, nick := range nicks {
+		if address, ok := keystore.NicknameMap[nick]; ok && nick != "" {
+			senders[strings.ToLower(address)] = true
+		}
+	}
+	return senders
+}
+
+// expectedBalanceDeltas returns the buyer USDC, seller USDC and CNPY changes verifyFinalBalances
+// asserts for a test case, summed over the orders of a batch. Once orders are matched, the CNPY is
+// what they actually sell, which a fee-netted order matched within --amount-tolerance lowers
+func expectedBalanceDeltas(testCase *TestCase) (buyerUSDC, sellerUSDC *big.Int, cnpy uint64) {
+	sellerUSDC = new(big.Int).SetUint64(testCase.ExpectedUSDCTransfer)
+	sellerUSDC.Mul(sellerUSDC, new(big.Int).SetUint64(testCase.orderCount()))
+	buyerUSDC = new(big.Int).Neg(sellerUSDC)
+	cnpy = testCase.ExpectedCNPYTransfer * testCase.orderCount()
+	if testCase.MatchedAmount != 0 && testCase.CloseRecipient == "" {
+		cnpy = testCase.MatchedAmount
+	}
+	return buyerUSDC, sellerUSDC, cnpy
+}
+
+// PreviewTestCases prints the balance changes every generated test case will assert, without
+// creating, locking or closing any order
+func (e *EthOracleE2E) PreviewTestCases() {
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("TEST CASE PREVIEW")
+	fmt.Println(strings.Repeat("=", 80))
+	testCases := e.generateTestCases()
+	if e.rotateAccounts {
+		if err := rotateTestAccounts(testCases); err != nil {
+			fmt.Printf("Cannot rotate test accounts: %v\n", err)
+			return
+		}
+	}
+	for _, testCase := range testCases {
+		e.previewTestCase(testCase)
+	}
+	fmt.Println(strings.Repeat("=", 80))
+}
+
+// previewTestCase prints the balance changes a single test case will assert
+func (e *EthOracleE2E) previewTestCase(testCase *TestCase) {
+	buyerUSDC, sellerUSDC, cnpy := expectedBalanceDeltas(testCase)
+	if testCase.BatchSize > 1 {
+		fmt.Printf("%s (committee %d, %d orders of %d uCNPY)\n", testCase.Name, testCase.Committee, testCase.BatchSize, testCase.OrderAmount)
+	} else {
+		fmt.Printf("%s (committee %d, order %d uCNPY)\n", testCase.Name, testCase.Committee, testCase.OrderAmount)
+	}
+	fmt.Printf("  buyer  %-44s USDC %s\n", testCase.BuyerAddress, e.formatUSDCBalance(buyerUSDC))
+	if testCase.CloseRecipient != "" {
+		// a wrong close pays the recipient and must release nothing
+		fmt.Printf("  wrong  %-44s USDC %s\n", testCase.CloseRecipient, e.formatUSDCBalance(sellerUSDC))
+		fmt.Printf("  seller %-44s USDC %s\n", testCase.SellerAddress, e.formatUSDCBalance(new(big.Int)))
+		fmt.Printf("  canopy %-44s CNPY 0 (order must not be released)\n", testCase.CanopyReceiveAddress)
+		return
+	}
+	fmt.Printf("  seller %-44s USDC %s\n", testCase.SellerAddress, e.formatUSDCBalance(sellerUSDC))
+	fmt.Printf("  canopy %-44s CNPY %d\n", testCase.CanopyReceiveAddress, cnpy)
+	// every order sells OrderAmount uCNPY, so closing them must release exactly that
+	if selling := testCase.OrderAmount * testCase.orderCount(); cnpy != selling {
+		e.logger.Warnf("Test %s expects %d CNPY released by orders selling %d", testCase.Name, cnpy, selling)
+	}
+}
+
+// checkBuyerFunds returns an error if the buyer of a test case can't pay the close transfers or the
+// gas of the lock and close transactions of its orders. The seller sends nothing on the eth side
+func (e *EthOracleE2E) checkBuyerFunds(testCase *TestCase) error {
+	tokenBalance, err := e.getTokenBalance(testCase.TokenContract, testCase.BuyerAddress)
+	if err != nil {
+		return fmt.Errorf("failed to get buyer USDC balance: %w", err)
+	}
+	ethBalance, err := e.getETHBalance(testCase.BuyerAddress)
+	if err != nil {
+		return err
+	}
+	gasPrice, err := e.ethClient.SuggestGasPrice(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get gas price: %w", err)
+	}
+	gas := new(big.Int).Mul(e.txOptions.MaxTxFee(gasPrice), new(big.Int).SetUint64(buyerTxs*testCase.orderCount()))
+	return buyerFundsShortfall(testCase, tokenBalance, ethBalance, gas)
+}
+
+// buyerFundsShortfall returns an insufficient balance error naming the buyer and the shortfall if
+// tokenBalance doesn't cover the test case's transfers or ethBalance doesn't cover gas
+func buyerFundsShortfall(testCase *TestCase, tokenBalance, ethBalance, gas *big.Int) error {
+	_, transfer, _ := expectedBalanceDeltas(testCase)
+	if tokenBalance.Cmp(transfer) < 0 {
+		return fmt.Errorf("insufficient balance: buyer %s has %s USDC, needs %s (short %s)",
+			testCase.BuyerAddress, tokenBalance, transfer, new(big.Int).Sub(transfer, tokenBalance))
+	}
+	if ethBalance.Cmp(gas) < 0 {
+		return fmt.Errorf("insufficient balance: buyer %s has %s wei, needs %s for gas (short %s)",
+			testCase.BuyerAddress, ethBalance, gas, new(big.Int).Sub(gas, ethBalance))
+	}
+	return nil
+}
+
+// EscrowReport reconciles the CNPY the completed orders of a suite run should have released from
+// committee escrow against the balance changes of their receive addresses
+type EscrowReport struct {
+	Committees []CommitteeEscrow // per committee, sorted by committee
+	Accounts   []AccountEscrow   // per receive address, sorted by address
+}
+
+// CommitteeEscrow is the CNPY the completed orders of a committee should have released
+type CommitteeEscrow struct {
+	Committee uint64
+	Orders    int
+	Expected  uint64
+}
+
+// AccountEscrow is the CNPY a receive address should have received and its actual balance change
+type AccountEscrow struct {
+	Address  string
+	Expected uint64
+	Actual   int64
+}
+
+// Missing is the CNPY the account didn't receive, 0 when it received at least the expected amount
+func (a AccountEscrow) Missing() uint64 {
+	if a.Actual >= int64(a.Expected) {
+		return 0
+	}
+	return uint64(int64(a.Expected) - a.Actual)
+}
+
+// Stuck sums the CNPY the receive addresses are missing, which is potentially stuck in escrow
+func (r EscrowReport) Stuck() uint64 {
+	var stuck uint64
+	for _, account := range r.Accounts {
+		stuck += account.Missing()
+	}
+	return stuck
+}
+
+// reconcileEscrow builds the escrow report of the completed test cases between two snapshots.
+// Negative test cases release nothing and are left out
+func reconcileEscrow(testCases []*TestCase, before, after *BalanceSnapshot) EscrowReport {
+	committees := make(map[uint64]*CommitteeEscrow)
+	accounts := make(map[string]*AccountEscrow)
+	for _, testCase := range testCases {
+		if (testCase.Status != StatusClosed && testCase.Status != StatusVerified) || testCase.CloseRecipient != "" {
+			continue
+		}
+		committee := committees[testCase.Committee]
+		if committee == nil {
+			committee = &CommitteeEscrow{Committee: testCase.Committee}
+			committees[testCase.Committee] = committee
+		}
+		_, _, released := expectedBalanceDeltas(testCase)
+		committee.Orders += int(testCase.orderCount())
+		committee.Expected += released
+
+		account := accounts[testCase.CanopyReceiveAddress]
+		if account == nil {
+			account = &AccountEscrow{Address: testCase.CanopyReceiveAddress,
+				Actual: int64(after.CNPY[testCase.CanopyReceiveAddress]) - int64(before.CNPY[testCase.CanopyReceiveAddress])}
+			accounts[testCase.CanopyReceiveAddress] = account
+		}
+		account.Expected += released
+	}
+
+	var report EscrowReport
+	for _, committee := range committees {
+		report.Committees = append(report.Committees, *committee)
+	}
+	sort.Slice(report.Committees, func(i, j int) bool { return report.Committees[i].Committee < report.Committees[j].Committee })
+	for _, account := range accounts {
+		report.Accounts = append(report.Accounts, *account)
+	}
+	sort.Slice(report.Accounts, func(i, j int) bool { return report.Accounts[i].Address < report.Accounts[j].Address })
+	return report
+}
+
+// printEscrowReport prints the escrow reconciliation of the suite run and warns about CNPY that
+// potentially got stuck in escrow
+func (e *EthOracleE2E) printEscrowReport(before, after *BalanceSnapshot) {
+	e.testResults.mutex.RLock()
+	testCases := make([]*TestCase, 0, len(e.testResults.testCases))
+	for _, testCase := range e.testResults.testCases {
+		testCases = append(testCases, testCase)
+	}
+	e.testResults.mutex.RUnlock()
+	report := reconcileEscrow(testCases, before, after)
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("COMMITTEE ESCROW")
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Printf("%-10s %8s %20s\n", "COMMITTEE", "ORDERS", "RELEASED (uCNPY)")
+	for _, committee := range report.Committees {
+		fmt.Printf("%-10d %8d %20d\n", committee.Committee, committee.Orders, committee.Expected)
+	}
+	fmt.Printf("\n%-44s %15s %15s %15s\n", "RECEIVE ADDRESS", "EXPECTED", "ACTUAL", "MISSING")
+	for _, account := range report.Accounts {
+		fmt.Printf("%-44s %15d %15d %15d\n", account.Address, account.Expected, account.Actual, account.Missing())
+	}
+	if stuck := report.Stuck(); stuck > 0 {
+		e.logger.Warnf("%d uCNPY released by completed orders never reached the receive addresses; it may be stuck in committee escrow", stuck)
+	}
+	fmt.Println(strings.Repeat("=", 80))
+}
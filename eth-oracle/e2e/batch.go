@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/canopy-network/canopy/lib"
+)
+
+// orderCount is the number of orders a test case sells, BatchSize for a batch and 1 otherwise
+func (t *TestCase) orderCount() uint64 {
+	if t.BatchSize > 1 {
+		return uint64(t.BatchSize)
+	}
+	return 1
+}
+
+// batchTestCase is the test case run with --batch-orders, selling n orders of half a USDC each so
+// the single order cases don't match them
+func batchTestCase(n int) *TestCase {
+	return &TestCase{
+		Name:                 fmt.Sprintf("BatchOrderFlow_%dx500USDC", n),
+		OrderAmount:          500000, // distinct from the positive cases so their orders aren't matched
+		ExpectedUSDCTransfer: 500000,
+		ExpectedCNPYTransfer: 500000,
+		BatchSize:            n,
+		BuyerAddress:         ethAccounts[0],
+		BuyerPrivateKey:      ethPrivateKeys[0],
+		SellerAddress:        ethAccounts[1],
+		SellerPrivateKey:     ethPrivateKeys[1],
+		CanopyReceiveAddress: canopyAccounts[1],
+		Status:               StatusCreated,
+	}
+}
+
+// runBatchTestCase creates the BatchSize orders of a test case, locks them back to back once they
+// are all in the book, closes each and verifies the balances changed by the sum of the orders.
+// The per order steps reuse the single order helpers on a copy of the test case per order ID
+func (e *EthOracleE2E) runBatchTestCase(testCase *TestCase) {
+	if err := e.checkBuyerFunds(testCase); err != nil {
+		e.failTestCase(testCase, err)
+		return
+	}
+	e.recordInitialBalances(testCase)
+	orders := e.traceOrders(testCase, "start", nil)
+
+	for i := 0; i < testCase.BatchSize; i++ {
+		orderID, err := e.createSellOrder(testCase.Committee, testCase.OrderAmount, testCase.ExpectedUSDCTransfer, testCase.SellerAddress,
+			testCase.CanopySendAddress, testCase.CanopyReceiveAddress, testCase.TokenContract, testCase.SellerNick, testCase.SellerPass)
+		if err != nil {
+			e.failTestCase(testCase, fmt.Errorf("failed to create order %d of %d: %w", i+1, testCase.BatchSize, err))
+			return
+		}
+		testCase.BatchOrderIDs = append(testCase.BatchOrderIDs, orderID)
+	}
+	orders = e.traceOrders(testCase, "create", orders)
+
+	err := e.waitForBatchInBook(testCase)
+	if err == nil {
+		for _, orderID := range testCase.BatchOrderIDs {
+			if err = e.LockOrder(orderID, testCase.BuyerAddress, testCase.BuyerPrivateKey, testCase.CanopyReceiveAddress); err != nil {
+				err = fmt.Errorf("order %s: %w", orderID, err)
+				break
+			}
+		}
+	}
+	orders = e.traceOrders(testCase, "lock", orders)
+	if err != nil {
+		e.failTestCase(testCase, fmt.Errorf("failed to lock batch: %w", err))
+		return
+	}
+
+	for _, orderID := range testCase.BatchOrderIDs {
+		order := testCase.batchOrder(orderID)
+		err = e.closeTestOrder(order)
+		testCase.MatchedAmount += order.MatchedAmount
+		if err == nil {
+			err = e.waitForOrderCompletion(order)
+		}
+		if err == nil {
+			err = e.waitForCloseSettlement(order)
+		}
+		if err != nil {
+			testCase.Status = order.Status
+			e.traceOrders(testCase, "close", orders)
+			e.failTestCase(testCase, fmt.Errorf("failed to close order %s: %w", orderID, err))
+			return
+		}
+	}
+	testCase.Status = StatusClosed
+	e.traceOrders(testCase, "close", orders)
+
+	if err := e.checkBalanceChanges(testCase); err != nil {
+		e.failTestCase(testCase, fmt.Errorf("balance verification failed: %w", err))
+		return
+	}
+	e.passTestCase(testCase)
+}
+
+// batchOrder returns the single order test case of one order of a batch
+func (t *TestCase) batchOrder(orderID string) *TestCase {
+	order := *t
+	order.Name = fmt.Sprintf("%s[%s]", t.Name, orderID)
+	order.OrderID = orderID
+	order.BatchSize, order.BatchOrderIDs, order.MatchedAmount = 0, nil, 0
+	return &order
+}
+
+// waitForBatchInBook waits until every order of a batch is in the order book
+func (e *EthOracleE2E) waitForBatchInBook(testCase *TestCase) error {
+	timeout := time.After(60 * time.Second)
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("%w waiting for the %d orders to appear", ErrTimeout, testCase.BatchSize)
+		case <-e.suiteDone():
+			return e.suiteErr()
+		case <-ticker.C:
+			orders, err := e.Orders()
+			if err != nil {
+				continue
+			}
+			inBook := make(map[string]bool)
+			for _, book := range orders.OrderBooks {
+				for _, order := range book.Orders {
+					if GetOrderStatus(order, 0) == StatusCreated {
+						inBook[lib.BytesToString(order.Id)] = true
+					}
+				}
+			}
+			all := true
+			for _, orderID := range testCase.BatchOrderIDs {
+				all = all && inBook[orderID]
+			}
+			if all {
+				testCase.Status = StatusCreated
+				return nil
+			}
+		}
+	}
+}
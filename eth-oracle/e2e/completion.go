@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unfinishedTest is a test case that has neither passed nor failed, with the state it is in
+type unfinishedTest struct {
+	testCase *TestCase
+	state    OrderStatus
+}
+
+// unfinishedTests returns the unfinished test cases by name and a fingerprint of the suite's
+// progress, which changes whenever a test case finishes or moves to another state
+func (e *EthOracleE2E) unfinishedTests() ([]unfinishedTest, string) {
+	e.testResults.mutex.RLock()
+	defer e.testResults.mutex.RUnlock()
+
+	names := make([]string, 0, len(e.testResults.testCases))
+	for name := range e.testResults.testCases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unfinished []unfinishedTest
+	progress := []string{fmt.Sprintf("%d/%d", e.testResults.passed+e.testResults.failed, e.testResults.total)}
+	for _, name := range names {
+		testCase := e.testResults.testCases[name]
+		if testCase.finished {
+			continue
+		}
+		state := testCase.Status
+		if state == "" {
+			state = "pending"
+		}
+		unfinished = append(unfinished, unfinishedTest{testCase: testCase, state: state})
+		progress = append(progress, name+"="+string(state))
+	}
+	return unfinished, strings.Join(progress, ",")
+}
+
+// logUnfinishedTests logs which test cases are stuck in which state
+func (e *EthOracleE2E) logUnfinishedTests(unfinished []unfinishedTest, idle time.Duration) {
+	e.logger.Warnf("No test progressed for %s; %d unfinished:", idle.Round(time.Second), len(unfinished))
+	for _, test := range unfinished {
+		e.logger.Warnf("  %s: %s", test.testCase.Name, test.state)
+	}
+}
+
+// failUnfinishedTests fails every unfinished test case with the state it stalled in and cancels
+// the suite, so the running test case returns and the ones not started yet aren't run
+func (e *EthOracleE2E) failUnfinishedTests(cause error) {
+	unfinished, _ := e.unfinishedTests()
+	for _, test := range unfinished {
+		e.failTestCase(test.testCase, fmt.Errorf("stalled in state %s: %w", test.state, cause))
+	}
+	if e.suiteCancel != nil {
+		e.suiteCancel(cause)
+	}
+}
+
+// waitForTestCompletion waits for every test case to pass or fail. When no test case progresses
+// for the stall interval the stuck ones are logged, and after maxRetries such intervals, the
+// completion timeout or the suite deadline the unfinished ones are failed as stalled
+func (e *EthOracleE2E) waitForTestCompletion() {
+	timeout := time.After(completionTimeout)
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	lastProgress, lastChange, stalls := "", time.Now(), 0
+	for {
+		select {
+		case <-timeout:
+			e.logger.Errorf("Timeout waiting for test completion")
+			e.failUnfinishedTests(fmt.Errorf("%w after %s waiting for test completion", ErrTimeout, completionTimeout))
+			return
+		case <-e.suiteDone():
+			e.logger.Errorf("Stopped waiting for test completion: %v", e.suiteErr())
+			e.failUnfinishedTests(e.suiteErr())
+			return
+		case <-ticker.C:
+			unfinished, progress := e.unfinishedTests()
+			if len(unfinished) == 0 {
+				return
+			}
+			if progress != lastProgress {
+				lastProgress, lastChange, stalls = progress, time.Now(), 0
+				continue
+			}
+			if e.stallInterval <= 0 || time.Since(lastChange) < e.stallInterval*time.Duration(stalls+1) {
+				continue
+			}
+			stalls++
+			e.logUnfinishedTests(unfinished, time.Since(lastChange))
+			if e.maxRetries > 0 && stalls >= e.maxRetries {
+				e.failUnfinishedTests(fmt.Errorf("%w: no progress for %s", ErrTimeout, time.Since(lastChange).Round(time.Second)))
+				return
+			}
+		}
+	}
+}
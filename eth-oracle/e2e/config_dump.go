@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// redacted replaces secrets in the --dump-config output
+const redacted = "[redacted]"
+
+// EffectiveConfig is the configuration a run resolved from its flags, the oracle config file, the
+// environment and the built-in defaults. It holds addresses only; keys and passwords are redacted
+type EffectiveConfig struct {
+	DataDir           string          `json:"dataDir"`
+	OracleConfigPath  string          `json:"oracleConfigPath"`
+	CanopyRPCURL      string          `json:"canopyRpcUrl"`
+	CanopyAdminRPCURL string          `json:"canopyAdminRpcUrl"`
+	EthRPCURL         string          `json:"ethRpcUrl"`
+	EthChainID        string          `json:"ethChainId"`
+	TokenContract     string          `json:"tokenContract"`
+	TokenDecimals     int             `json:"tokenDecimals"`
+	Committees        []uint64        `json:"committees"`
+	EthAccounts       []string        `json:"ethAccounts"`
+	CanopyAccounts    []string        `json:"canopyAccounts"`
+	SellerNick        string          `json:"sellerNick"`
+	AmountTolerance   string          `json:"amountTolerance"`
+	Password          string          `json:"password"`
+	Timeouts          EffectiveTimers `json:"timeouts"`
+}
+
+// EffectiveTimers are the resolved intervals, timeouts and confirmation counts of a run
+type EffectiveTimers struct {
+	LockInterval     string `json:"lockInterval"`
+	DeleteTimeout    string `json:"deleteTimeout"`
+	SettleTimeout    string `json:"settleTimeout"`
+	SuiteDeadline    string `json:"suiteDeadline"`
+	StallInterval    string `json:"stallInterval"`
+	MaxRetries       int    `json:"maxRetries"`
+	MinConfirmations uint64 `json:"minConfirmations"`
+}
+
+// effectiveConfig resolves the configuration of the tester. ethRPCURL and ethChainID are passed in
+// because the tester only holds the eth client they were used for
+func (e *EthOracleE2E) effectiveConfig(ethRPCURL string, ethChainID uint64) EffectiveConfig {
+	config := EffectiveConfig{
+		DataDir:           e.dataDir,
+		OracleConfigPath:  defaultOracleConfigPath(e.dataDir),
+		CanopyRPCURL:      e.config.RPCUrl,
+		CanopyAdminRPCURL: e.config.AdminRPCUrl,
+		EthRPCURL:         ethRPCURL,
+		EthChainID:        "auto (node network id)",
+		TokenContract:     e.tokenContract,
+		TokenDecimals:     usdcDecimals,
+		Committees:        e.committees,
+		EthAccounts:       []string{},
+		CanopyAccounts:    canopyAccounts,
+		SellerNick:        e.sellerNick,
+		AmountTolerance:   e.amountTolerance.String(),
+		Timeouts: EffectiveTimers{
+			LockInterval:     e.lockInterval.String(),
+			DeleteTimeout:    e.deleteTimeout.String(),
+			SettleTimeout:    settleTimeout.String(),
+			SuiteDeadline:    "none",
+			StallInterval:    e.stallInterval.String(),
+			MaxRetries:       e.maxRetries,
+			MinConfirmations: e.minConfirmations,
+		},
+	}
+	if ethChainID != 0 {
+		config.EthChainID = fmt.Sprintf("%d", ethChainID)
+	}
+	for _, account := range ethAccounts {
+		if account != "" {
+			config.EthAccounts = append(config.EthAccounts, account)
+		}
+	}
+	if config.SellerNick == "" {
+		config.SellerNick = os.Getenv("E2E_FROM_NICK")
+	}
+	if e.sellerPass != "" || e.envPassword() != "" {
+		config.Password = redacted
+	}
+	if e.suiteDeadline > 0 {
+		config.Timeouts.SuiteDeadline = e.suiteDeadline.String()
+	}
+	return config
+}
+
+// DumpConfig prints the resolved configuration as JSON
+func (e *EthOracleE2E) DumpConfig(ethRPCURL string, ethChainID uint64) error {
+	data, err := json.MarshalIndent(e.effectiveConfig(ethRPCURL, ethChainID), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
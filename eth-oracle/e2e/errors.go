@@ -0,0 +1,18 @@
+package main
+
+import "errors"
+
+// Sentinel errors the E2E flow wraps, so callers and tests can tell failures apart with errors.Is
+// while the wrapping errors keep their human-readable messages
+var (
+	// ErrOrderNotFound is returned when no order in the queried order books matches
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrRPCUnavailable wraps a failed canopy or eth rpc request
+	ErrRPCUnavailable = errors.New("rpc unavailable")
+	// ErrBalanceMismatch is returned when a balance didn't change the way a test case expects
+	ErrBalanceMismatch = errors.New("balance mismatch")
+	// ErrOrderLocked is returned when an order can't be changed because a buyer locked it
+	ErrOrderLocked = errors.New("order locked")
+	// ErrTimeout is returned when a wait loop gives up
+	ErrTimeout = errors.New("timeout")
+)
@@ -4,27 +4,53 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"math/big"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"canopy-testing/eth-oracle/orderflow"
+	"canopy-testing/internal/password"
 	"github.com/canopy-network/canopy/cmd/rpc"
 	"github.com/canopy-network/canopy/lib"
 	"github.com/canopy-network/canopy/lib/crypto"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 const (
-	erc20TransferMethodID = "a9059cbb"
-	lockInterval          = 10 * time.Second
+	defaultLockInterval  = 1 * time.Second
+	defaultDeleteTimeout = 60 * time.Second
+	ethConnectTimeout    = 5 * time.Second
+	// defaultMinConfirmations is the number of eth confirmations the close tx needs before balances are checked
+	defaultMinConfirmations = 1
+	// defaultWatchInterval is how often --watch polls the order books
+	defaultWatchInterval = 2 * time.Second
+	// defaultCloseTimeout bounds the wait for a closed order to leave the order book, and is how long
+	// an order expected to remain must stay in it
+	defaultCloseTimeout = 120 * time.Second
+	// settleTimeout bounds the wait for a closed order to settle on both chains
+	settleTimeout = 120 * time.Second
+	// unreleasedWindow is how long a negative test watches a wrongly closed order stay open
+	unreleasedWindow = 30 * time.Second
+	// defaultDeleteWorkers is how many delete order transactions are submitted in parallel
+	defaultDeleteWorkers = 4
+	// completionTimeout bounds the wait for every test case to pass or fail
+	completionTimeout = 5 * time.Minute
+	// defaultStallInterval is how long no test case may progress before the stuck ones are logged
+	defaultStallInterval = 30 * time.Second
+	// deleteOrderFee is the fee paid for each delete order transaction
+	deleteOrderFee = uint64(100000)
 
 	chainId = 2
 )
@@ -53,13 +79,26 @@ type TestCase struct {
 	SellerAddress            string
 	SellerPrivateKey         string
 	CanopyReceiveAddress     string
-	CanopySendAddress        string
+	CanopySendAddress        string // canopy account the order is created from; defaults to the seller nick's
 	InitialBuyerUSDCBalance  *big.Int
 	InitialSellerUSDCBalance *big.Int
 	InitialCNPYBalance       uint64
+	Committee                uint64
 	OrderID                  string
-	Status                   string // "created", "locked", "closed", "verified"
+	BatchSize                int      // sells this many orders of OrderAmount, locked and closed together; 0 or 1 for one order
+	BatchOrderIDs            []string // the orders of a batch, in creation order
+	MatchedAmount            uint64   // CNPY the matched orders sell, which --amount-tolerance lets differ from OrderAmount; 0 until matched
+	CloseTxHash              common.Hash
+	CloseRecipient           string // negative tests only: pays the close transfer here instead of to the seller
+	ExpectOrderRemains       bool   // the close leaves a residual order, e.g. a partial fill, that must stay in the book
+	TokenContract            string // ERC20 the buyer pays in; defaults to the suite's token contract
+	SellerNick               string // keystore entry creating the order; defaults to the suite's seller
+	SellerPass               string // password of SellerNick
+	Status                   OrderStatus
 	Error                    error
+	Duration                 time.Duration // how long runTestCase took
+
+	finished bool // set once the test case passed or failed, under the results mutex
 }
 
 // TestResults holds the results of all test cases
@@ -137,35 +176,136 @@ func main() {
 		}
 	}
 
+	// Load the eth rpc url, token contract and test accounts from the sidecar config, which take
+	// precedence over the environment and the built-in Anvil accounts
+	dataDir := lib.DefaultDataDirPath()
+	oracleConfig, err := loadOracleConfig(defaultOracleConfigPath(dataDir))
+	if err != nil {
+		fmt.Printf("Error loading oracle config: %v\n", err)
+		os.Exit(1)
+	}
+	oracleConfig.applyAccounts()
+
 	// Command line flags
 	createOrder := flag.Bool("create-order", false, "Create a new sell order")
 	lockOrder := flag.String("lock-order", "", "Lock an order by order ID")
 	lockAllUnlocked := flag.Bool("lock-all", false, "Lock all unlocked orders")
 	closeOrder := flag.String("close-order", "", "Close an order by order ID")
 	closeAllLocked := flag.Bool("close-all", false, "Close all locked orders")
+	cancelOrder := flag.String("cancel-order", "", "Delete an unlocked order by order ID")
 	runTests := flag.Bool("run-tests", false, "Run the full E2E test suite")
+	previewTests := flag.Bool("preview-test-cases", false, "Print the balance changes each test case will assert without running it")
+	dedup := flag.Bool("dedup", false, "With --create-order, skip creating when an unlocked order with the same amounts, seller and token is already in the book")
+	seedOrders := flag.Int("seed-orders", 0, "Create this many sell orders with distinct amounts and exit")
+	dumpConfig := flag.Bool("dump-config", false, "Print the resolved configuration as JSON, with keys and passwords redacted, and exit")
+	watch := flag.Bool("watch", false, "Stream order book changes until interrupted")
+	repl := flag.Bool("repl", false, "Read create, lock, close, delete, list and balances commands from stdin over one connection")
+	watchInterval := flag.Duration("watch-interval", defaultWatchInterval, "Polling interval of --watch")
+	stallInterval := flag.Duration("stall-interval", defaultStallInterval, "With --run-tests, log the state of unfinished tests after this long without progress (0 = never)")
+	maxRetries := flag.Int("max-retries", 0, "With --run-tests, fail unfinished tests as stalled after this many stall intervals without progress (0 = wait for the 5m timeout)")
+	suiteDeadline := flag.Duration("suite-deadline", 0, "Abort --run-tests after this long and print partial results (0 = no deadline)")
+	fundAccounts := flag.Uint64("fund-accounts", 0, "With --run-tests, top up every test canopy account to this CNPY balance first")
+	reportDir := flag.String("report-dir", "", "With --run-tests, collect the log, config, summary, balance snapshots and final order book in a timestamped subdirectory of this directory")
+	summaryJSON := flag.String("summary-json", "", "With --run-tests, write a JSON summary of the results to this path")
+	rotateAccounts := flag.Bool("rotate-accounts", false, "With --run-tests, give every test case its own buyer, seller and canopy accounts")
+	completionAbsenceOnly := flag.Bool("completion-absence-only", false, "Count an order that left the book as completed without checking its CNPY was released")
+	committeeBalance := flag.Bool("committee-balance", false, "After --run-tests, reconcile the CNPY completed orders released against the receive address balances")
+	deleteWorkers := flag.Int("delete-workers", defaultDeleteWorkers, "Delete order transactions submitted in parallel before --run-tests")
+	deleteMineOnly := flag.Bool("delete-mine-only", true, "Before --run-tests, only delete orders created by the E2E_FROM_NICK and --seller-nick accounts, so the suite is safe on a shared chain")
+	resume := flag.Bool("resume", false, "With --run-tests, continue the orders left in the order book instead of deleting them")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
+	committees := flag.String("committees", fmt.Sprintf("%d", chainId), "Comma-separated committee IDs to query and create orders on")
+	maxOrders := flag.Int("max-orders", 0, "Maximum number of orders --lock-all and --close-all process per run (0 = all)")
+	amountTolerance := flag.String("amount-tolerance", "", "Match test orders whose amount for sale is within this many uCNPY, or basis points like 25bps, of the test amount (default: exact)")
+	orderIDPrefix := flag.String("order-id-prefix", "", "Only find, lock, close or watch orders whose hex ID starts with this prefix")
+	transferSelector := flag.String("transfer-selector", orderflow.ERC20TransferMethodID, "4 byte hex selector of the token transfer method close orders call")
+	balanceOfSelector := flag.String("balanceof-selector", orderflow.ERC20BalanceOfMethodID, "4 byte hex selector of the token balance method")
+	ethChainID := flag.Uint64("eth-chain-id", 0, "Chain id eth transactions are signed for (0 = the node's network id)")
+	lockGasLimit := flag.Uint64("lock-gas-limit", 0, "Gas limit of lock order transactions (0 = default 100000)")
+	ethRedials := flag.Int("eth-redials", defaultEthRedials, "Times a dropped eth connection is re-dialed, with backoff, before an eth call fails (0 = never)")
+	dumpTx := flag.Bool("dump-tx", false, "Print the hash and raw hex of every signed eth transaction before sending it")
+	dumpTxOnly := flag.Bool("dump-tx-only", false, "Print every signed eth transaction like --dump-tx without sending it")
+	closeGasLimit := flag.Uint64("close-gas-limit", 0, "Gas limit of close order transfers, for closes that revert out of gas (0 = default 100000)")
+	txRate := flag.Float64("tx-rate", 0, "Maximum eth transactions sent per second (0 = unlimited)")
+	lockInterval := flag.Duration("lock-interval", defaultLockInterval, "Delay between lock operations with --lock-all")
+	deleteTimeout := flag.Duration("delete-timeout", defaultDeleteTimeout, "How long to wait for existing orders to be deleted before running tests")
+	negativeTests := flag.Bool("negative-tests", false, "Add negative test cases, such as closing an order with a transfer to the wrong seller address")
+	batchOrders := flag.Int("batch-orders", 0, "Add a test case creating this many orders, locking them together and closing them in one flow (0 = none)")
+	minConfirmations := flag.Uint64("min-confirmations", defaultMinConfirmations, "Eth confirmations the close tx needs before final balances are checked")
 
 	// Order parameters
-	amount := flag.Uint64("amount", 1000000, "Order amount in smallest unit (default: 1 USDC = 1000000)")
+	amountFlag := amountValue(1000000)
+	flag.Var(&amountFlag, "amount", "Order amount in smallest unit or with a token suffix, e.g. 1.5usdc or 2cnpy (default: 1 USDC = 1000000)")
+	seedMinFlag, seedMaxFlag := amountValue(0), amountValue(0)
+	flag.Var(&seedMinFlag, "seed-amount-min", "Smallest --seed-orders amount (default: --amount)")
+	flag.Var(&seedMaxFlag, "seed-amount-max", "Randomize --seed-orders amounts up to this amount (default: consecutive amounts from --seed-amount-min)")
 	buyerAddr := flag.String("buyer-addr", ethAccounts[0], "Buyer Ethereum address")
 	buyerKey := flag.String("buyer-key", ethPrivateKeys[0], "Buyer private key")
 	sellerAddr := flag.String("seller-addr", ethAccounts[1], "Seller Ethereum address")
 	_ = flag.String("seller-key", ethPrivateKeys[1], "Seller private key") // Reserved for future use
+	sellerNick := flag.String("seller-nick", "", "Keystore nickname orders are created by (default: $E2E_FROM_NICK)")
+	sellerPass := flag.String("seller-pass", "", "Password of --seller-nick (default: $E2E_FROM_PASS)")
+	verifyPassword := flag.Bool("verify-keystore-password", false, "Before running, check the seller's password decrypts its keystore entry and exit non-zero if it doesn't")
+	passwordFile := flag.String("password-file", "", "Read the canopy password from this file instead of $E2E_FROM_PASS; can't be combined with --seller-pass")
 	canopyAddr := flag.String("canopy-addr", canopyAccounts[0], "Canopy receive address")
+	canopySendAddr := flag.String("canopy-send-addr", "", "With --create-order, canopy account the order's CNPY is debited from (default: the --seller-nick or $E2E_FROM_NICK account)")
+	tokenContract := flag.String("token-contract", "", "ERC20 contract orders are paid in (default: usdcContract in "+oracleConfigFile+", then $USDC_CONTRACT)")
 
 	flag.Parse()
+	amount := (*uint64)(&amountFlag)
 
 	// Show help if no flags provided
-	if !*createOrder && *lockOrder == "" && !*lockAllUnlocked && *closeOrder == "" && !*closeAllLocked && !*runTests {
+	operation := *createOrder || *lockOrder != "" || *lockAllUnlocked || *closeOrder != "" || *closeAllLocked || *cancelOrder != "" ||
+		*runTests || *previewTests || *watch || *repl || *seedOrders != 0
+	if !operation && !*dumpConfig && !*verifyPassword {
 		fmt.Println("Usage:")
 		fmt.Println("  --create-order                    Create a new sell order")
+		fmt.Println("  --dedup                           Skip --create-order when a matching unlocked order is already in the book")
 		fmt.Println("  --lock-order <order-id|first>     Lock an order (use 'first' for first unlocked)")
 		fmt.Println("  --lock-all                        Lock all unlocked orders")
 		fmt.Println("  --close-order <order-id|first>    Close an order (use 'first' for first locked)")
 		fmt.Println("  --close-all                       Close all locked orders")
+		fmt.Println("  --cancel-order <order-id>         Delete a single unlocked order")
 		fmt.Println("  --run-tests                       Run full E2E test suite")
-		fmt.Println("  --verbose                         Enable verbose logging")
+		fmt.Println("  --preview-test-cases              Print the balance changes each test case asserts without running it")
+		fmt.Println("  --watch                           Stream order book changes until interrupted")
+		fmt.Println("  --repl                            Run create, lock, close, delete, list and balances commands read from stdin")
+		fmt.Println("  --dump-config                     Print the resolved configuration as JSON and exit")
+		fmt.Println("  --seed-orders <n>                 Create n sell orders with distinct amounts")
+		fmt.Println("  --seed-amount-min <amount>        Smallest --seed-orders amount (default: --amount)")
+		fmt.Println("  --seed-amount-max <amount>        Randomize --seed-orders amounts up to this amount")
+		fmt.Println("  --watch-interval <duration>       Polling interval of --watch (default: 2s)")
+		fmt.Println("  --suite-deadline <duration>       Abort --run-tests after this long and print partial results")
+		fmt.Println("  --stall-interval <duration>       Log unfinished tests after this long without progress (default: 30s)")
+		fmt.Println("  --max-retries <n>                 Fail unfinished tests as stalled after n stall intervals (default: 0, wait 5m)")
+		fmt.Println("  --fund-accounts <amount>          Top up every test canopy account to this CNPY balance before --run-tests")
+		fmt.Println("  --summary-json <path>             Write a JSON summary of the --run-tests results to this file")
+		fmt.Println("  --report-dir <dir>                Bundle the --run-tests log, config, summary, balances and order book in one directory")
+		fmt.Println("  --resume                          Continue in-flight orders with --run-tests instead of deleting them")
+		fmt.Println("  --committee-balance               Reconcile CNPY released from committee escrow after --run-tests")
+		fmt.Println("  --rotate-accounts                 Give every --run-tests case its own buyer, seller and canopy accounts")
+		fmt.Println("  --completion-absence-only         Count an order that left the book as completed without checking its CNPY release")
+		fmt.Println("  --delete-mine-only=false          Delete the orders of every keystore account before --run-tests, not only the test accounts'")
+		fmt.Println("  --verbose                         Enable verbose logging and print order book changes per test step")
+		fmt.Println("  --committees <id,id,...>          Committees to query and create orders on (default: 2)")
+		fmt.Println("  --max-orders <n>                  Orders --lock-all and --close-all process per run (default: all)")
+		fmt.Println("  --amount-tolerance <n|nbps>       Match test orders within n uCNPY or n basis points of the test amount (default: exact)")
+		fmt.Println("  --order-id-prefix <hex>           Only find, lock, close or watch orders whose ID starts with this prefix")
+		fmt.Println("  --tx-rate <tx/sec>                Maximum eth transactions sent per second (default: unlimited)")
+		fmt.Println("  --lock-gas-limit <gas>            Gas limit of lock order transactions (default: 100000)")
+		fmt.Println("  --close-gas-limit <gas>           Gas limit of close order transfers (default: 100000)")
+		fmt.Println("  --eth-chain-id <id>               Chain id eth transactions are signed for (default: the node's network id)")
+		fmt.Println("  --dump-tx                         Print the raw signed eth transactions, for eth_sendRawTransaction elsewhere")
+		fmt.Println("  --dump-tx-only                    Print the raw signed eth transactions without sending them")
+		fmt.Println("  --eth-redials <n>                 Times a dropped eth connection is re-dialed before failing (default: 3)")
+		fmt.Println("  --transfer-selector <hex>         Token transfer method selector for non-standard tokens (default: a9059cbb)")
+		fmt.Println("  --balanceof-selector <hex>        Token balance method selector for non-standard tokens (default: 70a08231)")
+		fmt.Println("  --lock-interval <duration>        Delay between lock operations with --lock-all (default: 1s)")
+		fmt.Println("  --delete-timeout <duration>       Wait for existing orders to be deleted (default: 60s)")
+		fmt.Println("  --delete-workers <n>              Delete order transactions submitted in parallel (default: 4)")
+		fmt.Println("  --min-confirmations <n>           Close tx confirmations before checking balances (default: 1)")
+		fmt.Println("  --negative-tests                  Add negative test cases with --run-tests")
+		fmt.Println("  --batch-orders <n>                Add a --run-tests case locking and closing n orders together")
 		fmt.Println("\nExamples:")
 		fmt.Println("  ./eth_oracle_e2e --create-order")
 		fmt.Println("  ./eth_oracle_e2e --lock-order first")
@@ -174,16 +314,75 @@ func main() {
 		fmt.Println("  ./eth_oracle_e2e --close-all")
 		fmt.Println("  ./eth_oracle_e2e --lock-order abc123def456")
 		fmt.Println("\nOrder Parameters (all have defaults):")
-		fmt.Printf("  --amount <amount>                 Order amount (default: 1000000)\n")
+		fmt.Printf("  --amount <amount>                 Order amount, raw or suffixed like 1.5usdc / 2cnpy (default: 1000000)\n")
 		fmt.Printf("  --buyer-addr <address>            Buyer address (default: %s)\n", ethAccounts[0])
 		fmt.Printf("  --buyer-key <private-key>         Buyer private key (default: %s)\n", ethPrivateKeys[0])
 		fmt.Printf("  --seller-addr <address>           Seller address (default: %s)\n", ethAccounts[1])
 		fmt.Printf("  --seller-key <private-key>        Seller private key (default: %s)\n", ethPrivateKeys[1])
+		fmt.Println("  --seller-nick <nickname>          Keystore nickname orders are created by (default: $E2E_FROM_NICK)")
+		fmt.Println("  --seller-pass <password>          Password of --seller-nick (default: $E2E_FROM_PASS)")
+		fmt.Println("  --password-file <path>            Read the canopy password from a file instead of $E2E_FROM_PASS")
+		fmt.Println("  --verify-keystore-password        Check the seller's password decrypts its keystore entry before running")
 		fmt.Printf("  --canopy-addr <address>           Canopy address (default: %s)\n", canopyAccounts[0])
+		fmt.Println("  --canopy-send-addr <address>      Canopy account --create-order debits the CNPY from (default: the seller nick's)")
+		fmt.Printf("  --token-contract <address>        ERC20 contract orders are paid in (default: usdcContract in %s, then $USDC_CONTRACT)\n", oracleConfigFile)
 		return
 	}
 
-	dataDir := lib.DefaultDataDirPath()
+	txOptions := orderflow.Options{ChainID: *ethChainID, LockGasLimit: *lockGasLimit, CloseGasLimit: *closeGasLimit}
+	if *txRate != 0 {
+		limiter, err := orderflow.NewRateLimiter(*txRate, 1)
+		if err != nil {
+			fmt.Printf("Invalid --tx-rate: %v\n", err)
+			os.Exit(1)
+		}
+		txOptions.Limiter = limiter
+	}
+	if *dumpTx || *dumpTxOnly {
+		txOptions.TxDump, txOptions.TxDumpOnly = os.Stdout, *dumpTxOnly
+	}
+	if *maxRetries < 0 {
+		fmt.Printf("Invalid --max-retries: %d is negative\n", *maxRetries)
+		os.Exit(1)
+	}
+	if *ethRedials < 0 {
+		fmt.Printf("Invalid --eth-redials: %d is negative\n", *ethRedials)
+		os.Exit(1)
+	}
+	if *seedOrders < 0 {
+		fmt.Printf("Invalid --seed-orders: %d is negative\n", *seedOrders)
+		os.Exit(1)
+	}
+	tolerance, err := parseAmountTolerance(*amountTolerance)
+	if err != nil {
+		fmt.Printf("Invalid --amount-tolerance: %v\n", err)
+		os.Exit(1)
+	}
+	prefix, err := parseOrderIDPrefix(*orderIDPrefix)
+	if err != nil {
+		fmt.Printf("Invalid --order-id-prefix: %v\n", err)
+		os.Exit(1)
+	}
+	if txOptions.TransferSelector, err = orderflow.ParseSelector(*transferSelector); err != nil {
+		fmt.Printf("Invalid --transfer-selector: %v\n", err)
+		os.Exit(1)
+	}
+	if txOptions.BalanceOfSelector, err = orderflow.ParseSelector(*balanceOfSelector); err != nil {
+		fmt.Printf("Invalid --balanceof-selector: %v\n", err)
+		os.Exit(1)
+	}
+	var filePassword string
+	if *passwordFile != "" {
+		if *sellerPass != "" {
+			fmt.Println("Invalid --password-file: --seller-pass already sets the password")
+			os.Exit(1)
+		}
+		if filePassword, err = password.Read(*passwordFile); err != nil {
+			fmt.Printf("Invalid --password-file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	configFilePath := filepath.Join(dataDir, lib.ConfigFilePath)
 
 	// load the config object
@@ -193,12 +392,82 @@ func main() {
 	}
 	c.DataDirPath = dataDir
 
-	e2e, err := NewEthOracleE2E(c, dataDir)
+	// dumping the config or only checking the keystore must work without a reachable eth node
+	var e2e *EthOracleE2E
+	if *dumpConfig || !operation {
+		e2e = newEthOracleE2E(c, dataDir, oracleConfig)
+	} else {
+		e2e, err = NewEthOracleE2E(c, dataDir, oracleConfig)
+		if err != nil {
+			fmt.Printf("Error initializing E2E tester: %v\n", err)
+			return
+		}
+	}
+	e2e.committees, err = parseCommittees(*committees)
 	if err != nil {
-		fmt.Printf("Error initializing E2E tester: %v\n", err)
+		fmt.Printf("Error parsing --committees: %v\n", err)
+		os.Exit(1)
+	}
+	e2e.txOptions = txOptions
+	e2e.password = filePassword
+	e2e.lockInterval = *lockInterval
+	e2e.deleteTimeout = *deleteTimeout
+	e2e.minConfirmations = *minConfirmations
+	e2e.negativeTests = *negativeTests
+	e2e.batchOrders = *batchOrders
+	e2e.verbose = *verbose
+	e2e.resume = *resume
+	e2e.deleteMineOnly = *deleteMineOnly
+	e2e.deleteWorkers = *deleteWorkers
+	e2e.committeeBalance = *committeeBalance
+	e2e.completionAbsenceOnly = *completionAbsenceOnly
+	e2e.rotateAccounts = *rotateAccounts
+	e2e.maxOrders = *maxOrders
+	e2e.orderIDPrefix = prefix
+	e2e.amountTolerance = tolerance
+	if e2e.ethClient != nil {
+		e2e.ethClient.redials = *ethRedials
+	}
+	e2e.suiteDeadline = *suiteDeadline
+	e2e.stallInterval = *stallInterval
+	e2e.maxRetries = *maxRetries
+	e2e.fundAmount = *fundAccounts
+	e2e.summaryPath = *summaryJSON
+	e2e.sellerNick = *sellerNick
+	e2e.sellerPass = *sellerPass
+	if *tokenContract != "" {
+		e2e.tokenContract = *tokenContract
+	}
+	if *reportDir != "" {
+		if !*runTests {
+			fmt.Println("--report-dir collects the artifacts of --run-tests")
+			os.Exit(1)
+		}
+		dir, err := e2e.startReport(*reportDir, oracleConfig.ethRPCURL(), *ethChainID)
+		if err != nil {
+			fmt.Printf("Invalid --report-dir: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Collecting run artifacts in %s\n", dir)
+	}
+
+	if *dumpConfig {
+		if err := e2e.DumpConfig(oracleConfig.ethRPCURL(), *ethChainID); err != nil {
+			fmt.Printf("Error dumping config: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
+	if *verifyPassword {
+		nick, err := e2e.verifyKeystorePassword()
+		if err != nil {
+			fmt.Printf("Keystore check failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Keystore password for %s verified\n", nick)
+	}
+
 	// Route to appropriate operation
 	if *createOrder {
 		// Use default seller address if not provided or use first account
@@ -213,7 +482,19 @@ func main() {
 			canopyAddress = canopyAccounts[0]
 		}
 
-		err := e2e.CreateSellOrder(*amount, *amount, sellerAddress, canopyAddress)
+		if *dedup {
+			existing, err := e2e.findDuplicateOrder(e2e.committees[0], *amount, *amount, sellerAddress, e2e.tokenContract)
+			if err != nil {
+				fmt.Printf("Error checking for a duplicate order: %v\n", err)
+				os.Exit(1)
+			}
+			if existing != nil {
+				fmt.Printf("Order %s already matches, not creating a duplicate\n", lib.BytesToString(existing.Id))
+				return
+			}
+		}
+
+		err := e2e.CreateSellOrder(e2e.committees[0], *amount, *amount, sellerAddress, *canopySendAddr, canopyAddress, e2e.tokenContract, e2e.sellerNick, e2e.sellerPass)
 		if err != nil {
 			fmt.Printf("Error creating order: %v\n", err)
 			os.Exit(1)
@@ -269,29 +550,138 @@ func main() {
 			os.Exit(1)
 		}
 		fmt.Printf("All locked orders closed successfully\n")
+	} else if *cancelOrder != "" {
+		if err := e2e.CancelOrder(*cancelOrder); err != nil {
+			fmt.Printf("Error cancelling order: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Order %s cancelled\n", *cancelOrder)
 	} else if *runTests {
 		if *verbose {
 			fmt.Println("Running test suite in verbose mode")
 		}
 		e2e.RunTestSuite()
+	} else if *previewTests {
+		e2e.PreviewTestCases()
+	} else if *seedOrders > 0 {
+		seedMin := uint64(seedMinFlag)
+		if seedMin == 0 {
+			seedMin = *amount
+		}
+		orderIDs, err := e2e.SeedOrders(*seedOrders, seedMin, uint64(seedMaxFlag), *sellerAddr, *canopyAddr)
+		if err != nil {
+			fmt.Printf("Error seeding orders after %d of %d: %v\n", len(orderIDs), *seedOrders, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Seeded %d orders\n", len(orderIDs))
+	} else if *watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := e2e.WatchOrders(ctx, *watchInterval); err != nil {
+			fmt.Printf("Error watching orders: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *repl {
+		defaults := replDefaults{
+			amount:            *amount,
+			sellerAddress:     *sellerAddr,
+			canopySendAddress: *canopySendAddr,
+			canopyAddress:     *canopyAddr,
+			buyerAddress:      *buyerAddr,
+			buyerKey:          *buyerKey,
+		}
+		if err := e2e.RunREPL(os.Stdin, os.Stdout, defaults); err != nil {
+			fmt.Printf("Error reading commands: %v\n", err)
+			os.Exit(1)
+		}
 	}
 }
 
 // EthOracleE2E handles RPC requests to the canopy blockchain
 type EthOracleE2E struct {
-	ethClient   *ethclient.Client
-	client      *rpc.Client
+	ethClient   *reconnectingEthClient
+	client      orderflow.CanopyClient
 	dataDir     string
 	logger      lib.LoggerI
 	config      lib.Config
 	testResults *TestResults
+
+	// txOptions configures how lock and close transactions are built, signed and sent
+	txOptions orderflow.Options
+	// committees are the committees orders are queried from; orders are created on the first
+	committees []uint64
+	// lockInterval is the delay between lock operations when locking in batch
+	lockInterval time.Duration
+	// deleteTimeout bounds the wait for deleted orders to leave the order book
+	deleteTimeout time.Duration
+	// closeTimeout bounds the wait for a closed order to complete, and is how long an order expected
+	// to remain must stay in the book
+	closeTimeout time.Duration
+	// minConfirmations is the number of confirmations the close tx needs before balances are checked
+	minConfirmations uint64
+	// negativeTests adds test cases that must not release an order
+	negativeTests bool
+	// batchOrders adds a test case locking and closing this many orders together, 0 for none
+	batchOrders int
+	// verbose prints the order book changes of each test step
+	verbose bool
+	// tokenContract is the ERC20 orders are paid in unless a test case sets its own
+	tokenContract string
+	// resume continues the orders left in the order book instead of deleting them
+	resume bool
+	// maxOrders caps how many orders --lock-all and --close-all touch, 0 for no cap
+	maxOrders int
+	// orderIDPrefix narrows the finders and --watch to orders whose lowercase hex ID starts with
+	// it, empty for every order. Sell orders don't record the height they were created at, so
+	// the ID is the only handle on a specific order
+	orderIDPrefix string
+	// amountTolerance is how far a test order's amount for sale may be from the test case's and
+	// still be matched as its order, exact by default
+	amountTolerance amountTolerance
+	// fundAmount is the CNPY balance every test account is topped up to before the suite, 0 to skip
+	fundAmount uint64
+	// sellerNick and sellerPass are the keystore entry orders are created by, empty for E2E_FROM_NICK
+	sellerNick string
+	sellerPass string
+	// password is the canopy password read from --password-file, used instead of E2E_FROM_PASS
+	password string
+	// summaryPath is where RunTestSuite writes its JSON summary, empty to skip
+	summaryPath string
+	// reportDir collects the artifacts of a --report-dir run, with the log copied to reportLog
+	reportDir string
+	reportLog *os.File
+	// committeeBalance reconciles the CNPY released by completed orders after a suite run
+	committeeBalance bool
+	// deleteWorkers is how many delete order transactions are submitted in parallel
+	deleteWorkers int
+	// rotateAccounts gives every test case its own buyer, seller and canopy accounts
+	rotateAccounts bool
+	// completionAbsenceOnly counts an order that left the book as completed without checking
+	// that its CNPY was released
+	completionAbsenceOnly bool
+	// deleteMineOnly limits the cleanup before a suite run to orders created by the E2E_FROM_NICK
+	// and seller accounts instead of by any keystore account
+	deleteMineOnly bool
+	// stallInterval is how long no test case may progress before the stuck ones are logged, 0 to
+	// never log them
+	stallInterval time.Duration
+	// maxRetries is how many stall intervals pass without progress before the unfinished test cases
+	// are failed, 0 to wait out completionTimeout
+	maxRetries int
+	// suiteDeadline bounds a whole RunTestSuite run, 0 for no bound
+	suiteDeadline time.Duration
+	// suiteCtx is cancelled when the suite deadline is exceeded or the unfinished test cases are
+	// failed as stalled; nil outside RunTestSuite
+	suiteCtx context.Context
+	// suiteCancel cancels suiteCtx with the reason the suite stopped
+	suiteCancel context.CancelCauseFunc
 }
 
 // NewEthOracleE2E creates a new E2E tester instance
-func NewEthOracleE2E(config lib.Config, dataDir string) (*EthOracleE2E, error) {
-	ethUrl := os.Getenv("ETH_RPC_URL")
+func NewEthOracleE2E(config lib.Config, dataDir string, oracleConfig *OracleConfig) (*EthOracleE2E, error) {
+	ethUrl := oracleConfig.ethRPCURL()
 	if ethUrl == "" {
-		return nil, fmt.Errorf("ETH_RPC_URL environment variable not set")
+		return nil, fmt.Errorf("no eth rpc url: set ethRpcUrl in %s or the ETH_RPC_URL environment variable", oracleConfigFile)
 	}
 
 	// connect to rpc endpoint
@@ -300,6 +690,21 @@ func NewEthOracleE2E(config lib.Config, dataDir string) (*EthOracleE2E, error) {
 		return nil, err
 	}
 
+	// dial is lazy, so make a real request to fail fast on an unreachable node
+	ctx, cancel := context.WithTimeout(context.Background(), ethConnectTimeout)
+	defer cancel()
+	if _, err := ethClient.NetworkID(ctx); err != nil {
+		return nil, fmt.Errorf("cannot reach eth node at %s: %w", ethUrl, err)
+	}
+
+	e := newEthOracleE2E(config, dataDir, oracleConfig)
+	e.ethClient = newReconnectingEthClient(ethUrl, ethClient, e.logger)
+	return e, nil
+}
+
+// newEthOracleE2E creates an E2E tester with its defaults and canopy client but no eth client, for
+// commands like --dump-config that must work without a reachable eth node
+func newEthOracleE2E(config lib.Config, dataDir string, oracleConfig *OracleConfig) *EthOracleE2E {
 	// initialize logger
 	logger := lib.NewDefaultLogger()
 
@@ -309,51 +714,159 @@ func NewEthOracleE2E(config lib.Config, dataDir string) (*EthOracleE2E, error) {
 	client := rpc.NewClient(config.RPCUrl, config.AdminRPCUrl)
 
 	return &EthOracleE2E{
-		ethClient: ethClient,
-		client:    client,
-		dataDir:   dataDir,
-		logger:    logger,
-		config:    config,
+		client:  client,
+		dataDir: dataDir,
+		logger:  logger,
+		config:  config,
 		testResults: &TestResults{
 			testCases: make(map[string]*TestCase),
 		},
-	}, nil
+		committees:       []uint64{chainId},
+		lockInterval:     defaultLockInterval,
+		deleteTimeout:    defaultDeleteTimeout,
+		closeTimeout:     defaultCloseTimeout,
+		minConfirmations: defaultMinConfirmations,
+		tokenContract:    oracleConfig.usdcContract(),
+		deleteMineOnly:   true,
+		deleteWorkers:    defaultDeleteWorkers,
+		stallInterval:    defaultStallInterval,
+	}
 }
 
 // RunTestSuite runs the complete test suite
 func (e *EthOracleE2E) RunTestSuite() {
 	e.logger.Info("Starting E2E Oracle Test Suite")
 
-	// Delete all existing orders before starting tests
-	err := e.deleteAllExistingOrders()
-	if err != nil {
-		e.logger.Errorf("Failed to delete existing orders: %v", err)
-		return
+	// Bound the whole run so a hung node can't keep the process alive
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	defer cancelCause(nil)
+	if e.suiteDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.suiteDeadline)
+		defer cancel()
+	}
+	e.suiteCtx, e.suiteCancel = ctx, cancelCause
+	defer func() { e.suiteCtx, e.suiteCancel = nil, nil }()
+
+	// Write the summary however the run ends, including a failed setup
+	if e.summaryPath != "" {
+		defer func() {
+			if err := e.writeSummary(e.summaryPath); err != nil {
+				e.logger.Errorf("Failed to write summary: %v", err)
+				return
+			}
+			e.logger.Infof("Wrote test summary to %s", e.summaryPath)
+		}()
 	}
 
-	// Generate test cases
-	testCases := e.generateTestCases()
+	// Bundle the artifacts of the run however it ends
+	var before, after *BalanceSnapshot
+	defer func() { e.finishReport(before, after) }()
 
-	// Run tests
+	var testCases []*TestCase
+	if e.resume {
+		// Continue the orders an earlier run left in the order book
+		var err error
+		testCases, err = e.resumeTestCases()
+		if err != nil {
+			e.logger.Errorf("Failed to resume existing orders: %v", err)
+			return
+		}
+		e.logger.Infof("Resuming %d existing orders", len(testCases))
+	} else {
+		// Generate test cases first, so too few accounts to rotate fails before anything is deleted
+		testCases = e.generateTestCases()
+		if e.rotateAccounts {
+			if err := rotateTestAccounts(testCases); err != nil {
+				e.logger.Errorf("Failed to rotate test accounts: %v", err)
+				return
+			}
+		}
+
+		// Delete all existing orders before starting tests
+		err := e.deleteAllExistingOrders()
+		if err != nil {
+			e.logger.Errorf("Failed to delete existing orders: %v", err)
+			return
+		}
+	}
+
+	// Make sure the test accounts hold CNPY on a freshly generated chain
+	if e.fundAmount > 0 {
+		if err := e.fundAccounts(testCases, e.fundAmount); err != nil {
+			e.logger.Errorf("Failed to fund test accounts: %v", err)
+			return
+		}
+	}
+
+	// Record every account balance before the suite runs
+	before = e.snapshotBalances()
+
+	// Register every test case first, so the completion wait sees the ones not started yet
+	e.testResults.mutex.Lock()
 	for _, testCase := range testCases {
-		e.testResults.mutex.Lock()
 		e.testResults.testCases[testCase.Name] = testCase
 		e.testResults.total++
-		e.testResults.mutex.Unlock()
-
-		e.logger.Infof("Test %s - Started", testCase.Name)
-		e.runTestCase(testCase)
 	}
+	e.testResults.mutex.Unlock()
+
+	// Run tests one after another while the completion wait watches for stalls
+	running := make(chan struct{})
+	go func() {
+		defer close(running)
+		for _, testCase := range testCases {
+			// Fail the remaining tests without running them once the suite is cancelled
+			if err := e.suiteErr(); err != nil {
+				e.failTestCase(testCase, err)
+				continue
+			}
+
+			e.logger.Infof("Test %s - Started", testCase.Name)
+			started := time.Now()
+			e.runTestCase(testCase)
+			testCase.Duration = time.Since(started)
+		}
+	}()
 
-	// Wait for all tests to complete
+	// Wait for all tests to complete, then for a test case cancelled as stalled to return, so
+	// nothing changes the results while they are printed
 	e.waitForTestCompletion()
+	<-running
+
+	// Compare every account balance against the start of the suite
+	after = e.snapshotBalances()
+	e.printBalanceDiff(before, after)
+	if e.committeeBalance {
+		e.printEscrowReport(before, after)
+	}
 
 	// Print final results
 	e.printTestResults()
 }
 
-// generateTestCases creates test cases for different scenarios
+// generateTestCases creates test cases for different scenarios on every configured committee
 func (e *EthOracleE2E) generateTestCases() []*TestCase {
+	var testCases []*TestCase
+	for _, committee := range e.committees {
+		for _, testCase := range e.committeeTestCases() {
+			testCase.Committee = committee
+			if testCase.TokenContract == "" {
+				testCase.TokenContract = e.tokenContract
+			}
+			if testCase.SellerNick == "" {
+				testCase.SellerNick, testCase.SellerPass = e.sellerNick, e.sellerPass
+			}
+			if len(e.committees) > 1 {
+				testCase.Name = fmt.Sprintf("%s_Committee%d", testCase.Name, committee)
+			}
+			testCases = append(testCases, testCase)
+		}
+	}
+	return testCases
+}
+
+// committeeTestCases creates the test cases run on each committee
+func (e *EthOracleE2E) committeeTestCases() []*TestCase {
 	testCases := []*TestCase{
 		{
 			Name:                 "BasicOrderFlow_1000USDC",
@@ -365,8 +878,7 @@ func (e *EthOracleE2E) generateTestCases() []*TestCase {
 			SellerAddress:        ethAccounts[1],
 			SellerPrivateKey:     ethPrivateKeys[1],
 			CanopyReceiveAddress: canopyAccounts[1],
-			CanopySendAddress:    canopyAccounts[1],
-			Status:               "created",
+			Status:               StatusCreated,
 		},
 		// {
 		// 	Name:                 "LargeOrderFlow_10000USDC",
@@ -379,7 +891,7 @@ func (e *EthOracleE2E) generateTestCases() []*TestCase {
 		// 	SellerPrivateKey:     ethPrivateKeys[2],
 		// 	CanopyReceiveAddress: canopyAccounts[1],
 		// 	CanopySendAddress:    canopyAccounts[1],
-		// 	Status:               "created",
+		// 	Status:               StatusCreated,
 		// },
 		// {
 		// 	Name:                 "BasicOrderFlow_1000USDC",
@@ -392,7 +904,7 @@ func (e *EthOracleE2E) generateTestCases() []*TestCase {
 		// 	SellerPrivateKey:     ethPrivateKeys[1],
 		// 	CanopyReceiveAddress: canopyAccounts[1],
 		// 	CanopySendAddress:    canopyAccounts[1],
-		// 	Status:               "created",
+		// 	Status:               StatusCreated,
 		// },
 		// {
 		// 	Name:                 "LargeOrderFlow_10000USDC",
@@ -405,27 +917,110 @@ func (e *EthOracleE2E) generateTestCases() []*TestCase {
 		// 	SellerPrivateKey:     ethPrivateKeys[2],
 		// 	CanopyReceiveAddress: canopyAccounts[1],
 		// 	CanopySendAddress:    canopyAccounts[1],
-		// 	Status:               "created",
+		// 	Status:               StatusCreated,
 		// },
 	}
 
+	if e.batchOrders > 0 {
+		testCases = append(testCases, batchTestCase(e.batchOrders))
+	}
+
+	if e.negativeTests {
+		testCases = append(testCases, &TestCase{
+			Name:                 "WrongSellerAddressClose_2000USDC",
+			OrderAmount:          2000000, // distinct from the positive cases so the locked order is matched
+			ExpectedUSDCTransfer: 2000000,
+			BuyerAddress:         ethAccounts[0],
+			BuyerPrivateKey:      ethPrivateKeys[0],
+			SellerAddress:        ethAccounts[1],
+			SellerPrivateKey:     ethPrivateKeys[1],
+			CanopyReceiveAddress: canopyAccounts[1],
+			CloseRecipient:       ethAccounts[2],
+			Status:               StatusCreated,
+		})
+	}
+
 	return testCases
 }
 
+// rotateTestAccounts gives every test case its own buyer, seller and canopy accounts, handed out in
+// order from the loaded account lists, so cases don't share balances. A case closing to a wrong
+// recipient also gets its own recipient. Canopy accounts start at 1 like the default cases, leaving
+// the first key to the validator
+func rotateTestAccounts(testCases []*TestCase) error {
+	ethNeeded, canopyNeeded := 0, len(testCases)+1
+	for _, testCase := range testCases {
+		ethNeeded += 2
+		if testCase.CloseRecipient != "" {
+			ethNeeded++
+		}
+	}
+	// the account arrays are only filled up to the accounts that are set
+	ethLoaded := 0
+	for ethLoaded < len(ethAccounts) && ethAccounts[ethLoaded] != "" && ethPrivateKeys[ethLoaded] != "" {
+		ethLoaded++
+	}
+	if ethNeeded > ethLoaded {
+		return fmt.Errorf("%d test cases need %d eth accounts, only %d are loaded", len(testCases), ethNeeded, ethLoaded)
+	}
+	if canopyNeeded > len(canopyAccounts) {
+		return fmt.Errorf("%d test cases need %d canopy accounts, only %d are loaded", len(testCases), canopyNeeded, len(canopyAccounts))
+	}
+
+	eth := 0
+	for i, testCase := range testCases {
+		testCase.BuyerAddress, testCase.BuyerPrivateKey = ethAccounts[eth], ethPrivateKeys[eth]
+		testCase.SellerAddress, testCase.SellerPrivateKey = ethAccounts[eth+1], ethPrivateKeys[eth+1]
+		eth += 2
+		if testCase.CloseRecipient != "" {
+			testCase.CloseRecipient = ethAccounts[eth]
+			eth++
+		}
+		testCase.CanopyReceiveAddress = canopyAccounts[i+1]
+	}
+	return nil
+}
+
 // runTestCase executes a single test case
 func (e *EthOracleE2E) runTestCase(testCase *TestCase) {
-	// Record initial balances
-	e.recordInitialBalances(testCase)
+	if testCase.BatchSize > 1 {
+		e.runBatchTestCase(testCase)
+		return
+	}
 
-	// Create order
-	err := e.createTestOrder(testCase)
-	if err != nil {
-		e.failTestCase(testCase, fmt.Errorf("failed to create order: %w", err))
+	// Fail early when the buyer can't pay for the close instead of mid-flow
+	if err := e.checkBuyerFunds(testCase); err != nil {
+		e.failTestCase(testCase, err)
 		return
 	}
 
-	// Wait for order to be available and lock it
-	err = e.waitAndLockOrder(testCase)
+	// Record initial balances
+	e.recordInitialBalances(testCase)
+
+	// Snapshot the order book so verbose runs show what each step changed
+	orders := e.traceOrders(testCase, "start", nil)
+
+	var err error
+	switch {
+	case testCase.OrderID == "":
+		// Create order
+		err = e.createTestOrder(testCase)
+		orders = e.traceOrders(testCase, "create", orders)
+		if err != nil {
+			e.failTestCase(testCase, fmt.Errorf("failed to create order: %w", err))
+			return
+		}
+
+		// Wait for order to be available and lock it
+		err = e.waitAndLockOrder(testCase)
+	case testCase.Status == StatusCreated:
+		// Resumed unlocked order
+		err = e.LockOrder(testCase.OrderID, testCase.BuyerAddress, testCase.BuyerPrivateKey, testCase.CanopyReceiveAddress)
+	case testCase.BuyerPrivateKey == "":
+		// Resumed order locked by an unknown buyer
+		err = fmt.Errorf("no private key for buyer %s", testCase.BuyerAddress)
+	}
+	orders = e.traceOrders(testCase, "lock", orders)
 	if err != nil {
 		e.failTestCase(testCase, fmt.Errorf("failed to lock order: %w", err))
 		return
@@ -434,12 +1029,26 @@ func (e *EthOracleE2E) runTestCase(testCase *TestCase) {
 	// Close the order
 	err = e.closeTestOrder(testCase)
 	if err != nil {
+		e.traceOrders(testCase, "close", orders)
 		e.failTestCase(testCase, fmt.Errorf("failed to close order: %w", err))
 		return
 	}
 
+	// A close paying the wrong recipient must leave the order open
+	if testCase.CloseRecipient != "" {
+		e.traceOrders(testCase, "close", orders)
+		err = e.verifyOrderNotReleased(testCase)
+		if err != nil {
+			e.failTestCase(testCase, fmt.Errorf("order released by wrong close: %w", err))
+			return
+		}
+		e.passTestCase(testCase)
+		return
+	}
+
 	// Wait for order to be completed and removed from order book
 	err = e.waitForOrderCompletion(testCase)
+	e.traceOrders(testCase, "close", orders)
 	if err != nil {
 		e.failTestCase(testCase, fmt.Errorf("failed to wait for order completion: %w", err))
 		return
@@ -460,13 +1069,13 @@ func (e *EthOracleE2E) recordInitialBalances(testCase *TestCase) {
 	var err error
 
 	// Record initial USDC balances
-	testCase.InitialBuyerUSDCBalance, err = e.getUSDCBalance(testCase.BuyerAddress)
+	testCase.InitialBuyerUSDCBalance, err = e.getTokenBalance(testCase.TokenContract, testCase.BuyerAddress)
 	if err != nil {
 		e.logger.Errorf("Failed to get initial buyer USDC balance: %v", err)
 		testCase.InitialBuyerUSDCBalance = big.NewInt(0)
 	}
 
-	testCase.InitialSellerUSDCBalance, err = e.getUSDCBalance(testCase.SellerAddress)
+	testCase.InitialSellerUSDCBalance, err = e.getTokenBalance(testCase.TokenContract, testCase.SellerAddress)
 	if err != nil {
 		e.logger.Errorf("Failed to get initial seller USDC balance: %v", err)
 		testCase.InitialSellerUSDCBalance = big.NewInt(0)
@@ -486,10 +1095,18 @@ func (e *EthOracleE2E) recordInitialBalances(testCase *TestCase) {
 		testCase.InitialCNPYBalance)
 }
 
+// envPassword returns the --password-file password, falling back to E2E_FROM_PASS
+func (e *EthOracleE2E) envPassword() string {
+	if e.password != "" {
+		return e.password
+	}
+	return os.Getenv("E2E_FROM_PASS")
+}
+
 // getAuth gets credentials from the env
-func getAuth() (rpc.AddrOrNickname, string) {
+func (e *EthOracleE2E) getAuth() (rpc.AddrOrNickname, string) {
 	nick := os.Getenv("E2E_FROM_NICK")
-	pass := os.Getenv("E2E_FROM_PASS")
+	pass := e.envPassword()
 	if nick == "" || pass == "" {
 		panic(fmt.Sprintf("%s %s\n", nick, pass))
 	}
@@ -498,32 +1115,55 @@ func getAuth() (rpc.AddrOrNickname, string) {
 
 }
 
-// CreateSellOrder creates a sell order with specified parameters
-func (e *EthOracleE2E) CreateSellOrder(sellAmount, receiveAmount uint64, sellerAddress, canopyAddress string) error {
-	// load the keystore from file
-	_, err := crypto.NewKeystoreFromFile(e.dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load keystore: %w", err)
+// sellerAuth returns the canopy credentials an order is created with: the keystore entry nick, which
+// must exist in the keystore, or the E2E_FROM_NICK account when nick is empty. An empty pass falls
+// back to the --password-file password or E2E_FROM_PASS
+func (e *EthOracleE2E) sellerAuth(nick, pass string) (rpc.AddrOrNickname, string, error) {
+	if nick == "" {
+		from, pass := e.getAuth()
+		return from, pass, nil
 	}
 
-	from, pass := getAuth()
-
-	receiveAddress := strings.TrimPrefix(sellerAddress, "0x")
-	submit := true
-	optFee := uint64(100000)
-	contract := strings.TrimPrefix(os.Getenv("USDC_CONTRACT"), "0x")
-	data, err := lib.NewHexBytesFromString(contract)
+	keystore, err := crypto.NewKeystoreFromFile(e.dataDir)
 	if err != nil {
-		return fmt.Errorf("failed to create contract data: %w", err)
+		return rpc.AddrOrNickname{}, "", fmt.Errorf("failed to load keystore: %w", err)
 	}
+	if _, ok := keystore.NicknameMap[nick]; !ok {
+		return rpc.AddrOrNickname{}, "", fmt.Errorf("seller nickname %q is not in the keystore in %s", nick, e.dataDir)
+	}
+	if pass == "" {
+		pass = e.envPassword()
+	}
+	return rpc.AddrOrNickname{Nickname: nick}, pass, nil
+}
 
-	_, _, err = e.client.TxCreateOrder(from, sellAmount, receiveAmount, chainId, receiveAddress, pass, data, submit, optFee)
+// orderAuth returns the canopy credentials an order is created with: the account at sendAddress,
+// unlocked with pass, the --password-file password or E2E_FROM_PASS, or sellerAuth's when
+// sendAddress is empty. A nick and a send address both pick the account, so they can't be combined
+func (e *EthOracleE2E) orderAuth(nick, pass, sendAddress string) (rpc.AddrOrNickname, string, error) {
+	if sendAddress == "" {
+		return e.sellerAuth(nick, pass)
+	}
+	if nick != "" {
+		return rpc.AddrOrNickname{}, "", fmt.Errorf("seller nickname %q and canopy send address %s both set the order's account", nick, sendAddress)
+	}
+	address, err := parseCanopyAddress(sendAddress)
 	if err != nil {
-		return fmt.Errorf("failed to create order: %w", err)
+		return rpc.AddrOrNickname{}, "", fmt.Errorf("canopy send address: %w", err)
+	}
+	if pass == "" {
+		pass = e.envPassword()
 	}
+	return rpc.AddrOrNickname{Address: string(address)}, pass, nil
+}
 
-	e.logger.Infof("Sell order transaction sent successfully: %d CNPY -> %d USDC (seller: %s)",
-		sellAmount, receiveAmount, sellerAddress)
+// CreateSellOrder creates a sell order on a committee with specified parameters. The CNPY is
+// debited from canopySendAddress, or, when it's empty, from the keystore entry sellerNick or the
+// E2E_FROM_NICK account. canopyReceiveAddress is where the buyer later receives the CNPY
+func (e *EthOracleE2E) CreateSellOrder(committee, sellAmount, receiveAmount uint64, sellerAddress, canopySendAddress, canopyReceiveAddress, tokenContract, sellerNick, sellerPass string) error {
+	if _, err := e.createSellOrder(committee, sellAmount, receiveAmount, sellerAddress, canopySendAddress, canopyReceiveAddress, tokenContract, sellerNick, sellerPass); err != nil {
+		return err
+	}
 
 	// Print balances after creating order
 	e.printAccountBalances("Balances After Creating Order")
@@ -531,9 +1171,37 @@ func (e *EthOracleE2E) CreateSellOrder(sellAmount, receiveAmount uint64, sellerA
 	return nil
 }
 
+// createSellOrder is CreateSellOrder without the balance printout, returning the new order's id
+func (e *EthOracleE2E) createSellOrder(committee, sellAmount, receiveAmount uint64, sellerAddress, canopySendAddress, canopyReceiveAddress, tokenContract, sellerNick, sellerPass string) (string, error) {
+	// reject canopy addresses that would make a doomed transaction; CreateOrder checks the seller
+	// receive address
+	if _, err := parseCanopyAddress(canopyReceiveAddress); err != nil {
+		return "", fmt.Errorf("canopy receive address: %w", err)
+	}
+
+	from, pass, err := e.orderAuth(sellerNick, sellerPass, canopySendAddress)
+	if err != nil {
+		return "", err
+	}
+
+	orderID, txHash, err := orderflow.CreateOrder(e.client, from, pass, committee, sellAmount, receiveAmount, sellerAddress, tokenContract)
+	if err != nil {
+		return "", err
+	}
+
+	creator := from.Nickname
+	if creator == "" {
+		creator = from.Address
+	}
+	e.logger.Infof("Sell order %s created on committee %d in tx %s by %s: %d CNPY -> %d USDC (seller: %s)",
+		orderID, committee, txHash, creator, sellAmount, receiveAmount, sellerAddress)
+	return orderID, nil
+}
+
 // createTestOrder creates an order for the test case
 func (e *EthOracleE2E) createTestOrder(testCase *TestCase) error {
-	return e.CreateSellOrder(testCase.OrderAmount, testCase.ExpectedUSDCTransfer, testCase.SellerAddress, testCase.CanopyReceiveAddress)
+	return e.CreateSellOrder(testCase.Committee, testCase.OrderAmount, testCase.ExpectedUSDCTransfer, testCase.SellerAddress, testCase.CanopySendAddress,
+		testCase.CanopyReceiveAddress, testCase.TokenContract, testCase.SellerNick, testCase.SellerPass)
 }
 
 // LockOrder locks an order by its ID with specified buyer parameters
@@ -544,13 +1212,39 @@ func (e *EthOracleE2E) LockOrder(orderID, buyerAddress, buyerPrivateKey, canopyA
 		return fmt.Errorf("failed to find order %s: %w", orderID, err)
 	}
 
-	if targetOrder.BuyerSendAddress != nil {
+	if isLocked(targetOrder) {
 		return fmt.Errorf("order %s is already locked", orderID)
 	}
 
 	return e.lockOrderInternal(targetOrder, buyerAddress, buyerPrivateKey, canopyAddress)
 }
 
+// CancelOrder deletes a single order by its ID, signed by the seller keystore entry. A locked
+// order is refused, since deleting it would strand the buyer mid-trade
+func (e *EthOracleE2E) CancelOrder(orderID string) error {
+	order, err := e.findOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+	if isLocked(order) {
+		return fmt.Errorf("%w: %s is locked by buyer %s and can't be cancelled mid-trade", ErrOrderLocked,
+			orderID, common.BytesToAddress(order.BuyerSendAddress).Hex())
+	}
+
+	from, pass, err := e.sellerAuth(e.sellerNick, e.sellerPass)
+	if err != nil {
+		return err
+	}
+	hash, _, txErr := e.client.TxDeleteOrder(from, orderID, order.Committee, pass, true, deleteOrderFee)
+	if txErr != nil {
+		return fmt.Errorf("failed to delete order %s: %w", orderID, txErr)
+	}
+	if hash != nil {
+		e.logger.Infof("Delete order %s sent in tx %s", orderID, *hash)
+	}
+	return nil
+}
+
 // LockFirstOrder locks the first available unlocked order
 func (e *EthOracleE2E) LockFirstOrder(buyerAddress, buyerPrivateKey, canopyAddress string) error {
 	// Find the first unlocked order
@@ -571,6 +1265,7 @@ func (e *EthOracleE2E) LockAllUnlockedOrders(buyerAddress, buyerPrivateKey, cano
 	}
 
 	fmt.Printf("Found %d unlocked orders to lock\n", len(unlockedOrders))
+	unlockedOrders, remaining := e.capOrders(unlockedOrders)
 
 	// Lock each unlocked order
 	var errors []string
@@ -591,11 +1286,14 @@ func (e *EthOracleE2E) LockAllUnlockedOrders(buyerAddress, buyerPrivateKey, cano
 		}
 
 		// Add a small delay between lock operations to avoid overwhelming the network
-		time.Sleep(1 * time.Second)
+		time.Sleep(e.lockInterval)
 	}
 
 	// Report results
 	fmt.Printf("Locked %d out of %d unlocked orders\n", successCount, len(unlockedOrders))
+	if remaining > 0 {
+		fmt.Printf("%d unlocked orders remain (--max-orders %d)\n", remaining, e.maxOrders)
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("encountered %d errors while locking orders:\n%s", len(errors), strings.Join(errors, "\n"))
@@ -604,42 +1302,57 @@ func (e *EthOracleE2E) LockAllUnlockedOrders(buyerAddress, buyerPrivateKey, cano
 	return nil
 }
 
-// lockOrderInternal handles the actual locking logic
+// capOrders limits a batch operation to the first maxOrders orders, returning how many are left
+func (e *EthOracleE2E) capOrders(orders []*lib.SellOrder) ([]*lib.SellOrder, int) {
+	if e.maxOrders <= 0 || len(orders) <= e.maxOrders {
+		return orders, 0
+	}
+	return orders[:e.maxOrders], len(orders) - e.maxOrders
+}
+
+// lockOrderInternal handles the actual locking logic. The lock is sent for the order's own
+// committee, which must be one of the configured committees
 func (e *EthOracleE2E) lockOrderInternal(targetOrder *lib.SellOrder, buyerAddress, buyerPrivateKey, canopyAddress string) error {
+	if err := e.checkOrderCommittee(targetOrder); err != nil {
+		return err
+	}
+	receiveAddress, err := parseCanopyAddress(canopyAddress)
+	if err != nil {
+		return err
+	}
+
 	// Lock the order
 	heightPtr, err := e.client.Height()
 	if err != nil {
-		return fmt.Errorf("failed to get height: %w", err)
+		return fmt.Errorf("failed to get height: %w: %w", ErrRPCUnavailable, err)
 	}
 	height := *heightPtr + 5
 
-	lockOrder := &lib.LockOrder{
-		OrderId:             targetOrder.Id,
-		BuyerSendAddress:    common.FromHex(buyerAddress),
-		BuyerReceiveAddress: common.Hex2Bytes(canopyAddress),
-		BuyerChainDeadline:  height,
-		ChainId:             chainId,
-	}
-
-	data, er := json.Marshal(lockOrder)
+	txHash, er := e.txOptions.LockOrder(e.ethClient, targetOrder, buyerAddress, buyerPrivateKey, string(receiveAddress), height)
 	if er != nil {
-		return fmt.Errorf("failed to marshal lock order: %w", er)
-	}
-
-	sendAddress := common.HexToAddress(strings.TrimPrefix(buyerAddress, "0x"))
-	err2 := SendTransaction(e.ethClient, sendAddress, buyerPrivateKey, new(big.Int).SetUint64(0), data)
-	if err2 != nil {
-		return fmt.Errorf("failed to send lock transaction: %w", err2)
+		return er
 	}
 
 	orderID := lib.BytesToString(targetOrder.Id)
-	e.logger.Infof("Lock order transaction sent for order %s by buyer %s", orderID, buyerAddress)
+	e.logger.Infof("Lock order transaction %s sent for order %s by buyer %s", txHash, orderID, buyerAddress)
 
 	// Print balances after locking order
 	e.printAccountBalances("Balances After Locking Order")
 	return nil
 }
 
+// checkOrderCommittee returns an error if order lives on a committee other than the configured
+// ones, since a lock or close for it would target a chain the test isn't watching
+func (e *EthOracleE2E) checkOrderCommittee(order *lib.SellOrder) error {
+	for _, committee := range e.committees {
+		if order.Committee == committee {
+			return nil
+		}
+	}
+	return fmt.Errorf("order %s is on committee %d, not on the configured committees %v",
+		lib.BytesToString(order.Id), order.Committee, e.committees)
+}
+
 // findOrderByID finds an order by its ID in the order books
 func (e *EthOracleE2E) findOrderByID(orderID string) (*lib.SellOrder, error) {
 	orders, err := e.Orders()
@@ -655,10 +1368,11 @@ func (e *EthOracleE2E) findOrderByID(orderID string) (*lib.SellOrder, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("order %s not found", orderID)
+	return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
 }
 
-// findFirstUnlockedOrder finds the first unlocked order in the order books
+// findFirstUnlockedOrder finds the first unlocked order in the order books whose ID
+// matches the order ID prefix
 func (e *EthOracleE2E) findFirstUnlockedOrder() (*lib.SellOrder, error) {
 	orders, err := e.Orders()
 	if err != nil {
@@ -667,16 +1381,17 @@ func (e *EthOracleE2E) findFirstUnlockedOrder() (*lib.SellOrder, error) {
 
 	for _, book := range orders.OrderBooks {
 		for _, order := range book.Orders {
-			if order.BuyerSendAddress == nil { // unlocked
+			if !isLocked(order) && e.matchesOrderIDPrefix(order) {
 				return order, nil
 			}
 		}
 	}
 
-	return nil, fmt.Errorf("no unlocked orders found")
+	return nil, fmt.Errorf("%w: no unlocked orders", ErrOrderNotFound)
 }
 
-// findFirstLockedOrder finds the first locked order in the order books
+// findFirstLockedOrder finds the first locked order in the order books whose ID
+// matches the order ID prefix
 func (e *EthOracleE2E) findFirstLockedOrder() (*lib.SellOrder, error) {
 	orders, err := e.Orders()
 	if err != nil {
@@ -685,16 +1400,17 @@ func (e *EthOracleE2E) findFirstLockedOrder() (*lib.SellOrder, error) {
 
 	for _, book := range orders.OrderBooks {
 		for _, order := range book.Orders {
-			if order.BuyerSendAddress != nil { // locked
+			if isLocked(order) && e.matchesOrderIDPrefix(order) {
 				return order, nil
 			}
 		}
 	}
 
-	return nil, fmt.Errorf("no locked orders found")
+	return nil, fmt.Errorf("%w: no locked orders", ErrOrderNotFound)
 }
 
-// findAllLockedOrders finds all locked orders in the order books
+// findAllLockedOrders finds all locked orders in the order books whose ID
+// matches the order ID prefix
 func (e *EthOracleE2E) findAllLockedOrders() ([]*lib.SellOrder, error) {
 	orders, err := e.Orders()
 	if err != nil {
@@ -704,20 +1420,21 @@ func (e *EthOracleE2E) findAllLockedOrders() ([]*lib.SellOrder, error) {
 	var lockedOrders []*lib.SellOrder
 	for _, book := range orders.OrderBooks {
 		for _, order := range book.Orders {
-			if order.BuyerSendAddress != nil { // locked
+			if isLocked(order) && e.matchesOrderIDPrefix(order) {
 				lockedOrders = append(lockedOrders, order)
 			}
 		}
 	}
 
 	if len(lockedOrders) == 0 {
-		return nil, fmt.Errorf("no locked orders found")
+		return nil, fmt.Errorf("%w: no locked orders", ErrOrderNotFound)
 	}
 
 	return lockedOrders, nil
 }
 
-// findAllUnlockedOrders finds all unlocked orders in the order books
+// findAllUnlockedOrders finds all unlocked orders in the order books whose ID
+// matches the order ID prefix
 func (e *EthOracleE2E) findAllUnlockedOrders() ([]*lib.SellOrder, error) {
 	orders, err := e.Orders()
 	if err != nil {
@@ -727,14 +1444,14 @@ func (e *EthOracleE2E) findAllUnlockedOrders() ([]*lib.SellOrder, error) {
 	var unlockedOrders []*lib.SellOrder
 	for _, book := range orders.OrderBooks {
 		for _, order := range book.Orders {
-			if order.BuyerSendAddress == nil { // unlocked
+			if !isLocked(order) && e.matchesOrderIDPrefix(order) {
 				unlockedOrders = append(unlockedOrders, order)
 			}
 		}
 	}
 
 	if len(unlockedOrders) == 0 {
-		return nil, fmt.Errorf("no unlocked orders found")
+		return nil, fmt.Errorf("%w: no unlocked orders", ErrOrderNotFound)
 	}
 
 	return unlockedOrders, nil
@@ -751,7 +1468,9 @@ func (e *EthOracleE2E) waitAndLockOrder(testCase *TestCase) error {
 	for !orderFound {
 		select {
 		case <-timeout:
-			return fmt.Errorf("timeout waiting for order to appear")
+			return fmt.Errorf("%w waiting for order to appear", ErrTimeout)
+		case <-e.suiteDone():
+			return e.suiteErr()
 		case <-ticker.C:
 			orders, err := e.Orders()
 			if err != nil {
@@ -762,10 +1481,8 @@ func (e *EthOracleE2E) waitAndLockOrder(testCase *TestCase) error {
 			for _, book := range orders.OrderBooks {
 				// Find our order (look for unlocked orders with matching amounts)
 				for _, order := range book.Orders {
-					if order.BuyerSendAddress == nil && // unlocked
-						order.AmountForSale == testCase.OrderAmount &&
-						order.RequestedAmount == testCase.ExpectedUSDCTransfer {
-						testCase.Status = "created"
+					if GetOrderStatus(order, 0) == StatusCreated && e.matchTestOrder(testCase, order) {
+						testCase.Status = StatusCreated
 						testCase.OrderID = lib.BytesToString(order.Id)
 						orderFound = true
 						break
@@ -786,11 +1503,12 @@ func (e *EthOracleE2E) CloseOrder(orderID, buyerPrivateKey string, transferAmoun
 		return fmt.Errorf("failed to find order %s: %w", orderID, err)
 	}
 
-	if lockedOrder.BuyerSendAddress == nil {
+	if !isLocked(lockedOrder) {
 		return fmt.Errorf("order %s is not locked", orderID)
 	}
 
-	return e.closeOrderInternal(lockedOrder, buyerPrivateKey, transferAmount)
+	_, err = e.closeOrderInternal(lockedOrder, e.tokenContract, buyerPrivateKey, transferAmount, nil)
+	return err
 }
 
 // CloseFirstOrder closes the first available locked order
@@ -801,7 +1519,8 @@ func (e *EthOracleE2E) CloseFirstOrder(buyerPrivateKey string, transferAmount ui
 		return fmt.Errorf("failed to find locked order: %w", err)
 	}
 
-	return e.closeOrderInternal(lockedOrder, buyerPrivateKey, transferAmount)
+	_, err = e.closeOrderInternal(lockedOrder, e.tokenContract, buyerPrivateKey, transferAmount, nil)
+	return err
 }
 
 // CloseAllLockedOrders closes all locked orders in the order books
@@ -813,6 +1532,7 @@ func (e *EthOracleE2E) CloseAllLockedOrders(buyerPrivateKey string, transferAmou
 	}
 
 	fmt.Printf("Found %d locked orders to close\n", len(lockedOrders))
+	lockedOrders, remaining := e.capOrders(lockedOrders)
 
 	// Close each locked order
 	var errors []string
@@ -822,7 +1542,7 @@ func (e *EthOracleE2E) CloseAllLockedOrders(buyerPrivateKey string, transferAmou
 		orderID := lib.BytesToString(order.Id)
 		fmt.Printf("Closing order %d/%d: %s\n", i+1, len(lockedOrders), orderID)
 
-		err := e.closeOrderInternal(order, buyerPrivateKey, transferAmount)
+		_, err := e.closeOrderInternal(order, e.tokenContract, buyerPrivateKey, transferAmount, nil)
 		if err != nil {
 			errorMsg := fmt.Sprintf("failed to close order %s: %v", orderID, err)
 			errors = append(errors, errorMsg)
@@ -835,6 +1555,9 @@ func (e *EthOracleE2E) CloseAllLockedOrders(buyerPrivateKey string, transferAmou
 
 	// Report results
 	fmt.Printf("Closed %d out of %d locked orders\n", successCount, len(lockedOrders))
+	if remaining > 0 {
+		fmt.Printf("%d locked orders remain (--max-orders %d)\n", remaining, e.maxOrders)
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("encountered %d errors while closing orders:\n%s", len(errors), strings.Join(errors, "\n"))
@@ -843,51 +1566,43 @@ func (e *EthOracleE2E) CloseAllLockedOrders(buyerPrivateKey string, transferAmou
 	return nil
 }
 
-// closeOrderInternal handles the actual closing logic
-func (e *EthOracleE2E) closeOrderInternal(lockedOrder *lib.SellOrder, buyerPrivateKey string, transferAmount uint64) error {
-	// Send USDC to the locked order's seller send address
-	usdcContract := common.HexToAddress(strings.TrimPrefix(os.Getenv("USDC_CONTRACT"), "0x"))
-	sellerReceiveAddress := common.BytesToAddress(lockedOrder.SellerReceiveAddress)
-
-	// Create USDC transfer transaction
-	transferData := erc20TransferMethodID +
-		hex.EncodeToString(common.LeftPadBytes(sellerReceiveAddress.Bytes(), 32)) +
-		hex.EncodeToString(common.LeftPadBytes(new(big.Int).SetUint64(transferAmount).Bytes(), 32))
-
-	transferDataBytes, err := hex.DecodeString(transferData)
-	if err != nil {
-		return fmt.Errorf("failed to decode transfer data: %w", err)
+// closeOrderInternal handles the actual closing logic. The close is sent for the order's own
+// committee, which must be one of the configured committees. The USDC goes to the order's seller
+// receive address unless recipient overrides it, which only negative tests do
+func (e *EthOracleE2E) closeOrderInternal(lockedOrder *lib.SellOrder, tokenContract, buyerPrivateKey string, transferAmount uint64, recipient *common.Address) (common.Hash, error) {
+	if err := e.checkOrderCommittee(lockedOrder); err != nil {
+		return common.Hash{}, err
 	}
-
-	// Create CloseOrder struct and marshal it
-	closeOrder := &lib.CloseOrder{
-		OrderId:    lockedOrder.Id,
-		ChainId:    lockedOrder.Committee,
-		CloseOrder: true,
+	contract := common.HexToAddress(strings.TrimPrefix(tokenContract, "0x"))
+	to := common.BytesToAddress(lockedOrder.SellerReceiveAddress)
+	if recipient != nil {
+		to = *recipient
 	}
-
-	closeOrderBytes, err := json.Marshal(closeOrder)
+	txHash, err := e.txOptions.CloseOrderTo(e.ethClient, lockedOrder, to, contract, buyerPrivateKey, transferAmount)
 	if err != nil {
-		return fmt.Errorf("failed to marshal close order: %w", err)
-	}
-
-	// Append the close order bytes to the transfer data
-	finalTransferData := append(transferDataBytes, closeOrderBytes...)
-
-	err = SendTransaction(e.ethClient, usdcContract, buyerPrivateKey, new(big.Int).SetUint64(0), finalTransferData)
-	if err != nil {
-		return fmt.Errorf("failed to send USDC transfer: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to close order: %w", err)
 	}
 
 	orderID := lib.BytesToString(lockedOrder.Id)
-	e.logger.Infof("Close order sent for order %s with %d USDC transfer", orderID, transferAmount)
-	return nil
+	e.logger.Infof("Close order transaction %s sent for order %s with %d USDC transfer", txHash, orderID, transferAmount)
+	return txHash, nil
 }
 
 func (e *EthOracleE2E) sendClose(lockedOrder *lib.SellOrder, testCase *TestCase) error {
 	e.logger.Infof("Test %s - %x locked order found", testCase.Name, lockedOrder.Id)
 
-	return e.CloseOrder(lib.BytesToString(lockedOrder.Id), testCase.BuyerPrivateKey, testCase.ExpectedUSDCTransfer)
+	var recipient *common.Address
+	if testCase.CloseRecipient != "" {
+		address := common.HexToAddress(testCase.CloseRecipient)
+		recipient = &address
+	}
+
+	txHash, err := e.closeOrderInternal(lockedOrder, testCase.TokenContract, testCase.BuyerPrivateKey, testCase.ExpectedUSDCTransfer, recipient)
+	if err != nil {
+		return err
+	}
+	testCase.CloseTxHash = txHash
+	return nil
 }
 
 func (e *EthOracleE2E) closeTestOrder(testCase *TestCase) error {
@@ -902,28 +1617,42 @@ func (e *EthOracleE2E) closeTestOrder(testCase *TestCase) error {
 	for !done {
 		select {
 		case <-timeout:
-			return fmt.Errorf("timeout waiting for order %s to be locked", testCase.OrderID)
+			return fmt.Errorf("%w waiting for order %s to be locked", ErrTimeout, testCase.OrderID)
+		case <-e.suiteDone():
+			return e.suiteErr()
 		case <-ticker.C:
 			orders, err := e.Orders()
 			if err != nil {
 				continue
 			}
+			height := e.canopyHeight()
 
 			// Find our locked order
-			for _, order := range orders.OrderBooks[0].Orders {
-				if order.BuyerSendAddress != nil && // locked
-					order.AmountForSale == testCase.OrderAmount &&
-					order.RequestedAmount == testCase.ExpectedUSDCTransfer {
-					testCase.Status = "locked"
-					var send = true
-					for _, id := range closed {
-						if testCase.OrderID == id {
-							send = false
-						}
+			for _, book := range orders.OrderBooks {
+				for _, order := range book.Orders {
+					if lib.BytesToString(order.Id) != testCase.OrderID {
+						continue
+					}
+					// a lock that expired can't be closed anymore
+					if GetOrderStatus(order, height) == StatusExpired {
+						testCase.Status = StatusExpired
+						return fmt.Errorf("lock of order %s expired at height %d before it was closed", testCase.OrderID, order.BuyerChainDeadline)
 					}
-					if send {
-						e.sendClose(order, testCase)
-						closed = append(closed, testCase.OrderID)
+					if GetOrderStatus(order, height) == StatusLocked && e.matchTestOrder(testCase, order) {
+						testCase.Status = StatusLocked
+						var send = true
+						for _, id := range closed {
+							if testCase.OrderID == id {
+								send = false
+							}
+						}
+						if send {
+							if err := e.sendClose(order, testCase); err != nil {
+								return err
+							}
+							closed = append(closed, testCase.OrderID)
+							done = true
+						}
 					}
 				}
 			}
@@ -932,61 +1661,106 @@ func (e *EthOracleE2E) closeTestOrder(testCase *TestCase) error {
 	return nil
 }
 
-// waitForOrderCompletion waits for the order to be removed from the order book, indicating successful completion
+// waitForOrderCompletion waits for the order to be removed from the order book and, unless
+// completionAbsenceOnly is set, for its CNPY to reach the receive address, so an order that was
+// deleted or expired instead of settled doesn't pass. When the test case expects the order to
+// remain, it instead watches the order for the whole close window and fails if it is removed
 func (e *EthOracleE2E) waitForOrderCompletion(testCase *TestCase) error {
 	e.logger.Infof("Test %s - %s waiting for completion", testCase.Name, testCase.OrderID)
 
-	timeout := time.After(120 * time.Second)  // Longer timeout for order completion
+	timeout := time.After(e.closeTimeout)
 	ticker := time.NewTicker(2 * time.Second) // Check every 2 seconds
 	defer ticker.Stop()
 
+	var lastErr error
 	for {
 		select {
 		case <-timeout:
-			return fmt.Errorf("timeout waiting for order %s to be completed and removed", testCase.OrderID)
+			if testCase.ExpectOrderRemains {
+				e.logger.Infof("Test %s - %s order remained in the order book as expected", testCase.Name, testCase.OrderID)
+				testCase.Status = StatusClosed
+				return nil
+			}
+			if errors.Is(lastErr, ErrBalanceMismatch) {
+				return fmt.Errorf("%w waiting for order %s to complete: %w", ErrTimeout, testCase.OrderID, lastErr)
+			}
+			return fmt.Errorf("%w waiting for order %s to be completed and removed", ErrTimeout, testCase.OrderID)
+		case <-e.suiteDone():
+			return e.suiteErr()
 		case <-ticker.C:
-			orders, err := e.Orders()
-			if err != nil {
-				e.logger.Warnf("Failed to query orders during completion wait: %v", err)
+			if testCase.ExpectOrderRemains {
+				inBook, err := e.orderInBook(testCase.OrderID)
+				if err != nil {
+					e.logger.Warnf("Failed to check order completion: %v", err)
+				} else if !inBook {
+					return fmt.Errorf("order %s left the order book but was expected to remain", testCase.OrderID)
+				}
 				continue
 			}
-
-			// Check if our order is still in the order book
-			orderFound := false
-		orderLoop:
-			for _, orderBook := range orders.OrderBooks {
-				for _, order := range orderBook.Orders {
-					if lib.BytesToString(order.Id) == testCase.OrderID {
-						orderFound = true
-						break orderLoop
-					}
+			completed, err := e.orderCompleted(testCase)
+			if err != nil {
+				if !errors.Is(err, ErrBalanceMismatch) {
+					e.logger.Warnf("Failed to check order completion: %v", err)
 				}
+				lastErr = err
+				continue
 			}
-
-			// If order is not found in order book, it means it was completed successfully
-			if !orderFound {
+			if completed {
 				e.logger.Infof("Test %s - %s order successfully completed and removed from order book", testCase.Name, testCase.OrderID)
-				testCase.Status = "closed"
+				testCase.Status = StatusClosed
 				return nil
 			}
-
-			// e.logger.Debugf("Test %s - Order %s still in order book, waiting for completion...", testCase.Name, testCase.OrderID)
 		}
 	}
 }
 
+// orderCompleted checks once whether a test case's order has completed. An order still in the book
+// hasn't; one that left it has only when the expected CNPY reached the receive address, otherwise
+// an ErrBalanceMismatch is returned. With completionAbsenceOnly, leaving the book is enough
+func (e *EthOracleE2E) orderCompleted(testCase *TestCase) (bool, error) {
+	inBook, err := e.orderInBook(testCase.OrderID)
+	if err != nil || inBook {
+		return false, err
+	}
+	if e.completionAbsenceOnly {
+		return true, nil
+	}
+
+	balance, err := e.getCNPYBalance(testCase.CanopyReceiveAddress)
+	if err != nil {
+		return false, err
+	}
+	_, _, expected := expectedBalanceDeltas(testCase)
+	var released uint64
+	if balance > testCase.InitialCNPYBalance {
+		released = balance - testCase.InitialCNPYBalance
+	}
+	if released < expected {
+		return false, fmt.Errorf("order %s left the order book but %w: %d of %d CNPY released to %s",
+			testCase.OrderID, ErrBalanceMismatch, released, expected, testCase.CanopyReceiveAddress)
+	}
+	return true, nil
+}
+
 // verifyFinalBalances verifies that the balances changed as expected
 func (e *EthOracleE2E) verifyFinalBalances(testCase *TestCase) error {
-	// Wait a bit for balances to update
-	time.Sleep(5 * time.Second)
+	// Wait for the close to settle on both chains before reading balances
+	if err := e.waitForCloseSettlement(testCase); err != nil {
+		return err
+	}
+	return e.checkBalanceChanges(testCase)
+}
 
+// checkBalanceChanges compares the buyer, seller and CNPY balances with the ones recorded before
+// the test case and marks it verified when they changed by the expected deltas
+func (e *EthOracleE2E) checkBalanceChanges(testCase *TestCase) error {
 	// Get final balances
-	finalBuyerUSDC, err := e.getUSDCBalance(testCase.BuyerAddress)
+	finalBuyerUSDC, err := e.getTokenBalance(testCase.TokenContract, testCase.BuyerAddress)
 	if err != nil {
 		return fmt.Errorf("failed to get final buyer USDC balance: %w", err)
 	}
 
-	finalSellerUSDC, err := e.getUSDCBalance(testCase.SellerAddress)
+	finalSellerUSDC, err := e.getTokenBalance(testCase.TokenContract, testCase.SellerAddress)
 	if err != nil {
 		return fmt.Errorf("failed to get final seller USDC balance: %w", err)
 	}
@@ -1009,46 +1783,177 @@ func (e *EthOracleE2E) verifyFinalBalances(testCase *TestCase) error {
 		cnpyChange)
 
 	// Verify expected changes
-	expectedSellerChange := new(big.Int).SetUint64(testCase.ExpectedUSDCTransfer)
-	expectedBuyerChange := new(big.Int).Neg(expectedSellerChange)
-	expectedCNPYChange := testCase.ExpectedCNPYTransfer
+	expectedBuyerChange, expectedSellerChange, expectedCNPYChange := expectedBalanceDeltas(testCase)
 
 	if buyerUSDCChange.Cmp(expectedBuyerChange) != 0 {
-		return fmt.Errorf("buyer USDC change mismatch: expected %s, got %s",
+		return fmt.Errorf("buyer USDC change %w: expected %s, got %s", ErrBalanceMismatch,
 			e.formatUSDCBalance(expectedBuyerChange),
 			e.formatUSDCBalance(buyerUSDCChange))
 	}
 
 	if sellerUSDCChange.Cmp(expectedSellerChange) != 0 {
-		return fmt.Errorf("seller USDC change mismatch: expected %s, got %s",
+		return fmt.Errorf("seller USDC change %w: expected %s, got %s", ErrBalanceMismatch,
 			e.formatUSDCBalance(expectedSellerChange),
 			e.formatUSDCBalance(sellerUSDCChange))
 	}
 
 	if cnpyChange != expectedCNPYChange {
-		return fmt.Errorf("CNPY change mismatch: expected %d, got %d",
+		return fmt.Errorf("CNPY change %w: expected %d, got %d", ErrBalanceMismatch,
 			expectedCNPYChange, cnpyChange)
 	}
 
-	testCase.Status = "verified"
+	testCase.Status = StatusVerified
 	return nil
 }
 
-// isCanopyAddress checks if an address is a canopy address (shorter format without 0x prefix)
-func (e *EthOracleE2E) isCanopyAddress(address string) bool {
-	// Canopy addresses are shorter and don't have 0x prefix
-	// Ethereum addresses are 42 chars with 0x prefix, or 40 chars without
-	if len(address) == 40 && !strings.HasPrefix(address, "0x") {
-		return true
+// waitForCloseSettlement waits until the test case's close tx has the minimum number of eth
+// confirmations and its order has left the canopy order book
+func (e *EthOracleE2E) waitForCloseSettlement(testCase *TestCase) error {
+	if testCase.CloseTxHash == (common.Hash{}) {
+		return fmt.Errorf("no close transaction was sent for order %s", testCase.OrderID)
+	}
+
+	timeout := time.After(settleTimeout)
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		confirmations, err := e.confirmations(testCase.CloseTxHash)
+		if err != nil {
+			return err
+		}
+		if confirmations >= e.minConfirmations {
+			inBook, err := e.orderInBook(testCase.OrderID)
+			if err == nil && !inBook {
+				e.logger.Infof("Test %s - close tx %s settled with %d confirmations", testCase.Name, testCase.CloseTxHash, confirmations)
+				return nil
+			}
+		}
+
+		select {
+		case <-timeout:
+			return fmt.Errorf("%w waiting for close tx %s to reach %d confirmations and order %s to leave the order book", ErrTimeout,
+				testCase.CloseTxHash, e.minConfirmations, testCase.OrderID)
+		case <-e.suiteDone():
+			return e.suiteErr()
+		case <-ticker.C:
+		}
+	}
+}
+
+// verifyOrderNotReleased checks that an order closed with a transfer to the wrong recipient stays
+// in the order book and releases no CNPY once the close tx is confirmed
+func (e *EthOracleE2E) verifyOrderNotReleased(testCase *TestCase) error {
+	if testCase.CloseTxHash == (common.Hash{}) {
+		return fmt.Errorf("no close transaction was sent for order %s", testCase.OrderID)
+	}
+
+	timeout := time.After(settleTimeout)
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	var window <-chan time.Time
+	for {
+		if window == nil {
+			confirmations, err := e.confirmations(testCase.CloseTxHash)
+			if err != nil {
+				return err
+			}
+			if confirmations >= e.minConfirmations {
+				e.logger.Infof("Test %s - wrong close tx %s confirmed, watching order %s for %s",
+					testCase.Name, testCase.CloseTxHash, testCase.OrderID, unreleasedWindow)
+				window = time.After(unreleasedWindow)
+			}
+		} else {
+			inBook, err := e.orderInBook(testCase.OrderID)
+			if err == nil && !inBook {
+				return fmt.Errorf("order %s left the order book", testCase.OrderID)
+			}
+			balance, err := e.getCNPYBalance(testCase.CanopyReceiveAddress)
+			if err == nil && balance > testCase.InitialCNPYBalance {
+				return fmt.Errorf("%d CNPY released to %s", balance-testCase.InitialCNPYBalance, testCase.CanopyReceiveAddress)
+			}
+		}
+
+		select {
+		case <-window:
+			testCase.Status = StatusVerified
+			return nil
+		case <-timeout:
+			if window == nil {
+				return fmt.Errorf("%w waiting for close tx %s to reach %d confirmations", ErrTimeout, testCase.CloseTxHash, e.minConfirmations)
+			}
+		case <-e.suiteDone():
+			return e.suiteErr()
+		case <-ticker.C:
+		}
 	}
-	return false
+}
+
+// confirmations returns the number of confirmations of an eth transaction, 0 while it's pending
+func (e *EthOracleE2E) confirmations(txHash common.Hash) (uint64, error) {
+	receipt, err := e.ethClient.TransactionReceipt(context.Background(), txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get receipt of %s: %w", txHash, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return 0, fmt.Errorf("close tx %s failed", txHash)
+	}
+
+	head, err := e.ethClient.BlockNumber(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+	if head < receipt.BlockNumber.Uint64() {
+		return 0, nil
+	}
+	return head - receipt.BlockNumber.Uint64() + 1, nil
+}
+
+// orderInBook reports whether an order is still in any queried order book
+func (e *EthOracleE2E) orderInBook(orderID string) (bool, error) {
+	orders, err := e.Orders()
+	if err != nil {
+		return false, err
+	}
+	for _, book := range orders.OrderBooks {
+		for _, order := range book.Orders {
+			if lib.BytesToString(order.Id) == orderID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// CanopyAddress is a validated canopy address in its canonical form: 20 bytes of lowercase hex
+// without a 0x prefix
+type CanopyAddress string
+
+// parseCanopyAddress normalizes a canopy address, accepting an optional 0x prefix and any case, and
+// checks it decodes to exactly 20 bytes
+func parseCanopyAddress(address string) (CanopyAddress, error) {
+	decoded, err := orderflow.DecodeAddress(strings.ToLower(strings.TrimSpace(address)))
+	if err != nil {
+		return "", fmt.Errorf("invalid canopy address: %w", err)
+	}
+	return CanopyAddress(hex.EncodeToString(decoded)), nil
+}
+
+// isCanopyAddress checks if an address is a valid canopy address
+func (e *EthOracleE2E) isCanopyAddress(address string) bool {
+	_, err := parseCanopyAddress(address)
+	return err == nil
 }
 
 // printAccountBalances prints the balances of all related accounts for debugging
 func (e *EthOracleE2E) printAccountBalances(label string) {
 	fmt.Printf("\n=== %s ===\n", label)
 
-	// Print Ethereum account USDC balances
+	// Print Ethereum account USDC and ETH balances; a low ETH balance makes locks and closes run out of gas
 	for i, account := range ethAccounts {
 		usdcBalance, err := e.getUSDCBalance(account)
 		if err != nil {
@@ -1056,6 +1961,12 @@ func (e *EthOracleE2E) printAccountBalances(label string) {
 		} else {
 			fmt.Printf("ETH Account %d (%s): USDC balance: %s\n", i, account, e.formatUSDCBalance(usdcBalance))
 		}
+		ethBalance, err := e.getETHBalance(account)
+		if err != nil {
+			fmt.Printf("ETH Account %d (%s): ETH balance error: %v\n", i, account, err)
+		} else {
+			fmt.Printf("ETH Account %d (%s): ETH balance: %s\n", i, account, e.formatETHBalance(ethBalance))
+		}
 	}
 
 	// Print Canopy account CNPY balances
@@ -1072,33 +1983,38 @@ func (e *EthOracleE2E) printAccountBalances(label string) {
 
 // Helper functions
 func (e *EthOracleE2E) getUSDCBalance(address string) (*big.Int, error) {
-	usdcContract := common.HexToAddress(strings.TrimPrefix(os.Getenv("USDC_CONTRACT"), "0x"))
-	account := common.HexToAddress(strings.TrimPrefix(address, "0x"))
-
-	// ERC20 balanceOf method signature
-	balanceOfMethodID := "70a08231"
-	data := balanceOfMethodID + hex.EncodeToString(common.LeftPadBytes(account.Bytes(), 32))
+	return e.getTokenBalance(e.tokenContract, address)
+}
 
-	callData, err := hex.DecodeString(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode call data: %w", err)
-	}
+// getTokenBalance reads the ERC20 balance of an eth address
+func (e *EthOracleE2E) getTokenBalance(tokenContract, address string) (*big.Int, error) {
+	contract := common.HexToAddress(strings.TrimPrefix(tokenContract, "0x"))
+	account := common.HexToAddress(strings.TrimPrefix(address, "0x"))
 
 	result, err := e.ethClient.CallContract(context.Background(), ethereum.CallMsg{
-		To:   &usdcContract,
-		Data: callData,
+		To:   &contract,
+		Data: e.txOptions.BalanceOfData(account),
 	}, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call contract: %w", err)
+		return nil, fmt.Errorf("failed to call contract: %w: %w", ErrRPCUnavailable, err)
 	}
 
 	return new(big.Int).SetBytes(result), nil
 }
 
+// getETHBalance reads the ETH balance of an eth address in wei
+func (e *EthOracleE2E) getETHBalance(address string) (*big.Int, error) {
+	balance, err := e.ethClient.BalanceAt(context.Background(), common.HexToAddress(strings.TrimPrefix(address, "0x")), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ETH balance: %w: %w", ErrRPCUnavailable, err)
+	}
+	return balance, nil
+}
+
 func (e *EthOracleE2E) getCNPYBalance(address string) (uint64, error) {
 	account, err := e.client.Account(0, address)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get CNPY balance: %w", err)
+		return 0, fmt.Errorf("failed to get CNPY balance: %w: %w", ErrRPCUnavailable, err)
 	}
 	return account.Amount, nil
 }
@@ -1112,15 +2028,53 @@ func (e *EthOracleE2E) formatUSDCBalance(balance *big.Int) string {
 	return fmt.Sprintf("%s.%06d USDC", quotient.String(), remainder.Uint64())
 }
 
-func (e *EthOracleE2E) Orders() (*lib.OrderBooks, error) {
-	orders, err := e.client.Orders(0, 2)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query orders: %w", err)
+func (e *EthOracleE2E) formatETHBalance(balance *big.Int) string {
+	// ETH has 18 decimal places
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	quotient := new(big.Int).Div(balance, divisor)
+	remainder := new(big.Int).Mod(balance, divisor)
+
+	return fmt.Sprintf("%s.%018d ETH", quotient.String(), remainder.Uint64())
+}
+
+// Orders queries the order books of the given committees, defaulting to the configured committees
+func (e *EthOracleE2E) Orders(committees ...uint64) (*lib.OrderBooks, error) {
+	if len(committees) == 0 {
+		committees = e.committees
+	}
+	orders := &lib.OrderBooks{}
+	for _, committee := range committees {
+		books, err := e.client.Orders(0, committee)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query orders for committee %d: %w: %w", committee, ErrRPCUnavailable, err)
+		}
+		orders.OrderBooks = append(orders.OrderBooks, books.OrderBooks...)
 	}
 	return orders, nil
 }
 
-// deleteAllExistingOrders deletes all existing orders before starting tests
+// parseCommittees parses a comma-separated list of committee IDs
+func parseCommittees(value string) ([]uint64, error) {
+	var committees []uint64
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		committee, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid committee %q: %w", field, err)
+		}
+		committees = append(committees, committee)
+	}
+	if len(committees) == 0 {
+		return nil, fmt.Errorf("no committees given")
+	}
+	return committees, nil
+}
+
+// deleteAllExistingOrders deletes all existing orders before starting tests and waits
+// until they have left the order book
 func (e *EthOracleE2E) deleteAllExistingOrders() error {
 	e.logger.Info("Deleting all existing orders before starting tests...")
 
@@ -1130,77 +2084,224 @@ func (e *EthOracleE2E) deleteAllExistingOrders() error {
 		return fmt.Errorf("failed to get existing orders: %w", err)
 	}
 
-	from, pass := getAuth()
+	signers, err := e.deleteSigners()
+	if err != nil {
+		return err
+	}
 
-	deletedCount := 0
-	// Delete each order
-	for _, orderBook := range orders.OrderBooks {
-		for _, order := range orderBook.Orders {
-			// Delete the order using e.client.TxDeleteOrder
-			orderId := lib.BytesToString(order.Id)
+	toDelete, skipped := e.ordersToDelete(orders, signers)
+	if skipped > 0 {
+		e.logger.Infof("Leaving %d orders created by accounts we can't sign for in the order book", skipped)
+	}
 
-			e.logger.Infof("Deleting order %s created by %s", orderId, from)
+	pending, deletedCount := e.submitDeletes(toDelete)
+	if len(pending) == 0 {
+		return nil
+	}
+	e.logger.Infof("Sent %d of %d delete order transactions, waiting for confirmation", deletedCount, len(pending))
 
-			_, _, err := e.client.TxDeleteOrder(from, orderId, chainId, pass, true, 100000)
+	// Wait for the deleted orders to leave the order book
+	timeout := time.After(e.deleteTimeout)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("%w after %s waiting for %d orders to be deleted: %s", ErrTimeout,
+				e.deleteTimeout, len(pending), strings.Join(pending, ", "))
+		case <-e.suiteDone():
+			return e.suiteErr()
+		case <-ticker.C:
+			orders, err := e.Orders()
 			if err != nil {
-				e.logger.Errorf("Failed to delete order %s: %v", orderId, err)
+				e.logger.Warnf("Failed to query orders during delete wait: %v", err)
 				continue
 			}
 
-			deletedCount++
+			remaining := make(map[string]bool)
+			for _, orderBook := range orders.OrderBooks {
+				for _, order := range orderBook.Orders {
+					remaining[lib.BytesToString(order.Id)] = true
+				}
+			}
+
+			var stillPending []string
+			for _, orderId := range pending {
+				if remaining[orderId] {
+					stillPending = append(stillPending, orderId)
+				}
+			}
+			pending = stillPending
+
+			if len(pending) == 0 {
+				e.logger.Infof("Successfully deleted %d existing orders", deletedCount)
+				return nil
+			}
 		}
 	}
+}
+
+// orderSigner is the keystore entry and password transactions of an account are signed with
+type orderSigner struct {
+	from rpc.AddrOrNickname
+	pass string
+}
 
-	if deletedCount > 0 {
-		e.logger.Infof("Successfully deleted %d existing orders", deletedCount)
-		// Wait a moment for the deletions to be processed
-		time.Sleep(10 * time.Second)
+// orderDelete is an order deleteAllExistingOrders deletes and the account that created it, the
+// only one canopy accepts the delete from
+type orderDelete struct {
+	order  *lib.SellOrder
+	signer orderSigner
+}
+
+// submitDeletes sends a delete order transaction for every order, signed by its creator,
+// deleteWorkers at a time. It returns the ids of the orders to wait for and how many transactions
+// were sent
+func (e *EthOracleE2E) submitDeletes(deletes []orderDelete) ([]string, int) {
+	workers := e.deleteWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(deletes) {
+		workers = len(deletes)
+	}
+
+	pending := make([]string, len(deletes))
+	for i, del := range deletes {
+		pending[i] = lib.BytesToString(del.order.Id)
+	}
+
+	var mutex sync.Mutex
+	sent, done := 0, 0
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				orderId, signer := pending[i], deletes[i].signer
+				e.logger.Infof("Deleting order %s created by %s", orderId, signer.from)
+				_, _, err := e.client.TxDeleteOrder(signer.from, orderId, deletes[i].order.Committee, signer.pass, true, deleteOrderFee)
+
+				mutex.Lock()
+				done++
+				if err != nil {
+					e.logger.Errorf("Failed to delete order %s: %v", orderId, err)
+				} else {
+					sent++
+				}
+				e.logger.Infof("Delete progress: %d/%d submitted", done, len(deletes))
+				mutex.Unlock()
+			}
+		}()
 	}
+	for i := range deletes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 
-	return nil
+	return pending, sent
+}
+
+// ordersToDelete returns the orders deleteAllExistingOrders deletes, each with the signer of its
+// creator, and how many it leaves alone because none of the signers created them
+func (e *EthOracleE2E) ordersToDelete(orders *lib.OrderBooks, signers map[string]orderSigner) ([]orderDelete, int) {
+	var toDelete []orderDelete
+	skipped := 0
+	for _, orderBook := range orders.OrderBooks {
+		for _, order := range orderBook.Orders {
+			signer, ok := signers[lib.BytesToString(order.SellersSendAddress)]
+			if !ok {
+				skipped++
+				continue
+			}
+			toDelete = append(toDelete, orderDelete{order: order, signer: signer})
+		}
+	}
+	return toDelete, skipped
+}
+
+// deleteSigners returns the keystore entries deleteAllExistingOrders may delete the orders of, by
+// canopy address: the E2E_FROM_NICK and seller entries with deleteMineOnly, otherwise every entry
+// of the keystore, unlocked with the E2E_FROM_PASS password
+func (e *EthOracleE2E) deleteSigners() (map[string]orderSigner, error) {
+	keystore, err := crypto.NewKeystoreFromFile(e.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keystore: %w", err)
+	}
+
+	signers := make(map[string]orderSigner)
+	add := func(from rpc.AddrOrNickname, pass string) {
+		if address, ok := keystore.NicknameMap[from.Nickname]; ok {
+			signers[strings.ToLower(address)] = orderSigner{from: from, pass: pass}
+		}
+	}
+	if !e.deleteMineOnly {
+		for nick := range keystore.NicknameMap {
+			add(rpc.AddrOrNickname{Nickname: nick}, e.envPassword())
+		}
+	}
+	add(e.getAuth())
+	if e.sellerNick != "" {
+		from, pass, err := e.sellerAuth(e.sellerNick, e.sellerPass)
+		if err != nil {
+			return nil, err
+		}
+		add(from, pass)
+	}
+	return signers, nil
 }
 
+// suiteDone returns a channel closed when the suite deadline is exceeded, or nil outside a suite run
+func (e *EthOracleE2E) suiteDone() <-chan struct{} {
+	if e.suiteCtx == nil {
+		return nil
+	}
+	return e.suiteCtx.Done()
+}
+
+// suiteErr returns an error once the suite deadline is exceeded or the suite is cancelled, and nil
+// before
+func (e *EthOracleE2E) suiteErr() error {
+	if e.suiteCtx == nil || e.suiteCtx.Err() == nil {
+		return nil
+	}
+	if cause := context.Cause(e.suiteCtx); !errors.Is(cause, context.DeadlineExceeded) {
+		return cause
+	}
+	return fmt.Errorf("suite deadline of %s exceeded: %w", e.suiteDeadline, e.suiteCtx.Err())
+}
+
+// passTestCase counts a test case as passed, unless it already finished, e.g. failed as stalled
 func (e *EthOracleE2E) passTestCase(testCase *TestCase) {
 	e.testResults.mutex.Lock()
 	defer e.testResults.mutex.Unlock()
 
+	if testCase.finished {
+		return
+	}
+	testCase.finished = true
 	e.testResults.passed++
 	e.logger.Infof("Test %s - PASSED ✅", testCase.Name)
 }
 
+// failTestCase counts a test case as failed with err, unless it already finished
 func (e *EthOracleE2E) failTestCase(testCase *TestCase, err error) {
 	e.testResults.mutex.Lock()
 	defer e.testResults.mutex.Unlock()
 
+	if testCase.finished {
+		return
+	}
 	testCase.Error = err
+	testCase.finished = true
 	e.testResults.failed++
 	e.logger.Errorf("Test %s - FAILED ❌: %v", testCase.Name, err)
 }
 
-func (e *EthOracleE2E) waitForTestCompletion() {
-	// Wait for all tests to complete
-	timeout := time.After(5 * time.Minute)
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-timeout:
-			e.logger.Errorf("Timeout waiting for test completion")
-			return
-		case <-ticker.C:
-			e.testResults.mutex.RLock()
-			completed := e.testResults.passed + e.testResults.failed
-			total := e.testResults.total
-			e.testResults.mutex.RUnlock()
-
-			if completed >= total {
-				return
-			}
-		}
-	}
-}
-
 func (e *EthOracleE2E) printTestResults() {
 	e.testResults.mutex.RLock()
 	defer e.testResults.mutex.RUnlock()
@@ -0,0 +1,1405 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/canopy-network/canopy/cmd/rpc"
+	"github.com/canopy-network/canopy/fsm"
+	"github.com/canopy-network/canopy/lib"
+	"github.com/canopy-network/canopy/lib/crypto"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeCanopyClient is an in-memory CanopyClient serving a fixed order book
+type fakeCanopyClient struct {
+	orders    *lib.OrderBooks
+	height    uint64
+	amounts   map[string]uint64
+	sends     int
+	ordersErr lib.ErrorI // returned by Orders when set, as if the node were down
+	creators  []rpc.AddrOrNickname
+
+	deleteMutex sync.Mutex
+	deleting    int // delete transactions in flight
+	maxDeleting int // most delete transactions in flight at once
+	deleted     []string
+	deleters    []rpc.AddrOrNickname // the signer of each delete, in the order of deleted
+}
+
+func (f *fakeCanopyClient) Orders(height, chainId uint64) (*lib.OrderBooks, lib.ErrorI) {
+	if f.ordersErr != nil {
+		return nil, f.ordersErr
+	}
+	books := &lib.OrderBooks{}
+	for _, book := range f.orders.OrderBooks {
+		if book.ChainId == chainId {
+			books.OrderBooks = append(books.OrderBooks, book)
+		}
+	}
+	return books, nil
+}
+
+func (f *fakeCanopyClient) Height() (*uint64, lib.ErrorI) {
+	return &f.height, nil
+}
+
+func (f *fakeCanopyClient) Account(height uint64, address string) (*fsm.Account, lib.ErrorI) {
+	return &fsm.Account{Amount: f.amounts[address]}, nil
+}
+
+func (f *fakeCanopyClient) TxCreateOrder(from rpc.AddrOrNickname, sellAmount, receiveAmount, chainId uint64, receiveAddress string,
+	pwd string, data lib.HexBytes, submit bool, optFee uint64) (*string, json.RawMessage, lib.ErrorI) {
+	f.creators = append(f.creators, from)
+	hash := strings.Repeat("ab", 32)
+	return &hash, nil, nil
+}
+
+// TxDeleteOrder records the order id and how many deletes overlap; it doesn't remove the order
+func (f *fakeCanopyClient) TxDeleteOrder(from rpc.AddrOrNickname, orderId string, chainId uint64,
+	pwd string, submit bool, optFee uint64) (*string, json.RawMessage, lib.ErrorI) {
+	f.deleteMutex.Lock()
+	f.deleting++
+	if f.deleting > f.maxDeleting {
+		f.maxDeleting = f.deleting
+	}
+	f.deleted = append(f.deleted, orderId)
+	f.deleters = append(f.deleters, from)
+	f.deleteMutex.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	f.deleteMutex.Lock()
+	f.deleting--
+	f.deleteMutex.Unlock()
+	return nil, nil, nil
+}
+
+// TxSend credits the recipient immediately, as if the send confirmed in the next block
+func (f *fakeCanopyClient) TxSend(from rpc.AddrOrNickname, rec string, amt uint64, pwd string, submit bool,
+	optFee uint64) (*string, json.RawMessage, lib.ErrorI) {
+	if f.amounts == nil {
+		f.amounts = make(map[string]uint64)
+	}
+	f.amounts[rec] += amt
+	f.sends++
+	hash := fmt.Sprintf("%040x", f.sends)
+	return &hash, nil, nil
+}
+
+// newTestE2E returns an EthOracleE2E backed by a fake canopy client serving the given orders
+func newTestE2E(orders ...*lib.SellOrder) *EthOracleE2E {
+	return &EthOracleE2E{
+		client: &fakeCanopyClient{orders: &lib.OrderBooks{
+			OrderBooks: []*lib.OrderBook{{ChainId: chainId, Orders: orders}},
+		}},
+		logger:       lib.NewDefaultLogger(),
+		testResults:  &TestResults{testCases: make(map[string]*TestCase)},
+		committees:   []uint64{chainId},
+		closeTimeout: defaultCloseTimeout,
+	}
+}
+
+var (
+	unlockedOrder = &lib.SellOrder{Id: []byte{0x01}, Committee: chainId, AmountForSale: 100, RequestedAmount: 100}
+	lockedOrder   = &lib.SellOrder{Id: []byte{0x02}, Committee: chainId, AmountForSale: 200, RequestedAmount: 200,
+		BuyerSendAddress: []byte{0xaa}}
+	unlockedOrder2 = &lib.SellOrder{Id: []byte{0x03}, Committee: chainId, AmountForSale: 300, RequestedAmount: 300}
+)
+
+func TestFindOrderByID(t *testing.T) {
+	tests := []struct {
+		name    string
+		orders  []*lib.SellOrder
+		orderID string
+		wantErr bool
+	}{
+		{name: "found unlocked", orders: []*lib.SellOrder{unlockedOrder, lockedOrder}, orderID: "01"},
+		{name: "found locked", orders: []*lib.SellOrder{unlockedOrder, lockedOrder}, orderID: "02"},
+		{name: "not found", orders: []*lib.SellOrder{unlockedOrder}, orderID: "02", wantErr: true},
+		{name: "empty book", orderID: "01", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, err := newTestE2E(test.orders...).findOrderByID(test.orderID)
+			if test.wantErr {
+				if !errors.Is(err, ErrOrderNotFound) {
+					t.Fatalf("expected ErrOrderNotFound, got order %v, error %v", order, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := lib.BytesToString(order.Id); got != test.orderID {
+				t.Errorf("got order %s, want %s", got, test.orderID)
+			}
+		})
+	}
+}
+
+func TestFindFirstOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		orders       []*lib.SellOrder
+		wantUnlocked string
+		wantLocked   string
+	}{
+		{name: "mixed", orders: []*lib.SellOrder{lockedOrder, unlockedOrder, unlockedOrder2}, wantUnlocked: "01", wantLocked: "02"},
+		{name: "only unlocked", orders: []*lib.SellOrder{unlockedOrder2, unlockedOrder}, wantUnlocked: "03"},
+		{name: "only locked", orders: []*lib.SellOrder{lockedOrder}, wantLocked: "02"},
+		{name: "empty book"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestE2E(test.orders...)
+
+			unlocked, err := e.findFirstUnlockedOrder()
+			if test.wantUnlocked == "" {
+				if err == nil {
+					t.Errorf("expected no unlocked order, got %x", unlocked.Id)
+				}
+			} else if err != nil || lib.BytesToString(unlocked.Id) != test.wantUnlocked {
+				t.Errorf("findFirstUnlockedOrder() = %v, %v; want %s", unlocked, err, test.wantUnlocked)
+			}
+
+			locked, err := e.findFirstLockedOrder()
+			if test.wantLocked == "" {
+				if err == nil {
+					t.Errorf("expected no locked order, got %x", locked.Id)
+				}
+			} else if err != nil || lib.BytesToString(locked.Id) != test.wantLocked {
+				t.Errorf("findFirstLockedOrder() = %v, %v; want %s", locked, err, test.wantLocked)
+			}
+		})
+	}
+}
+
+func TestFindAllOrders(t *testing.T) {
+	tests := []struct {
+		name         string
+		orders       []*lib.SellOrder
+		wantUnlocked int
+		wantLocked   int
+	}{
+		{name: "mixed", orders: []*lib.SellOrder{lockedOrder, unlockedOrder, unlockedOrder2}, wantUnlocked: 2, wantLocked: 1},
+		{name: "only unlocked", orders: []*lib.SellOrder{unlockedOrder, unlockedOrder2}, wantUnlocked: 2},
+		{name: "only locked", orders: []*lib.SellOrder{lockedOrder}, wantLocked: 1},
+		{name: "empty book"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestE2E(test.orders...)
+
+			unlocked, err := e.findAllUnlockedOrders()
+			if (err != nil) != (test.wantUnlocked == 0) || len(unlocked) != test.wantUnlocked {
+				t.Errorf("findAllUnlockedOrders() returned %d orders, err %v; want %d", len(unlocked), err, test.wantUnlocked)
+			}
+			for _, order := range unlocked {
+				if order.BuyerSendAddress != nil {
+					t.Errorf("findAllUnlockedOrders() returned locked order %x", order.Id)
+				}
+			}
+
+			locked, err := e.findAllLockedOrders()
+			if (err != nil) != (test.wantLocked == 0) || len(locked) != test.wantLocked {
+				t.Errorf("findAllLockedOrders() returned %d orders, err %v; want %d", len(locked), err, test.wantLocked)
+			}
+			for _, order := range locked {
+				if order.BuyerSendAddress == nil {
+					t.Errorf("findAllLockedOrders() returned unlocked order %x", order.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderIDPrefix(t *testing.T) {
+	tests := []struct {
+		name         string
+		prefix       string
+		wantUnlocked int
+		wantLocked   int
+	}{
+		{name: "no prefix", wantUnlocked: 2, wantLocked: 1},
+		{name: "unlocked order", prefix: "0x03", wantUnlocked: 1},
+		{name: "locked order", prefix: "02", wantLocked: 1},
+		{name: "shared prefix", prefix: "0", wantUnlocked: 2, wantLocked: 1},
+		{name: "no match", prefix: "ff"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestE2E(lockedOrder, unlockedOrder, unlockedOrder2)
+			prefix, err := parseOrderIDPrefix(test.prefix)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			e.orderIDPrefix = prefix
+
+			unlocked, _ := e.findAllUnlockedOrders()
+			locked, _ := e.findAllLockedOrders()
+			if len(unlocked) != test.wantUnlocked || len(locked) != test.wantLocked {
+				t.Errorf("found %d unlocked and %d locked orders, want %d and %d", len(unlocked), len(locked), test.wantUnlocked, test.wantLocked)
+			}
+			if first, err := e.findFirstUnlockedOrder(); (err == nil) != (test.wantUnlocked > 0) {
+				t.Errorf("findFirstUnlockedOrder() = %v, %v", first, err)
+			}
+			watched, err := e.watchedOrders()
+			if err != nil || len(watched) != test.wantUnlocked+test.wantLocked {
+				t.Errorf("watchedOrders() returned %d orders, err %v; want %d", len(watched), err, test.wantUnlocked+test.wantLocked)
+			}
+		})
+	}
+
+	if _, err := parseOrderIDPrefix("0xzz"); err == nil {
+		t.Error("expected error for a non-hex prefix")
+	}
+}
+
+func TestOrdersAcrossCommittees(t *testing.T) {
+	otherCommittee := &lib.SellOrder{Id: []byte{0x04}, Committee: 3, AmountForSale: 400, RequestedAmount: 400}
+	e := newTestE2E(unlockedOrder)
+	fake := e.client.(*fakeCanopyClient)
+	fake.orders.OrderBooks = append(fake.orders.OrderBooks, &lib.OrderBook{ChainId: 3, Orders: []*lib.SellOrder{otherCommittee}})
+
+	tests := []struct {
+		name       string
+		committees []uint64
+		want       int
+	}{
+		{name: "default committee", committees: []uint64{chainId}, want: 1},
+		{name: "other committee", committees: []uint64{3}, want: 1},
+		{name: "both committees", committees: []uint64{chainId, 3}, want: 2},
+		{name: "unknown committee", committees: []uint64{9}, want: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e.committees = test.committees
+			orders, err := e.findAllUnlockedOrders()
+			if len(orders) != test.want || (err != nil) != (test.want == 0) {
+				t.Errorf("findAllUnlockedOrders() returned %d orders, err %v; want %d", len(orders), err, test.want)
+			}
+		})
+	}
+}
+
+func TestDiffOrders(t *testing.T) {
+	before, err := newTestE2E(unlockedOrder, lockedOrder).snapshotOrders()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// order 01 gets locked, order 02 completes and order 03 is created
+	nowLocked := &lib.SellOrder{Id: unlockedOrder.Id, Committee: chainId, AmountForSale: 100, RequestedAmount: 100,
+		BuyerSendAddress: []byte{0xbb}}
+	after, err := newTestE2E(nowLocked, unlockedOrder2).snapshotOrders()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	diff := diffOrders(before, after)
+	want := OrderDiff{Added: []string{"03"}, Removed: []string{"02"}, Locked: []string{"01"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diffOrders() = %+v, want %+v", diff, want)
+	}
+	if !diffOrders(after, after).Empty() {
+		t.Error("diff of a snapshot with itself is not empty")
+	}
+}
+
+func TestRotateTestAccounts(t *testing.T) {
+	defer func(accounts []string) { canopyAccounts = accounts }(canopyAccounts)
+	defer func(accounts, keys [10]string) { ethAccounts, ethPrivateKeys = accounts, keys }(ethAccounts, ethPrivateKeys)
+	canopyAccounts = []string{"validator", "canopy-1", "canopy-2", "canopy-3"}
+	for i := range ethAccounts {
+		ethAccounts[i], ethPrivateKeys[i] = fmt.Sprintf("eth-%d", i), fmt.Sprintf("key-%d", i)
+	}
+
+	e := newTestE2E()
+	e.committees = []uint64{1, 2}
+	e.negativeTests = true
+	testCases := e.generateTestCases()
+	if len(testCases) != 4 {
+		t.Fatalf("got %d test cases, want 4", len(testCases))
+	}
+	if err := rotateTestAccounts(testCases); err == nil {
+		t.Error("expected error for 4 test cases with 3 rotatable canopy accounts")
+	}
+
+	canopyAccounts = append(canopyAccounts, "canopy-4")
+	if err := rotateTestAccounts(testCases); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seen := make(map[string]string)
+	claim := func(account, owner string) {
+		if previous, ok := seen[account]; ok {
+			t.Errorf("account %s is used by both %s and %s", account, previous, owner)
+		}
+		seen[account] = owner
+	}
+	for i, testCase := range testCases {
+		claim(testCase.BuyerAddress, testCase.Name+" buyer")
+		claim(testCase.SellerAddress, testCase.Name+" seller")
+		if testCase.CloseRecipient != "" {
+			claim(testCase.CloseRecipient, testCase.Name+" recipient")
+		}
+		claim(testCase.CanopyReceiveAddress, testCase.Name+" canopy")
+		if testCase.CanopyReceiveAddress != canopyAccounts[i+1] || testCase.CanopySendAddress != "" {
+			t.Errorf("%s canopy accounts = %s/%s, want %s and the seller nick's", testCase.Name, testCase.CanopyReceiveAddress, testCase.CanopySendAddress, canopyAccounts[i+1])
+		}
+		if strings.TrimPrefix(testCase.BuyerPrivateKey, "key-") != strings.TrimPrefix(testCase.BuyerAddress, "eth-") ||
+			strings.TrimPrefix(testCase.SellerPrivateKey, "key-") != strings.TrimPrefix(testCase.SellerAddress, "eth-") {
+			t.Errorf("%s keys don't match its accounts", testCase.Name)
+		}
+	}
+
+	// 4 cases, 2 of them negative, need 10 eth accounts; without the last one they run out
+	ethAccounts[9] = ""
+	if err := rotateTestAccounts(testCases); err == nil {
+		t.Error("expected error when the eth accounts run out")
+	}
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "env-nick")
+	t.Setenv("E2E_FROM_PASS", "env-secret")
+
+	e := newEthOracleE2E(lib.Config{}, "/data", &OracleConfig{USDCContract: "0xtoken"})
+	e.suiteDeadline = time.Minute
+	config := e.effectiveConfig("http://anvil:8545", 0)
+
+	if config.CanopyRPCURL != "http://node-1:50002" || config.EthRPCURL != "http://anvil:8545" || config.TokenContract != "0xtoken" {
+		t.Errorf("endpoints = %s, %s, %s", config.CanopyRPCURL, config.EthRPCURL, config.TokenContract)
+	}
+	if config.EthChainID != "auto (node network id)" {
+		t.Errorf("EthChainID = %q, want auto", config.EthChainID)
+	}
+	if got := e.effectiveConfig("", 31337).EthChainID; got != "31337" {
+		t.Errorf("EthChainID with an override = %q, want 31337", got)
+	}
+	if config.SellerNick != "env-nick" || config.Password != redacted {
+		t.Errorf("credentials = %s/%s, want env-nick/%s", config.SellerNick, config.Password, redacted)
+	}
+	if config.Timeouts.SuiteDeadline != "1m0s" || config.Timeouts.DeleteTimeout != defaultDeleteTimeout.String() {
+		t.Errorf("timeouts = %+v", config.Timeouts)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, secret := range append([]string{"env-secret"}, ethPrivateKeys[:]...) {
+		if secret != "" && strings.Contains(string(data), secret) {
+			t.Errorf("dumped config contains the secret %s", secret)
+		}
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "nick-0")
+	t.Setenv("E2E_FROM_PASS", "test")
+	e := newTestE2E(unlockedOrder, lockedOrder)
+	fake := e.client.(*fakeCanopyClient)
+
+	if err := e.CancelOrder(lib.BytesToString(unlockedOrder.Id)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.CancelOrder(lib.BytesToString(lockedOrder.Id)); !errors.Is(err, ErrOrderLocked) {
+		t.Errorf("CancelOrder(locked) = %v, want %v", err, ErrOrderLocked)
+	}
+	if err := e.CancelOrder("ff"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("CancelOrder(missing) = %v, want %v", err, ErrOrderNotFound)
+	}
+	if want := []string{lib.BytesToString(unlockedOrder.Id)}; !reflect.DeepEqual(fake.deleted, want) {
+		t.Errorf("deleted %v, want only %v", fake.deleted, want)
+	}
+}
+
+func TestResumeTestCases(t *testing.T) {
+	defer func(accounts []string) { canopyAccounts = accounts }(canopyAccounts)
+	canopyAccounts = []string{"a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e", "b1fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"}
+
+	locked := &lib.SellOrder{Id: []byte{0x04}, Committee: chainId, AmountForSale: 400, RequestedAmount: 500,
+		BuyerSendAddress: common.FromHex(ethAccounts[2]), BuyerReceiveAddress: common.FromHex(canopyAccounts[0]),
+		SellerReceiveAddress: common.FromHex(ethAccounts[1])}
+	e := newTestE2E(unlockedOrder, locked)
+
+	testCases, err := e.resumeTestCases()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(testCases) != 2 {
+		t.Fatalf("got %d test cases, want 2", len(testCases))
+	}
+
+	created, resumedLock := testCases[0], testCases[1]
+	if created.OrderID != "01" || created.Status != "created" || created.BuyerPrivateKey != ethPrivateKeys[0] {
+		t.Errorf("unlocked order resumed as %+v", created)
+	}
+	if resumedLock.OrderID != "04" || resumedLock.Status != "locked" {
+		t.Errorf("locked order resumed as %+v", resumedLock)
+	}
+	if resumedLock.BuyerPrivateKey != ethPrivateKeys[2] || !strings.EqualFold(resumedLock.BuyerAddress, ethAccounts[2]) {
+		t.Errorf("locked order buyer %s, key %s; want %s", resumedLock.BuyerAddress, resumedLock.BuyerPrivateKey, ethAccounts[2])
+	}
+	if resumedLock.CanopyReceiveAddress != canopyAccounts[0] || resumedLock.OrderAmount != 400 || resumedLock.ExpectedUSDCTransfer != 500 {
+		t.Errorf("locked order resumed as %+v", resumedLock)
+	}
+}
+
+func TestParseCanopyAddress(t *testing.T) {
+	const want = CanopyAddress("a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e")
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "canonical", address: "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"},
+		{name: "0x prefix", address: "0xa0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"},
+		{name: "uppercase", address: "A0FD5A5DCB6DA1BBAC3AD7FE6AB1C9B06E5F6D5E"},
+		{name: "uppercase prefix", address: "0XA0FD5A5DCB6DA1BBAC3AD7FE6AB1C9B06E5F6D5E"},
+		{name: "too short", address: "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d", wantErr: true},
+		{name: "too long", address: "0xa0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e00", wantErr: true},
+		{name: "odd length", address: "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5", wantErr: true},
+		{name: "not hex", address: "g0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseCanopyAddress(test.address)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				if (&EthOracleE2E{}).isCanopyAddress(test.address) {
+					t.Errorf("isCanopyAddress(%q) = true", test.address)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCapOrders(t *testing.T) {
+	orders := []*lib.SellOrder{unlockedOrder, lockedOrder, unlockedOrder2}
+	tests := []struct {
+		name          string
+		maxOrders     int
+		wantLen       int
+		wantRemaining int
+	}{
+		{name: "no cap", maxOrders: 0, wantLen: 3},
+		{name: "cap above count", maxOrders: 5, wantLen: 3},
+		{name: "cap equal to count", maxOrders: 3, wantLen: 3},
+		{name: "cap below count", maxOrders: 2, wantLen: 2, wantRemaining: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestE2E()
+			e.maxOrders = test.maxOrders
+			capped, remaining := e.capOrders(orders)
+			if len(capped) != test.wantLen || remaining != test.wantRemaining {
+				t.Errorf("capOrders() = %d orders, %d remaining; want %d, %d", len(capped), remaining, test.wantLen, test.wantRemaining)
+			}
+			if len(capped) > 0 && capped[0] != orders[0] {
+				t.Error("capOrders() didn't keep the first orders")
+			}
+		})
+	}
+}
+
+func TestExpectedBalanceDeltas(t *testing.T) {
+	testCase := &TestCase{OrderAmount: 1000000, ExpectedUSDCTransfer: 2500000, ExpectedCNPYTransfer: 1000000}
+	buyer, seller, cnpy := expectedBalanceDeltas(testCase)
+	if buyer.Int64() != -2500000 || seller.Int64() != 2500000 || cnpy != 1000000 {
+		t.Errorf("expectedBalanceDeltas() = %s, %s, %d; want -2500000, 2500000, 1000000", buyer, seller, cnpy)
+	}
+}
+
+func TestBatchTestCase(t *testing.T) {
+	defer func(accounts []string) { canopyAccounts = accounts }(canopyAccounts)
+	canopyAccounts = []string{"validator", "canopy-1"}
+	e := newTestE2E()
+	e.committees = []uint64{2}
+	if testCases := e.generateTestCases(); len(testCases) != 1 {
+		t.Fatalf("got %d test cases without --batch-orders, want 1", len(testCases))
+	}
+
+	e.batchOrders = 3
+	testCases := e.generateTestCases()
+	if len(testCases) != 2 {
+		t.Fatalf("got %d test cases, want 2", len(testCases))
+	}
+	batch := testCases[1]
+	if batch.BatchSize != 3 || batch.orderCount() != 3 || batch.Committee != 2 {
+		t.Fatalf("batch case = %+v, want 3 orders on committee 2", batch)
+	}
+
+	buyer, seller, cnpy := expectedBalanceDeltas(batch)
+	if buyer.Int64() != -1500000 || seller.Int64() != 1500000 || cnpy != 1500000 {
+		t.Errorf("expectedBalanceDeltas() = %s, %s, %d; want the sum of the 3 orders", buyer, seller, cnpy)
+	}
+	if err := buyerFundsShortfall(batch, big.NewInt(1000000), big.NewInt(1), big.NewInt(0)); err == nil || !strings.Contains(err.Error(), "short 500000") {
+		t.Errorf("buyerFundsShortfall() = %v, want the buyer short of the third order", err)
+	}
+
+	order := batch.batchOrder("abcd")
+	if order.OrderID != "abcd" || order.orderCount() != 1 || batch.OrderID != "" {
+		t.Errorf("batchOrder() = %+v, want a single order copy", order)
+	}
+
+	batch.Status, batch.CanopyReceiveAddress = StatusVerified, "aa"
+	report := reconcileEscrow([]*TestCase{batch}, &BalanceSnapshot{CNPY: map[string]uint64{}}, &BalanceSnapshot{CNPY: map[string]uint64{"aa": 1500000}})
+	if want := []CommitteeEscrow{{Committee: 2, Orders: 3, Expected: 1500000}}; !reflect.DeepEqual(report.Committees, want) {
+		t.Errorf("committees = %+v, want %+v", report.Committees, want)
+	}
+}
+
+func TestLoadOracleConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return path
+	}
+
+	t.Run("missing file falls back to the environment", func(t *testing.T) {
+		t.Setenv("ETH_RPC_URL", "http://env:8545")
+		t.Setenv("USDC_CONTRACT", "0xenv")
+		config, err := loadOracleConfig(filepath.Join(dir, "missing.json"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if config.ethRPCURL() != "http://env:8545" || config.usdcContract() != "0xenv" {
+			t.Errorf("got %q, %q, want the environment values", config.ethRPCURL(), config.usdcContract())
+		}
+	})
+
+	t.Run("file takes precedence over the environment", func(t *testing.T) {
+		t.Setenv("ETH_RPC_URL", "http://env:8545")
+		path := write("valid.json", `{"ethRpcUrl": "http://file:8545", "usdcContract": "0xfile",
+			"accounts": [{"address": "0x90F79bf6EB2c4f870365E785982E1f101E93b906", "privateKey": "7c85"}]}`)
+		config, err := loadOracleConfig(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if config.ethRPCURL() != "http://file:8545" || config.usdcContract() != "0xfile" {
+			t.Errorf("got %q, %q, want the file values", config.ethRPCURL(), config.usdcContract())
+		}
+
+		defer func(accounts, keys [10]string) { ethAccounts, ethPrivateKeys = accounts, keys }(ethAccounts, ethPrivateKeys)
+		builtin, builtinKey := ethAccounts[1], ethPrivateKeys[1]
+		config.applyAccounts()
+		if ethAccounts[0] != "0x90F79bf6EB2c4f870365E785982E1f101E93b906" || ethPrivateKeys[0] != "7c85" {
+			t.Errorf("applyAccounts() left accounts %v", ethAccounts)
+		}
+		// the test cases use accounts the config doesn't list
+		if ethAccounts[1] != builtin || ethPrivateKeys[1] != builtinKey {
+			t.Errorf("applyAccounts() replaced unlisted account 1 with %q", ethAccounts[1])
+		}
+	})
+
+	invalid := map[string]string{
+		"bad json":            `{`,
+		"bad address":         `{"accounts": [{"address": "0x1234", "privateKey": "7c85"}]}`,
+		"missing private key": `{"accounts": [{"address": "0x90F79bf6EB2c4f870365E785982E1f101E93b906"}]}`,
+	}
+	for name, content := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadOracleConfig(write(strings.ReplaceAll(name, " ", "_")+".json", content)); err == nil {
+				t.Error("loadOracleConfig() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestSuiteDeadline(t *testing.T) {
+	e := newTestE2E(lockedOrder)
+	if err := e.suiteErr(); err != nil {
+		t.Fatalf("suiteErr() outside a suite = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	e.suiteCtx, e.suiteDeadline = ctx, time.Millisecond
+	<-ctx.Done()
+
+	// the order never leaves the book, so only the deadline can end the wait
+	testCase := &TestCase{Name: "deadline", OrderID: lib.BytesToString(lockedOrder.Id)}
+	err := e.waitForOrderCompletion(testCase)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waitForOrderCompletion() = %v, want a deadline error", err)
+	}
+}
+
+func TestWaitForOrderCompletionExpectRemains(t *testing.T) {
+	// the residual order left the book, so the wait fails on the first check
+	e := newTestE2E(unlockedOrder)
+	testCase := &TestCase{Name: "partial fill", OrderID: lib.BytesToString(lockedOrder.Id), ExpectOrderRemains: true}
+	err := e.waitForOrderCompletion(testCase)
+	if err == nil || !strings.Contains(err.Error(), "expected to remain") {
+		t.Errorf("waitForOrderCompletion() = %v, want an unexpected removal error", err)
+	}
+
+	// the residual order is still in the book when the close window elapses, which passes
+	e = newTestE2E(lockedOrder)
+	e.closeTimeout = 10 * time.Millisecond
+	testCase.Status = StatusLocked
+	if err := e.waitForOrderCompletion(testCase); err != nil || testCase.Status != StatusClosed {
+		t.Errorf("waitForOrderCompletion() = %v with status %s, want nil and %s", err, testCase.Status, StatusClosed)
+	}
+}
+
+func TestOrderCompleted(t *testing.T) {
+	receive := "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"
+	tests := []struct {
+		name          string
+		orders        []*lib.SellOrder
+		balance       uint64
+		absenceOnly   bool
+		wantCompleted bool
+		wantMismatch  bool
+	}{
+		{name: "still in book", orders: []*lib.SellOrder{lockedOrder}, balance: 1500},
+		{name: "left book and released", balance: 1500, wantCompleted: true},
+		{name: "left book without release", balance: 1000, wantMismatch: true},
+		{name: "left book with partial release", balance: 1400, wantMismatch: true},
+		{name: "absence only", balance: 1000, absenceOnly: true, wantCompleted: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestE2E(test.orders...)
+			e.client.(*fakeCanopyClient).amounts = map[string]uint64{receive: test.balance}
+			e.completionAbsenceOnly = test.absenceOnly
+			testCase := &TestCase{
+				OrderID:              lib.BytesToString(lockedOrder.Id),
+				CanopyReceiveAddress: receive,
+				InitialCNPYBalance:   1000,
+				ExpectedCNPYTransfer: 500,
+			}
+
+			completed, err := e.orderCompleted(testCase)
+			if completed != test.wantCompleted {
+				t.Errorf("orderCompleted() = %t, want %t", completed, test.wantCompleted)
+			}
+			if errors.Is(err, ErrBalanceMismatch) != test.wantMismatch {
+				t.Errorf("orderCompleted() error = %v, want balance mismatch %t", err, test.wantMismatch)
+			}
+			if !test.wantMismatch && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestFindDuplicateOrder(t *testing.T) {
+	seller := common.HexToAddress(ethAccounts[1])
+	contract := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	existing := &lib.SellOrder{Id: []byte{0x10}, Committee: chainId, AmountForSale: 100, RequestedAmount: 100,
+		SellerReceiveAddress: seller.Bytes(), Data: contract.Bytes()}
+	locked := &lib.SellOrder{Id: []byte{0x11}, Committee: chainId, AmountForSale: 200, RequestedAmount: 200,
+		SellerReceiveAddress: seller.Bytes(), Data: contract.Bytes(), BuyerSendAddress: []byte{0xaa}}
+	e := newTestE2E(existing, locked)
+
+	tests := []struct {
+		name     string
+		amount   uint64
+		seller   string
+		contract string
+		want     *lib.SellOrder
+	}{
+		{name: "same order", amount: 100, seller: seller.Hex(), contract: contract.Hex(), want: existing},
+		{name: "different amount", amount: 101, seller: seller.Hex(), contract: contract.Hex()},
+		{name: "different seller", amount: 100, seller: ethAccounts[0], contract: contract.Hex()},
+		{name: "different token", amount: 100, seller: seller.Hex(), contract: ethAccounts[0]},
+		{name: "locked order is not a duplicate", amount: 200, seller: seller.Hex(), contract: contract.Hex()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := e.findDuplicateOrder(chainId, test.amount, test.amount, test.seller, test.contract)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("findDuplicateOrder() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFundAccounts(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "nick-0")
+	t.Setenv("E2E_FROM_PASS", "test")
+
+	e := newTestE2E()
+	client := e.client.(*fakeCanopyClient)
+	client.amounts = map[string]uint64{"funded": 500, "short": 100}
+	testCases := []*TestCase{
+		{CanopyReceiveAddress: "funded", CanopySendAddress: "short"},
+		{CanopyReceiveAddress: "empty", CanopySendAddress: "short"},
+	}
+
+	if err := e.fundAccounts(testCases, 300); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]uint64{"funded": 500, "short": 300, "empty": 300}
+	if !reflect.DeepEqual(client.amounts, want) {
+		t.Errorf("balances = %v, want %v", client.amounts, want)
+	}
+	if client.sends != 2 {
+		t.Errorf("sent %d funding transactions, want 2", client.sends)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	e := newTestE2E()
+	e.testResults.testCases = map[string]*TestCase{
+		"b": {Name: "b", Committee: 2, Status: "locked", Error: errors.New("close failed"), Duration: 1500 * time.Millisecond},
+		"a": {Name: "a", Committee: 2, OrderID: "abcd", Status: "verified", Duration: 2 * time.Second},
+	}
+	e.testResults.total, e.testResults.passed, e.testResults.failed = 2, 1, 1
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := e.writeSummary(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var summary SuiteSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("summary isn't valid JSON: %v", err)
+	}
+
+	if summary.Total != 2 || summary.Passed != 1 || summary.Failed != 1 || summary.SuccessRate != 50 {
+		t.Errorf("totals = %d/%d/%d %.0f%%, want 2/1/1 50%%", summary.Total, summary.Passed, summary.Failed, summary.SuccessRate)
+	}
+	want := []TestSummary{
+		{Name: "a", Committee: 2, OrderID: "abcd", Status: "verified", Passed: true, DurationMs: 2000},
+		{Name: "b", Committee: 2, Status: "locked", Error: "close failed", DurationMs: 1500},
+	}
+	if !reflect.DeepEqual(summary.Tests, want) {
+		t.Errorf("tests = %+v, want %+v", summary.Tests, want)
+	}
+}
+
+func TestWriteSummaryNoTests(t *testing.T) {
+	// a suite that failed before running anything must still produce valid JSON
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := newTestE2E().writeSummary(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGetOrderStatus(t *testing.T) {
+	buyer := []byte{0xaa, 0xbb}
+	tests := []struct {
+		name   string
+		order  *lib.SellOrder
+		height uint64
+		want   OrderStatus
+	}{
+		{name: "not in the book", order: nil, height: 10, want: StatusClosed},
+		{name: "unlocked", order: &lib.SellOrder{Id: []byte{1}}, height: 10, want: StatusCreated},
+		{name: "empty buyer address", order: &lib.SellOrder{Id: []byte{1}, BuyerSendAddress: []byte{}}, height: 10, want: StatusCreated},
+		{name: "locked before deadline", order: &lib.SellOrder{BuyerSendAddress: buyer, BuyerChainDeadline: 15}, height: 10, want: StatusLocked},
+		{name: "locked at deadline", order: &lib.SellOrder{BuyerSendAddress: buyer, BuyerChainDeadline: 15}, height: 15, want: StatusLocked},
+		{name: "deadline passed", order: &lib.SellOrder{BuyerSendAddress: buyer, BuyerChainDeadline: 15}, height: 16, want: StatusExpired},
+		{name: "height unknown", order: &lib.SellOrder{BuyerSendAddress: buyer, BuyerChainDeadline: 15}, height: 0, want: StatusLocked},
+		{name: "no deadline", order: &lib.SellOrder{BuyerSendAddress: buyer}, height: 100, want: StatusLocked},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GetOrderStatus(test.order, test.height); got != test.want {
+				t.Errorf("GetOrderStatus() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCloseTestOrderExpired(t *testing.T) {
+	expired := &lib.SellOrder{Id: []byte{0x09}, Committee: chainId, AmountForSale: 100, RequestedAmount: 100,
+		BuyerSendAddress: []byte{0xaa}, BuyerChainDeadline: 5}
+	e := newTestE2E(expired)
+	e.client.(*fakeCanopyClient).height = 6
+
+	testCase := &TestCase{OrderID: lib.BytesToString(expired.Id), Committee: chainId, OrderAmount: 100, ExpectedUSDCTransfer: 100}
+	if err := e.closeTestOrder(testCase); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("closeTestOrder() = %v, want an expired error", err)
+	}
+	if testCase.Status != StatusExpired {
+		t.Errorf("Status = %s, want %s", testCase.Status, StatusExpired)
+	}
+}
+
+func TestSellerAuth(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "default-nick")
+	t.Setenv("E2E_FROM_PASS", "default-pass")
+	dir := t.TempDir()
+	keystore := `{"addressMap": {}, "nicknameMap": {"seller-2": "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"}}`
+	if err := os.WriteFile(filepath.Join(dir, "keystore.json"), []byte(keystore), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := newTestE2E()
+	e.dataDir = dir
+
+	tests := []struct {
+		name     string
+		nick     string
+		pass     string
+		wantNick string
+		wantPass string
+		wantErr  bool
+	}{
+		{name: "default account", wantNick: "default-nick", wantPass: "default-pass"},
+		{name: "keystore entry", nick: "seller-2", pass: "secret", wantNick: "seller-2", wantPass: "secret"},
+		{name: "keystore entry with default password", nick: "seller-2", wantNick: "seller-2", wantPass: "default-pass"},
+		{name: "unknown nickname", nick: "seller-9", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from, pass, err := e.sellerAuth(test.nick, test.pass)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("sellerAuth() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && (from.Nickname != test.wantNick || pass != test.wantPass) {
+				t.Errorf("sellerAuth() = %s/%s, want %s/%s", from.Nickname, pass, test.wantNick, test.wantPass)
+			}
+		})
+	}
+}
+
+func TestPasswordFile(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "default-nick")
+	t.Setenv("E2E_FROM_PASS", "env-pass")
+	dir := t.TempDir()
+	keystore := `{"addressMap": {}, "nicknameMap": {"seller-2": "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"}}`
+	if err := os.WriteFile(filepath.Join(dir, "keystore.json"), []byte(keystore), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := newTestE2E()
+	e.dataDir = dir
+
+	// the file password replaces the env, for the default account and a nick without a password
+	e.password = "file-pass"
+	if _, pass := e.getAuth(); pass != "file-pass" {
+		t.Errorf("getAuth() password = %q, want file-pass", pass)
+	}
+	if _, pass, err := e.sellerAuth("seller-2", ""); err != nil || pass != "file-pass" {
+		t.Errorf("sellerAuth() password = %q, %v, want file-pass", pass, err)
+	}
+	// a password set for the nick itself still wins
+	if _, pass, err := e.sellerAuth("seller-2", "inline"); err != nil || pass != "inline" {
+		t.Errorf("sellerAuth() password = %q, %v, want inline", pass, err)
+	}
+	if _, pass, err := e.orderAuth("", "inline", "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"); err != nil || pass != "inline" {
+		t.Errorf("orderAuth() password = %q, %v, want inline", pass, err)
+	}
+
+	// another tester in the same process keeps the env password
+	if _, pass := newTestE2E().getAuth(); pass != "env-pass" {
+		t.Errorf("getAuth() without a password file = %q, want env-pass", pass)
+	}
+}
+
+func TestLockOrderCommitteeMismatch(t *testing.T) {
+	e := newTestE2E()
+	if err := e.checkOrderCommittee(unlockedOrder); err != nil {
+		t.Errorf("unexpected error for an order on the configured committee: %v", err)
+	}
+
+	// no ethereum client is set, so getting past the check would panic
+	other := &lib.SellOrder{Id: []byte{0x0a}, Committee: chainId + 1, AmountForSale: 100, RequestedAmount: 100}
+	err := e.lockOrderInternal(other, "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", "", "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e")
+	if err == nil {
+		t.Fatal("expected error for an order on another committee")
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("committee %d", chainId+1)) {
+		t.Errorf("error %q doesn't name the order's committee", err)
+	}
+}
+
+func TestCommitteeRotation(t *testing.T) {
+	// an order created on the second configured committee is locked and closed on that committee,
+	// not on the first one or the chainId default
+	committee := uint64(chainId + 1)
+	order := &lib.SellOrder{Id: []byte{0x0b}, Committee: committee, AmountForSale: 100, RequestedAmount: 100,
+		SellerReceiveAddress: common.HexToAddress(ethAccounts[1]).Bytes()}
+	e := newTestE2E()
+	e.committees = []uint64{chainId, committee}
+	ethClient, backend := newFakeEthClient()
+	e.ethClient = ethClient
+
+	if err := e.lockOrderInternal(order, ethAccounts[0], ethPrivateKeys[0], "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"); err != nil {
+		t.Fatalf("lockOrderInternal: %v", err)
+	}
+	var lock lib.LockOrder
+	if err := json.Unmarshal(backend.sent[0].Data(), &lock); err != nil {
+		t.Fatalf("lock payload: %v", err)
+	}
+	if lock.ChainId != committee {
+		t.Errorf("lock sent for committee %d, want the order's committee %d", lock.ChainId, committee)
+	}
+
+	if _, err := e.closeOrderInternal(order, e.tokenContract, ethPrivateKeys[0], 100, nil); err != nil {
+		t.Fatalf("closeOrderInternal: %v", err)
+	}
+	// the close payload follows the transfer selector, recipient and amount
+	var closeOrder lib.CloseOrder
+	if err := json.Unmarshal(backend.sent[1].Data()[68:], &closeOrder); err != nil {
+		t.Fatalf("close payload: %v", err)
+	}
+	if closeOrder.ChainId != committee {
+		t.Errorf("close sent for committee %d, want the order's committee %d", closeOrder.ChainId, committee)
+	}
+
+	// a close for a committee outside the configured ones is refused before anything is sent
+	other := &lib.SellOrder{Id: []byte{0x0c}, Committee: committee + 1, SellerReceiveAddress: order.SellerReceiveAddress}
+	if _, err := e.closeOrderInternal(other, e.tokenContract, ethPrivateKeys[0], 100, nil); err == nil {
+		t.Error("expected error closing an order on an unconfigured committee")
+	}
+	if len(backend.sent) != 2 {
+		t.Errorf("sent %d transactions, want 2", len(backend.sent))
+	}
+}
+
+func TestBuyerFundsShortfall(t *testing.T) {
+	testCase := &TestCase{BuyerAddress: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", ExpectedUSDCTransfer: 1000000}
+	gas := big.NewInt(500)
+	tests := []struct {
+		name  string
+		token int64
+		eth   int64
+		want  string
+	}{
+		{name: "enough", token: 1000000, eth: 500},
+		{name: "short usdc", token: 999000, eth: 500, want: "short 1000"},
+		{name: "short gas", token: 2000000, eth: 100, want: "short 400"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := buyerFundsShortfall(testCase, big.NewInt(test.token), big.NewInt(test.eth), gas)
+			if test.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), test.want) || !strings.Contains(err.Error(), testCase.BuyerAddress) {
+				t.Errorf("error %q doesn't name the buyer and %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatETHBalance(t *testing.T) {
+	e := newTestE2E()
+	tests := []struct {
+		wei  string
+		want string
+	}{
+		{wei: "0", want: "0.000000000000000000 ETH"},
+		{wei: "1", want: "0.000000000000000001 ETH"},
+		{wei: "1500000000000000000", want: "1.500000000000000000 ETH"},
+		{wei: "10000000000000000000000", want: "10000.000000000000000000 ETH"},
+	}
+	for _, test := range tests {
+		wei, _ := new(big.Int).SetString(test.wei, 10)
+		if got := e.formatETHBalance(wei); got != test.want {
+			t.Errorf("formatETHBalance(%s) = %q, want %q", test.wei, got, test.want)
+		}
+	}
+}
+
+func TestOrdersToDelete(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "tester")
+	t.Setenv("E2E_FROM_PASS", "secret")
+	dir := t.TempDir()
+	keystore := `{"addressMap": {}, "nicknameMap": {"tester": "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e",
+		"seller-2": "b1fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e", "other": "c2fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"}}`
+	if err := os.WriteFile(filepath.Join(dir, "keystore.json"), []byte(keystore), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// paying a test account doesn't make an order ours to delete, only creating it does
+	paysTestAccount := &lib.SellOrder{Id: []byte{0x11}, Committee: chainId,
+		SellerReceiveAddress: common.HexToAddress(ethAccounts[1]).Bytes()}
+	createdByTester := &lib.SellOrder{Id: []byte{0x12}, Committee: chainId,
+		SellersSendAddress: common.FromHex("a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e")}
+	createdBySeller := &lib.SellOrder{Id: []byte{0x13}, Committee: chainId,
+		SellersSendAddress: common.FromHex("b1fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e")}
+	createdByOther := &lib.SellOrder{Id: []byte{0x14}, Committee: chainId,
+		SellersSendAddress: common.FromHex("c2fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e")}
+	foreign := &lib.SellOrder{Id: []byte{0x15}, Committee: chainId,
+		SellerReceiveAddress: common.FromHex("1111111111111111111111111111111111111111"),
+		SellersSendAddress:   common.FromHex("2222222222222222222222222222222222222222")}
+
+	tests := []struct {
+		name        string
+		mineOnly    bool
+		want        []string // order id and the nick deleting it
+		wantSkipped int
+	}{
+		{name: "mine only", mineOnly: true, want: []string{"12 tester", "13 seller-2"}, wantSkipped: 3},
+		{name: "every keystore account", mineOnly: false, want: []string{"12 tester", "13 seller-2", "14 other"}, wantSkipped: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestE2E(paysTestAccount, createdByTester, createdBySeller, createdByOther, foreign)
+			e.dataDir = dir
+			e.sellerNick, e.sellerPass = "seller-2", "seller-pass"
+			e.deleteMineOnly = test.mineOnly
+			orders, err := e.Orders()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			signers, err := e.deleteSigners()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			toDelete, skipped := e.ordersToDelete(orders, signers)
+			var got []string
+			for _, del := range toDelete {
+				got = append(got, lib.BytesToString(del.order.Id)+" "+del.signer.from.Nickname)
+			}
+			if !reflect.DeepEqual(got, test.want) || skipped != test.wantSkipped {
+				t.Errorf("ordersToDelete() = %v, %d skipped, want %v, %d skipped", got, skipped, test.want, test.wantSkipped)
+			}
+			if toDelete[1].signer.pass != "seller-pass" {
+				t.Errorf("seller order deleted with password %q, want --seller-pass", toDelete[1].signer.pass)
+			}
+		})
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "tester")
+	t.Setenv("E2E_FROM_PASS", "secret")
+
+	down := newTestE2E(unlockedOrder)
+	down.client.(*fakeCanopyClient).ordersErr = lib.NewError(1, "rpc", "connection refused")
+	if _, err := down.findOrderByID("01"); !errors.Is(err, ErrRPCUnavailable) || errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("findOrderByID() with the node down = %v, want ErrRPCUnavailable", err)
+	}
+
+	// the fake never removes deleted orders, so the delete wait times out
+	dir := t.TempDir()
+	keystore := `{"addressMap": {}, "nicknameMap": {"tester": "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"}}`
+	if err := os.WriteFile(filepath.Join(dir, "keystore.json"), []byte(keystore), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stuck := newTestE2E(&lib.SellOrder{Id: []byte{0x01}, Committee: chainId,
+		SellersSendAddress: common.FromHex("a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e")})
+	stuck.dataDir = dir
+	stuck.deleteMineOnly = true
+	stuck.deleteTimeout = 10 * time.Millisecond
+	if err := stuck.deleteAllExistingOrders(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("deleteAllExistingOrders() = %v, want ErrTimeout", err)
+	}
+}
+
+func TestSeedAmounts(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name    string
+		n       int
+		min     uint64
+		max     uint64
+		wantErr bool
+	}{
+		{name: "consecutive", n: 5, min: 1000000},
+		{name: "random", n: 20, min: 1000000, max: 2000000},
+		{name: "random filling the range", n: 3, min: 10, max: 12},
+		{name: "range too small", n: 4, min: 10, max: 12, wantErr: true},
+		{name: "max below min", n: 1, min: 10, max: 5, wantErr: true},
+		{name: "zero min", n: 1, min: 0, wantErr: true},
+		{name: "span beyond int64", n: 5, min: 1, max: math.MaxUint64},
+		{name: "negative count", n: -1, min: 10, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amounts, err := seedAmounts(test.n, test.min, test.max, rng)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("seedAmounts() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if len(amounts) != test.n {
+				t.Fatalf("got %d amounts, want %d", len(amounts), test.n)
+			}
+			seen := make(map[uint64]bool)
+			for _, amount := range amounts {
+				if seen[amount] {
+					t.Errorf("amount %d repeated", amount)
+				}
+				seen[amount] = true
+				max := test.max
+				if max == 0 {
+					max = test.min + uint64(test.n) - 1
+				}
+				if amount < test.min || amount > max {
+					t.Errorf("amount %d outside [%d, %d]", amount, test.min, max)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileEscrow(t *testing.T) {
+	before := &BalanceSnapshot{CNPY: map[string]uint64{"aa": 100, "bb": 0}}
+	after := &BalanceSnapshot{CNPY: map[string]uint64{"aa": 400, "bb": 50}}
+	testCases := []*TestCase{
+		{Committee: 2, CanopyReceiveAddress: "aa", ExpectedCNPYTransfer: 100, Status: StatusVerified},
+		{Committee: 2, CanopyReceiveAddress: "aa", ExpectedCNPYTransfer: 200, Status: StatusClosed},
+		{Committee: 3, CanopyReceiveAddress: "bb", ExpectedCNPYTransfer: 150, Status: StatusVerified},
+		// released nothing: still locked, and a negative test
+		{Committee: 3, CanopyReceiveAddress: "bb", ExpectedCNPYTransfer: 999, Status: StatusLocked},
+		{Committee: 2, CanopyReceiveAddress: "aa", ExpectedCNPYTransfer: 999, Status: StatusVerified, CloseRecipient: "0x01"},
+	}
+
+	report := reconcileEscrow(testCases, before, after)
+	wantCommittees := []CommitteeEscrow{{Committee: 2, Orders: 2, Expected: 300}, {Committee: 3, Orders: 1, Expected: 150}}
+	if !reflect.DeepEqual(report.Committees, wantCommittees) {
+		t.Errorf("committees = %+v, want %+v", report.Committees, wantCommittees)
+	}
+	wantAccounts := []AccountEscrow{{Address: "aa", Expected: 300, Actual: 300}, {Address: "bb", Expected: 150, Actual: 50}}
+	if !reflect.DeepEqual(report.Accounts, wantAccounts) {
+		t.Errorf("accounts = %+v, want %+v", report.Accounts, wantAccounts)
+	}
+	if stuck := report.Stuck(); stuck != 100 {
+		t.Errorf("stuck = %d, want 100", stuck)
+	}
+}
+
+func TestSendingAccounts(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "tester")
+	dir := t.TempDir()
+	keystore := `{"addressMap": {}, "nicknameMap": {"tester": "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e",
+		"seller-2": "b1fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e", "other": "c2fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"}}`
+	if err := os.WriteFile(filepath.Join(dir, "keystore.json"), []byte(keystore), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := newTestE2E()
+	e.dataDir = dir
+	e.testResults.testCases["nick"] = &TestCase{SellerNick: "seller-2"}
+	e.testResults.testCases["send"] = &TestCase{CanopySendAddress: "0xD3FD5A5DCB6DA1BBAC3AD7FE6AB1C9B06E5F6D5E"}
+
+	// the order escrow and fees come out of every account orders are created from, and only those
+	want := map[string]bool{
+		"a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e": true,
+		"b1fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e": true,
+		"d3fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e": true,
+	}
+	if got := e.sendingAccounts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sending accounts = %v, want %v", got, want)
+	}
+}
+
+func TestSubmitDeletes(t *testing.T) {
+	var orders []*lib.SellOrder
+	var deletes []orderDelete
+	creators := make(map[string]string) // order id -> nick that created it
+	for i := 0; i < 10; i++ {
+		order := &lib.SellOrder{Id: []byte{byte(0x20 + i)}, Committee: chainId}
+		nick := fmt.Sprintf("nick-%d", i%2)
+		orders = append(orders, order)
+		deletes = append(deletes, orderDelete{order: order, signer: orderSigner{from: rpc.AddrOrNickname{Nickname: nick}, pass: "secret"}})
+		creators[lib.BytesToString(order.Id)] = nick
+	}
+	for _, workers := range []int{0, 1, 3, 20} {
+		e := newTestE2E(orders...)
+		e.deleteWorkers = workers
+		pending, sent := e.submitDeletes(deletes)
+		client := e.client.(*fakeCanopyClient)
+		for i, orderID := range client.deleted {
+			if client.deleters[i].Nickname != creators[orderID] {
+				t.Errorf("workers %d: order %s deleted by %s, want its creator %s", workers, orderID, client.deleters[i].Nickname, creators[orderID])
+			}
+		}
+
+		if len(pending) != len(orders) || sent != len(orders) || len(client.deleted) != len(orders) {
+			t.Errorf("workers %d: %d pending, %d sent, %d deleted, want %d each", workers, len(pending), sent, len(client.deleted), len(orders))
+		}
+		limit := workers
+		if limit < 1 {
+			limit = 1
+		}
+		if client.maxDeleting > limit {
+			t.Errorf("workers %d: %d deletes in flight at once", workers, client.maxDeleting)
+		}
+	}
+}
+
+func TestWaitForTestCompletionStalled(t *testing.T) {
+	e := newTestE2E()
+	done := &TestCase{Name: "Done"}
+	locked := &TestCase{Name: "Locked", Status: StatusLocked}
+	pending := &TestCase{Name: "Pending"}
+	for _, testCase := range []*TestCase{done, locked, pending} {
+		e.testResults.testCases[testCase.Name] = testCase
+		e.testResults.total++
+	}
+	e.passTestCase(done)
+
+	unfinished, progress := e.unfinishedTests()
+	if len(unfinished) != 2 || unfinished[0].testCase != locked || unfinished[1].state != "pending" {
+		t.Fatalf("unfinished = %+v", unfinished)
+	}
+	if progress != "1/3,Locked=locked,Pending=pending" {
+		t.Errorf("progress = %q", progress)
+	}
+
+	// with no progress the first stall interval gives up on the unfinished tests
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	e.suiteCtx, e.suiteCancel = ctx, cancel
+	e.stallInterval = time.Nanosecond
+	e.maxRetries = 1
+	e.waitForTestCompletion()
+
+	// the stalled test case returning late doesn't count again
+	e.passTestCase(locked)
+	e.failTestCase(pending, errors.New("late"))
+	if e.testResults.passed != 1 || e.testResults.failed != 2 {
+		t.Errorf("passed %d, failed %d; want 1 and 2", e.testResults.passed, e.testResults.failed)
+	}
+	if err := e.suiteErr(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("suiteErr() = %v, want the suite cancelled with the stall", err)
+	}
+	if done.Error != nil {
+		t.Errorf("passed test failed: %v", done.Error)
+	}
+	if locked.Error == nil || !strings.Contains(locked.Error.Error(), "stalled in state locked") || !errors.Is(locked.Error, ErrTimeout) {
+		t.Errorf("locked test error = %v, want stalled in state locked", locked.Error)
+	}
+	if pending.Error == nil || !strings.Contains(pending.Error.Error(), "stalled in state pending") {
+		t.Errorf("pending test error = %v, want stalled in state pending", pending.Error)
+	}
+}
+
+func TestCreateSellOrderSendAddress(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "nick-0")
+	t.Setenv("E2E_FROM_PASS", "test")
+	const (
+		seller  = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+		send    = "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"
+		receive = "851e90eaef1fa27debaee2c2591503bdeec1d123"
+		token   = "0x5FbDB2315678afecb367f032d93F642f64180aa3"
+	)
+	tests := []struct {
+		name    string
+		nick    string
+		send    string
+		receive string
+		want    rpc.AddrOrNickname
+		wantErr string
+	}{
+		{name: "seller nick account", receive: receive, want: rpc.AddrOrNickname{Nickname: "nick-0"}},
+		{name: "separate send account", send: "0x" + strings.ToUpper(send), receive: receive, want: rpc.AddrOrNickname{Address: send}},
+		{name: "invalid send address", send: "0xabcd", receive: receive, wantErr: "canopy send address"},
+		{name: "invalid receive address", send: send, receive: "0xabcd", wantErr: "canopy receive address"},
+		{name: "nick and send address", nick: "seller-2", send: send, receive: receive, wantErr: "both set"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestE2E()
+			client := e.client.(*fakeCanopyClient)
+			_, err := e.createSellOrder(chainId, 100, 100, seller, test.send, test.receive, token, test.nick, "")
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("createSellOrder() = %v, want an error containing %q", err, test.wantErr)
+				}
+				if len(client.creators) != 0 {
+					t.Errorf("order created despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(client.creators) != 1 || client.creators[0] != test.want {
+				t.Errorf("created by %+v, want %+v", client.creators, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyKeystorePassword(t *testing.T) {
+	dir := t.TempDir()
+	keystore, err := crypto.NewKeystoreFromFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.NewBLS12381PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keystore.ImportRaw(key.Bytes(), "test", crypto.ImportRawOpts{Nickname: "nick-0"}); err != nil {
+		t.Fatal(err)
+	}
+	keystore.NicknameMap["dangling"] = strings.Repeat("00", 20)
+	if err := keystore.SaveToFile(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		envNick string
+		nick    string
+		pass    string
+		envPass string
+		wantErr string
+	}{
+		{name: "env credentials", envNick: "nick-0", envPass: "test"},
+		{name: "seller nick", nick: "nick-0", pass: "test"},
+		{name: "seller nick with env password", nick: "nick-0", envPass: "test"},
+		{name: "wrong password", nick: "nick-0", pass: "nope", wantErr: "wrong password for nick-0"},
+		{name: "unknown nick", nick: "nick-9", pass: "test", wantErr: `nickname "nick-9" is not in the keystore`},
+		{name: "nick without key", nick: "dangling", pass: "test", wantErr: "has no key"},
+		{name: "no nick", wantErr: "no seller nickname"},
+		{name: "no password", envNick: "nick-0", wantErr: "no password for nick-0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("E2E_FROM_NICK", test.envNick)
+			t.Setenv("E2E_FROM_PASS", test.envPass)
+			e := newTestE2E()
+			e.dataDir = dir
+			e.sellerNick, e.sellerPass = test.nick, test.pass
+			_, err := e.verifyKeystorePassword()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("verifyKeystorePassword() = %v, want an error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
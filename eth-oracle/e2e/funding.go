@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// fundFee is the fee paid for each funding send transaction
+	fundFee = uint64(10000)
+	// fundTimeout is how long funded accounts have to show their new balance
+	fundTimeout = 60 * time.Second
+)
+
+// fundAccounts tops up the CNPY balance of every canopy address the test cases use to at least
+// amount, sending from the E2E_FROM_NICK account, and waits until the balances are confirmed
+func (e *EthOracleE2E) fundAccounts(testCases []*TestCase, amount uint64) error {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, testCase := range testCases {
+		for _, address := range []string{testCase.CanopyReceiveAddress, testCase.CanopySendAddress} {
+			if address == "" || seen[address] {
+				continue
+			}
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+
+	from, pass := e.getAuth()
+
+	var pending []string
+	for _, address := range addresses {
+		balance, err := e.getCNPYBalance(address)
+		if err != nil {
+			return err
+		}
+		if balance >= amount {
+			e.logger.Infof("Account %s already holds %d CNPY", address, balance)
+			continue
+		}
+
+		hash, _, sendErr := e.client.TxSend(from, address, amount-balance, pass, true, fundFee)
+		if sendErr != nil {
+			return fmt.Errorf("failed to fund %s: %w", address, sendErr)
+		}
+		if hash != nil {
+			e.logger.Infof("Funding %s with %d CNPY in tx %s", address, amount-balance, *hash)
+		}
+		pending = append(pending, address)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Wait for every funded account to reach the amount
+	timeout := time.After(fundTimeout)
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		var stillPending []string
+		for _, address := range pending {
+			balance, err := e.getCNPYBalance(address)
+			if err != nil || balance < amount {
+				stillPending = append(stillPending, address)
+			}
+		}
+		pending = stillPending
+		if len(pending) == 0 {
+			e.logger.Infof("Funded %d accounts with at least %d CNPY", len(addresses), amount)
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return fmt.Errorf("%w waiting for %d funded accounts to reach %d CNPY: %v", ErrTimeout, len(pending), amount, pending)
+		case <-e.suiteDone():
+			return e.suiteErr()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/canopy-network/canopy/lib/crypto"
+)
+
+// sellerCredentials returns the keystore nickname and password orders are created with, resolved
+// the way sellerAuth resolves them but without requiring them to be set
+func (e *EthOracleE2E) sellerCredentials() (nick, pass string) {
+	nick, pass = e.sellerNick, e.sellerPass
+	if nick == "" {
+		nick, pass = os.Getenv("E2E_FROM_NICK"), ""
+	}
+	if pass == "" {
+		pass = e.envPassword()
+	}
+	return nick, pass
+}
+
+// verifyKeystorePassword checks that the seller's password decrypts its entry in the keystore
+// under the data dir, so a wrong password fails before the run instead of at the first order
+func (e *EthOracleE2E) verifyKeystorePassword() (string, error) {
+	nick, pass := e.sellerCredentials()
+	if nick == "" {
+		return "", fmt.Errorf("no seller nickname: set --seller-nick or E2E_FROM_NICK")
+	}
+	if pass == "" {
+		return nick, fmt.Errorf("no password for %s: set --seller-pass, --password-file or E2E_FROM_PASS", nick)
+	}
+
+	keystore, err := crypto.NewKeystoreFromFile(e.dataDir)
+	if err != nil {
+		return nick, fmt.Errorf("failed to load keystore: %w", err)
+	}
+	address, ok := keystore.NicknameMap[nick]
+	if !ok {
+		return nick, fmt.Errorf("nickname %q is not in the keystore in %s", nick, e.dataDir)
+	}
+	if _, ok := keystore.AddressMap[address]; !ok {
+		return nick, fmt.Errorf("nickname %q points to %s, which has no key in the keystore in %s", nick, address, e.dataDir)
+	}
+	if _, err := keystore.GetKeyGroup(pass, crypto.GetKeyGroupOpts{Nickname: nick}); err != nil {
+		return nick, fmt.Errorf("wrong password for %s", nick)
+	}
+	return nick, nil
+}
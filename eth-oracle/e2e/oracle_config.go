@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"canopy-testing/eth-oracle/orderflow"
+)
+
+// oracleConfigFile is the sidecar file next to the canopy config.json holding the eth side of the
+// E2E setup
+const oracleConfigFile = "eth_oracle_e2e.json"
+
+// OracleConfig is the eth side of the E2E setup. Every field set here takes precedence over the
+// ETH_RPC_URL and USDC_CONTRACT environment variables and the built-in Anvil accounts
+type OracleConfig struct {
+	EthRPCURL    string       `json:"ethRpcUrl"`
+	USDCContract string       `json:"usdcContract"`
+	Accounts     []EthAccount `json:"accounts"`
+}
+
+// EthAccount is an eth test account and its private key
+type EthAccount struct {
+	Address    string `json:"address"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// loadOracleConfig reads the oracle config at path. A missing file yields an empty config, so
+// the environment and built-in defaults apply
+func loadOracleConfig(path string) (*OracleConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &OracleConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oracle config %s: %w", path, err)
+	}
+
+	config := new(OracleConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse oracle config %s: %w", path, err)
+	}
+	if len(config.Accounts) > len(ethAccounts) {
+		return nil, fmt.Errorf("oracle config %s has %d accounts, at most %d are supported", path, len(config.Accounts), len(ethAccounts))
+	}
+	for i, account := range config.Accounts {
+		if _, err := orderflow.DecodeAddress(account.Address); err != nil {
+			return nil, fmt.Errorf("oracle config %s account %d: %w", path, i, err)
+		}
+		if account.PrivateKey == "" {
+			return nil, fmt.Errorf("oracle config %s account %d has no private key", path, i)
+		}
+	}
+	return config, nil
+}
+
+// defaultOracleConfigPath returns the oracle config path in the canopy data dir
+func defaultOracleConfigPath(dataDir string) string {
+	return filepath.Join(dataDir, oracleConfigFile)
+}
+
+// applyAccounts replaces the built-in Anvil accounts at the indices the config lists. The test
+// cases index the accounts directly, so a config listing fewer keeps the built-in ones after it
+func (c *OracleConfig) applyAccounts() {
+	for i, account := range c.Accounts {
+		ethAccounts[i] = account.Address
+		ethPrivateKeys[i] = account.PrivateKey
+	}
+}
+
+// ethRPCURL returns the configured eth rpc url, falling back to ETH_RPC_URL
+func (c *OracleConfig) ethRPCURL() string {
+	if c.EthRPCURL != "" {
+		return c.EthRPCURL
+	}
+	return os.Getenv("ETH_RPC_URL")
+}
+
+// usdcContract returns the configured USDC contract, falling back to USDC_CONTRACT
+func (c *OracleConfig) usdcContract() string {
+	if c.USDCContract != "" {
+		return c.USDCContract
+	}
+	return os.Getenv("USDC_CONTRACT")
+}
@@ -0,0 +1,84 @@
+//go:build live
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/canopy-network/canopy/lib"
+)
+
+// BenchmarkOrderFlow times the create -> lock -> close -> completion flow of one order per
+// iteration against a live chain, the same one --run-tests uses. Besides ns/op it reports the
+// mean time of each phase, so a regression in the oracle's settlement time shows where it is.
+// Run it with:
+//
+//	go test -tags live -run '^$' -bench OrderFlow -benchtime 3x ./eth-oracle/e2e
+func BenchmarkOrderFlow(b *testing.B) {
+	e := newLiveE2E(b)
+
+	phases := []struct {
+		name string
+		run  func(*TestCase) error
+	}{
+		{name: "create", run: e.createTestOrder},
+		{name: "lock", run: e.waitAndLockOrder},
+		{name: "close", run: e.closeTestOrder},
+		{name: "complete", run: e.waitForOrderCompletion},
+	}
+	totals := make([]time.Duration, len(phases))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		testCase := e.generateTestCases()[0]
+		testCase.Name = fmt.Sprintf("Bench_%d", i)
+		// the complete phase waits for the receive account to grow past these, as the suite does
+		b.StopTimer()
+		e.recordInitialBalances(testCase)
+		b.StartTimer()
+		for p, phase := range phases {
+			start := time.Now()
+			if err := phase.run(testCase); err != nil {
+				b.Fatalf("%s %s: %v", testCase.Name, phase.name, err)
+			}
+			totals[p] += time.Since(start)
+		}
+	}
+	b.StopTimer()
+
+	for p, phase := range phases {
+		mean := totals[p] / time.Duration(b.N)
+		b.ReportMetric(float64(mean.Milliseconds()), phase.name+"-ms/op")
+		b.Logf("%-8s %s", phase.name, mean)
+	}
+}
+
+// newLiveE2E sets up the tester the way main does, from the default data dir
+func newLiveE2E(b *testing.B) *EthOracleE2E {
+	b.Helper()
+	if err := loadCanopyAccounts(); err != nil {
+		b.Skipf("no canopy accounts: %v", err)
+	}
+
+	dataDir := lib.DefaultDataDirPath()
+	oracleConfig, err := loadOracleConfig(defaultOracleConfigPath(dataDir))
+	if err != nil {
+		b.Fatalf("loading oracle config: %v", err)
+	}
+	oracleConfig.applyAccounts()
+
+	config, err := lib.NewConfigFromFile(filepath.Join(dataDir, lib.ConfigFilePath))
+	if err != nil {
+		b.Skipf("no canopy config in %s: %v", dataDir, err)
+	}
+	config.DataDirPath = dataDir
+
+	e, err := NewEthOracleE2E(config, dataDir, oracleConfig)
+	if err != nil {
+		b.Skipf("no live chain: %v", err)
+	}
+	return e
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"canopy-testing/eth-oracle/orderflow"
+	"github.com/canopy-network/canopy/lib"
+)
+
+// OrderSnapshot is the order book at a point in time, keyed by order ID
+type OrderSnapshot map[string]*lib.SellOrder
+
+// OrderDiff is the change of the order book between two snapshots
+type OrderDiff struct {
+	Added    []string // order IDs in the later snapshot only
+	Removed  []string // order IDs in the earlier snapshot only
+	Locked   []string // order IDs that became locked
+	Unlocked []string // order IDs that became unlocked
+}
+
+// Empty reports whether the order book didn't change
+func (d OrderDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Locked) == 0 && len(d.Unlocked) == 0
+}
+
+// snapshotOrders records every order in the queried order books
+func (e *EthOracleE2E) snapshotOrders() (OrderSnapshot, error) {
+	orders, err := e.Orders()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(OrderSnapshot)
+	for _, book := range orders.OrderBooks {
+		for _, order := range book.Orders {
+			snapshot[lib.BytesToString(order.Id)] = order
+		}
+	}
+	return snapshot, nil
+}
+
+// parseOrderIDPrefix normalizes an --order-id-prefix to the lowercase hex order IDs are printed
+// in, accepting an optional 0x
+func parseOrderIDPrefix(prefix string) (string, error) {
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(prefix, "0x"), "0X"))
+	for _, c := range prefix {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", fmt.Errorf("%q is not hex", prefix)
+		}
+	}
+	return prefix, nil
+}
+
+// matchesOrderIDPrefix reports whether order's ID starts with the configured order ID prefix
+func (e *EthOracleE2E) matchesOrderIDPrefix(order *lib.SellOrder) bool {
+	return strings.HasPrefix(lib.BytesToString(order.Id), e.orderIDPrefix)
+}
+
+// watchedOrders snapshots the orders --watch follows, the ones matching the order ID prefix
+func (e *EthOracleE2E) watchedOrders() (OrderSnapshot, error) {
+	snapshot, err := e.snapshotOrders()
+	if err != nil {
+		return nil, err
+	}
+	for id, order := range snapshot {
+		if !e.matchesOrderIDPrefix(order) {
+			delete(snapshot, id)
+		}
+	}
+	return snapshot, nil
+}
+
+// findDuplicateOrder returns an unlocked order on committee that a create with the same amounts,
+// seller address and token contract would duplicate, or nil when there is none
+func (e *EthOracleE2E) findDuplicateOrder(committee, sellAmount, receiveAmount uint64, sellerAddress, tokenContract string) (*lib.SellOrder, error) {
+	seller, err := orderflow.DecodeAddress(sellerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seller address: %w", err)
+	}
+	contract, err := lib.NewHexBytesFromString(strings.TrimPrefix(tokenContract, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token contract: %w", err)
+	}
+
+	orders, err := e.Orders()
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range orders.OrderBooks {
+		for _, order := range book.Orders {
+			if order.Committee == committee && !isLocked(order) &&
+				order.AmountForSale == sellAmount && order.RequestedAmount == receiveAmount &&
+				bytes.Equal(order.SellerReceiveAddress, seller) && bytes.Equal(order.Data, contract) {
+				return order, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// diffOrders reports the orders added, removed, locked and unlocked between two snapshots
+func diffOrders(before, after OrderSnapshot) OrderDiff {
+	var diff OrderDiff
+	for id, order := range after {
+		previous, ok := before[id]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, id)
+		case !isLocked(previous) && isLocked(order):
+			diff.Locked = append(diff.Locked, id)
+		case isLocked(previous) && !isLocked(order):
+			diff.Unlocked = append(diff.Unlocked, id)
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Locked)
+	sort.Strings(diff.Unlocked)
+	return diff
+}
+
+// traceOrders prints how the order book changed since the previous snapshot when running
+// verbose, and returns the new snapshot. It does nothing otherwise
+func (e *EthOracleE2E) traceOrders(testCase *TestCase, step string, previous OrderSnapshot) OrderSnapshot {
+	if !e.verbose {
+		return nil
+	}
+	current, err := e.snapshotOrders()
+	if err != nil {
+		e.logger.Warnf("Test %s - Failed to snapshot orders after %s: %v", testCase.Name, step, err)
+		return previous
+	}
+	if previous == nil {
+		return current
+	}
+
+	diff := diffOrders(previous, current)
+	if diff.Empty() {
+		fmt.Printf("Test %s - order book unchanged after %s\n", testCase.Name, step)
+		return current
+	}
+	fmt.Printf("Test %s - order book after %s:\n", testCase.Name, step)
+	printOrderDiff(diff, "  ")
+	return current
+}
+
+// printOrderDiff prints one line per changed order
+func printOrderDiff(diff OrderDiff, prefix string) {
+	for _, id := range diff.Added {
+		fmt.Printf("%s+ %s added\n", prefix, id)
+	}
+	for _, id := range diff.Locked {
+		fmt.Printf("%s~ %s locked\n", prefix, id)
+	}
+	for _, id := range diff.Unlocked {
+		fmt.Printf("%s~ %s unlocked\n", prefix, id)
+	}
+	for _, id := range diff.Removed {
+		fmt.Printf("%s- %s removed\n", prefix, id)
+	}
+}
+
+// WatchOrders polls the order books every interval and prints their changes until ctx is done
+func (e *EthOracleE2E) WatchOrders(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+	previous, err := e.watchedOrders()
+	if err != nil {
+		return fmt.Errorf("failed to query orders: %w", err)
+	}
+	fmt.Printf("Watching %d orders on committees %v every %s (Ctrl-C to stop)\n", len(previous), e.committees, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			current, err := e.watchedOrders()
+			if err != nil {
+				e.logger.Warnf("Failed to query orders: %v", err)
+				continue
+			}
+			if diff := diffOrders(previous, current); !diff.Empty() {
+				printOrderDiff(diff, time.Now().Format("15:04:05")+" ")
+			}
+			previous = current
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/canopy-network/canopy/lib"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultEthRedials is how many times a dropped eth connection is re-dialed before a call fails
+	defaultEthRedials = 3
+	// ethRedialBackoff is the wait before the first re-dial, doubled for every further one
+	ethRedialBackoff = 1 * time.Second
+)
+
+// ethBackend is the part of *ethclient.Client the E2E tool calls
+type ethBackend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	NetworkID(ctx context.Context) (*big.Int, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	Close()
+}
+
+// reconnectingEthClient is an eth client that re-dials its node when a call fails on a dropped
+// connection, so a brief node hiccup doesn't fail every eth call for the rest of a run
+type reconnectingEthClient struct {
+	url    string
+	logger lib.LoggerI
+	// dial connects to url; ethclient.Dial outside tests
+	dial func(url string) (ethBackend, error)
+	// redials is how many times a call re-dials before surfacing a connection error
+	redials int
+	// backoff is the wait before the first re-dial, doubled for every further one
+	backoff time.Duration
+
+	mutex  sync.Mutex
+	client ethBackend
+}
+
+// newReconnectingEthClient wraps a connected client to url
+func newReconnectingEthClient(url string, client ethBackend, logger lib.LoggerI) *reconnectingEthClient {
+	return &reconnectingEthClient{
+		url:    url,
+		logger: logger,
+		dial: func(url string) (ethBackend, error) {
+			return ethclient.Dial(url)
+		},
+		redials: defaultEthRedials,
+		backoff: ethRedialBackoff,
+		client:  client,
+	}
+}
+
+// current returns the client of the latest connection
+func (c *reconnectingEthClient) current() ethBackend {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.client
+}
+
+// redial replaces the connection with a new one, closing the old
+func (c *reconnectingEthClient) redial() error {
+	client, err := c.dial(c.url)
+	if err != nil {
+		return fmt.Errorf("failed to redial eth node at %s: %w", c.url, err)
+	}
+	c.mutex.Lock()
+	old := c.client
+	c.client = client
+	c.mutex.Unlock()
+	old.Close()
+	return nil
+}
+
+// retry runs call, re-dialing with backoff and running it again while it fails on a dropped
+// connection, up to c.redials times. It returns the last error and reports whether call ran on a
+// re-dialed connection
+func (c *reconnectingEthClient) retry(ctx context.Context, call func(client ethBackend) error) (bool, error) {
+	err := call(c.current())
+	redialed := false
+	for attempt := 0; attempt < c.redials && isConnectionError(err); attempt++ {
+		wait := c.backoff << attempt
+		c.logger.Warnf("eth connection lost (%v), redialing %s in %s (%d/%d)", err, c.url, wait, attempt+1, c.redials)
+		select {
+		case <-ctx.Done():
+			return redialed, err
+		case <-time.After(wait):
+		}
+		// a failed websocket dial is itself a connection error, so the loop keeps backing off
+		if dialErr := c.redial(); dialErr != nil {
+			err = dialErr
+			continue
+		}
+		redialed = true
+		err = call(c.current())
+	}
+	return redialed, err
+}
+
+// isConnectionError reports whether err comes from a dropped or refused connection rather than
+// from the node rejecting the request. Cancelled and expired contexts aren't connection errors
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, rpc.ErrClientQuit) || errors.As(err, &netErr) {
+		return true
+	}
+	// the websocket transport reports closed connections as plain strings
+	message := err.Error()
+	return strings.Contains(message, "websocket: close") || strings.Contains(message, "use of closed network connection")
+}
+
+func (c *reconnectingEthClient) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	_, err = c.retry(ctx, func(client ethBackend) (err error) {
+		nonce, err = client.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (c *reconnectingEthClient) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	_, err = c.retry(ctx, func(client ethBackend) (err error) {
+		price, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+func (c *reconnectingEthClient) NetworkID(ctx context.Context) (id *big.Int, err error) {
+	_, err = c.retry(ctx, func(client ethBackend) (err error) {
+		id, err = client.NetworkID(ctx)
+		return err
+	})
+	return id, err
+}
+
+// SendTransaction sends a signed transaction. A connection can drop after the node accepted the
+// transaction, so a resend the node reports as already known counts as sent
+func (c *reconnectingEthClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	redialed, err := c.retry(ctx, func(client ethBackend) error {
+		return client.SendTransaction(ctx, tx)
+	})
+	if redialed && err != nil && strings.Contains(err.Error(), "already known") {
+		return nil
+	}
+	return err
+}
+
+func (c *reconnectingEthClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
+	_, err = c.retry(ctx, func(client ethBackend) (err error) {
+		receipt, err = client.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+func (c *reconnectingEthClient) BlockNumber(ctx context.Context) (number uint64, err error) {
+	_, err = c.retry(ctx, func(client ethBackend) (err error) {
+		number, err = client.BlockNumber(ctx)
+		return err
+	})
+	return number, err
+}
+
+func (c *reconnectingEthClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (result []byte, err error) {
+	_, err = c.retry(ctx, func(client ethBackend) (err error) {
+		result, err = client.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+	return result, err
+}
+
+func (c *reconnectingEthClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	_, err = c.retry(ctx, func(client ethBackend) (err error) {
+		balance, err = client.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+// Close closes the current connection
+func (c *reconnectingEthClient) Close() {
+	c.current().Close()
+}
+
+var _ ethBackend = (*ethclient.Client)(nil)
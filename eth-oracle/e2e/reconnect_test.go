@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/canopy-network/canopy/lib"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeEthBackend answers BlockNumber and SendTransaction with the queued errors, then succeeds,
+// recording the transactions it was sent
+type fakeEthBackend struct {
+	errs   *[]error
+	sent   []*types.Transaction
+	closed bool
+}
+
+// newFakeEthClient wraps a fake eth node that never drops its connection
+func newFakeEthClient() (*reconnectingEthClient, *fakeEthBackend) {
+	backend := &fakeEthBackend{errs: &[]error{}}
+	return newReconnectingEthClient("ws://anvil:8545", backend, lib.NewDefaultLogger()), backend
+}
+
+func (f *fakeEthBackend) next() error {
+	if len(*f.errs) == 0 {
+		return nil
+	}
+	err := (*f.errs)[0]
+	*f.errs = (*f.errs)[1:]
+	return err
+}
+
+func (f *fakeEthBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (f *fakeEthBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (f *fakeEthBackend) NetworkID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(31337), nil
+}
+
+func (f *fakeEthBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := f.next(); err != nil {
+		return err
+	}
+	f.sent = append(f.sent, tx)
+	return nil
+}
+
+func (f *fakeEthBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, nil
+}
+
+func (f *fakeEthBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return 7, f.next()
+}
+
+func (f *fakeEthBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeEthBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (f *fakeEthBackend) Close() {
+	f.closed = true
+}
+
+func TestReconnectingEthClient(t *testing.T) {
+	rejected := errors.New("execution reverted")
+	tests := []struct {
+		name      string
+		errs      []error
+		redials   int
+		wantErr   error
+		wantDials int
+	}{
+		{name: "healthy connection", wantDials: 0},
+		{name: "redials through drops", errs: []error{io.EOF, io.ErrUnexpectedEOF}, redials: 3, wantDials: 2},
+		{name: "gives up after redials", errs: []error{io.EOF, io.EOF, io.EOF}, redials: 2, wantErr: io.EOF, wantDials: 2},
+		{name: "no redials", errs: []error{io.EOF}, redials: 0, wantErr: io.EOF, wantDials: 0},
+		{name: "node errors aren't retried", errs: []error{rejected}, redials: 3, wantErr: rejected, wantDials: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := append([]error(nil), test.errs...)
+			first := &fakeEthBackend{errs: &errs}
+			client := newReconnectingEthClient("ws://anvil:8545", first, lib.NewDefaultLogger())
+			client.redials = test.redials
+			client.backoff = 0
+			dials := 0
+			client.dial = func(string) (ethBackend, error) {
+				dials++
+				return &fakeEthBackend{errs: &errs}, nil
+			}
+
+			number, err := client.BlockNumber(context.Background())
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("BlockNumber() error = %v, want %v", err, test.wantErr)
+			}
+			if err == nil && number != 7 {
+				t.Errorf("BlockNumber() = %d, want 7", number)
+			}
+			if dials != test.wantDials {
+				t.Errorf("dials = %d, want %d", dials, test.wantDials)
+			}
+			if first.closed != (test.wantDials > 0) {
+				t.Errorf("first connection closed = %v after %d dials", first.closed, dials)
+			}
+		})
+	}
+}
+
+func TestReconnectingEthClientResend(t *testing.T) {
+	// the node accepted the transaction before the connection dropped
+	errs := []error{io.EOF, errors.New("already known")}
+	client := newReconnectingEthClient("ws://anvil:8545", &fakeEthBackend{errs: &errs}, lib.NewDefaultLogger())
+	client.backoff = 0
+	client.dial = func(string) (ethBackend, error) { return &fakeEthBackend{errs: &errs}, nil }
+	if err := client.SendTransaction(context.Background(), nil); err != nil {
+		t.Errorf("SendTransaction() = %v, want the resend counted as sent", err)
+	}
+
+	// without a redial, already known is the node's answer to a duplicate send
+	errs = []error{errors.New("already known")}
+	if err := client.SendTransaction(context.Background(), nil); err == nil {
+		t.Errorf("SendTransaction() = nil, want the duplicate send reported")
+	}
+}
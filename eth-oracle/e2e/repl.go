@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/canopy-network/canopy/lib"
+)
+
+// replHelp lists the commands of --repl
+const replHelp = `Commands:
+  create [amount]                   Create a sell order (default: --amount)
+  lock <order-id|first>             Lock an order as the buyer
+  close <order-id|first> [amount]   Close a locked order, transferring amount (default: --amount)
+  delete <order-id>                 Delete an unlocked order
+  list                              List the orders on the configured committees
+  balances                          Print the eth and canopy account balances
+  help                              Print this help
+  quit                              Leave the REPL`
+
+// replDefaults are the flag values REPL commands fall back to
+type replDefaults struct {
+	amount            uint64
+	sellerAddress     string
+	canopySendAddress string
+	canopyAddress     string
+	buyerAddress      string
+	buyerKey          string
+}
+
+// RunREPL reads commands from in until quit or EOF and runs them on this tester, so every command
+// reuses its eth and canopy connections. A failed command is reported and the REPL carries on
+func (e *EthOracleE2E) RunREPL(in io.Reader, out io.Writer, defaults replDefaults) error {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprintln(out, "Type help for the commands, quit to leave")
+	for {
+		fmt.Fprint(out, "e2e> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := e.runREPLCommand(out, fields[0], fields[1:], defaults); err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		}
+	}
+}
+
+// runREPLCommand runs a single REPL command with its arguments
+func (e *EthOracleE2E) runREPLCommand(out io.Writer, command string, args []string, defaults replDefaults) error {
+	switch command {
+	case "create":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: create [amount]")
+		}
+		amount, err := replAmount(args, 0, defaults.amount)
+		if err != nil {
+			return err
+		}
+		orderID, err := e.createSellOrder(e.committees[0], amount, amount, defaults.sellerAddress, defaults.canopySendAddress,
+			defaults.canopyAddress, e.tokenContract, e.sellerNick, e.sellerPass)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Order %s created: %d CNPY -> %d USDC\n", orderID, amount, amount)
+	case "lock":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: lock <order-id|first>")
+		}
+		if args[0] == "first" || args[0] == "auto" {
+			if err := e.LockFirstOrder(defaults.buyerAddress, defaults.buyerKey, defaults.canopyAddress); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, "First available order locked")
+			return nil
+		} else if err := e.LockOrder(args[0], defaults.buyerAddress, defaults.buyerKey, defaults.canopyAddress); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Order %s locked\n", args[0])
+	case "close":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("usage: close <order-id|first> [amount]")
+		}
+		amount, err := replAmount(args, 1, defaults.amount)
+		if err != nil {
+			return err
+		}
+		if args[0] == "first" || args[0] == "auto" {
+			if err := e.CloseFirstOrder(defaults.buyerKey, amount); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, "First available order closed")
+			return nil
+		} else if err := e.CloseOrder(args[0], defaults.buyerKey, amount); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Order %s closed\n", args[0])
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: delete <order-id>")
+		}
+		if err := e.CancelOrder(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Order %s deleted\n", args[0])
+	case "list":
+		return e.listOrders(out)
+	case "balances":
+		e.printAccountBalances("Balances")
+	case "help":
+		fmt.Fprintln(out, replHelp)
+	default:
+		return fmt.Errorf("unknown command %q, type help for the commands", command)
+	}
+	return nil
+}
+
+// replAmount parses the optional amount argument at index i, falling back to the default
+func replAmount(args []string, i int, fallback uint64) (uint64, error) {
+	if len(args) <= i {
+		return fallback, nil
+	}
+	amount, err := strconv.ParseUint(args[i], 10, 64)
+	if err != nil || amount == 0 {
+		return 0, fmt.Errorf("invalid amount %q", args[i])
+	}
+	return amount, nil
+}
+
+// listOrders prints one line per order matching the order ID prefix, with its amounts and whether
+// a buyer locked it
+func (e *EthOracleE2E) listOrders(out io.Writer) error {
+	orders, err := e.Orders()
+	if err != nil {
+		return fmt.Errorf("failed to query orders: %w", err)
+	}
+	listed := 0
+	for _, book := range orders.OrderBooks {
+		for _, order := range book.Orders {
+			if !e.matchesOrderIDPrefix(order) {
+				continue
+			}
+			state := "unlocked"
+			if isLocked(order) {
+				state = "locked by " + lib.BytesToString(order.BuyerSendAddress)
+			}
+			fmt.Fprintf(out, "  %s committee %d: %d CNPY for %d, %s\n",
+				lib.BytesToString(order.Id), order.Committee, order.AmountForSale, order.RequestedAmount, state)
+			listed++
+		}
+	}
+	fmt.Fprintf(out, "%d orders on committees %v\n", listed, e.committees)
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/canopy-network/canopy/lib"
+)
+
+func TestRunREPL(t *testing.T) {
+	t.Setenv("E2E_FROM_NICK", "nick-0")
+	t.Setenv("E2E_FROM_PASS", "test")
+	e := newTestE2E(unlockedOrder, lockedOrder)
+	fake := e.client.(*fakeCanopyClient)
+	defaults := replDefaults{
+		amount:        100,
+		sellerAddress: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		canopyAddress: "851e90eaef1fa27debaee2c2591503bdeec1d123",
+	}
+
+	commands := strings.Join([]string{
+		"list",
+		"",
+		"delete 01",
+		"delete",
+		"create 250",
+		"create lots",
+		"frobnicate",
+		"quit",
+		"list",
+	}, "\n")
+	var out bytes.Buffer
+	if err := e.RunREPL(strings.NewReader(commands), &out, defaults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"  01 committee 2: 100 CNPY for 100, unlocked\n",
+		"  02 committee 2: 200 CNPY for 200, locked by aa\n",
+		"2 orders on committees [2]\n",
+		"Order 01 deleted\n",
+		"Error: usage: delete <order-id>\n",
+		"created: 250 CNPY -> 250 USDC\n",
+		`Error: invalid amount "lots"`,
+		`Error: unknown command "frobnicate"`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("REPL output is missing %q:\n%s", want, output)
+		}
+	}
+	// the commands after quit are never run
+	if strings.Count(output, "orders on committees") != 1 {
+		t.Errorf("REPL kept reading after quit:\n%s", output)
+	}
+	if want := []string{lib.BytesToString(unlockedOrder.Id)}; !reflect.DeepEqual(fake.deleted, want) {
+		t.Errorf("deleted %v, want %v", fake.deleted, want)
+	}
+	if len(fake.creators) != 1 {
+		t.Errorf("created %d orders, want 1", len(fake.creators))
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/canopy-network/canopy/lib"
+)
+
+// reportLogName is the file the log of a --report-dir run is copied to
+const reportLogName = "run.log"
+
+// startReport creates a timestamped subdirectory of root for the artifacts of a run, copies the
+// log into it from now on and writes the resolved configuration, with secrets redacted. The
+// remaining artifacts are written by finishReport once the suite ends
+func (e *EthOracleE2E) startReport(root, ethRPCURL string, ethChainID uint64) (string, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", root, err)
+	}
+	dir := filepath.Join(root, "run-"+time.Now().Format("20060102-150405"))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	logFile, err := os.Create(filepath.Join(dir, reportLogName))
+	if err != nil {
+		return "", fmt.Errorf("failed to create run log: %w", err)
+	}
+	e.reportDir, e.reportLog = dir, logFile
+	e.logger = lib.NewLogger(lib.LoggerConfig{Level: lib.DebugLevel, Out: io.MultiWriter(os.Stdout, logFile)})
+	if e.ethClient != nil {
+		e.ethClient.logger = e.logger
+	}
+
+	if err := writeReportJSON(dir, "config.json", e.effectiveConfig(ethRPCURL, ethChainID)); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// finishReport writes the summary, the balance snapshots taken around the suite and the final
+// order book into the report directory and closes the run log. A nil snapshot wasn't taken
+// because the run ended before it
+func (e *EthOracleE2E) finishReport(before, after *BalanceSnapshot) {
+	if e.reportDir == "" {
+		return
+	}
+	artifacts := []struct {
+		name  string
+		value interface{}
+	}{
+		{"summary.json", e.summary()},
+		{"balances-before.json", before},
+		{"balances-after.json", after},
+	}
+	for _, artifact := range artifacts {
+		if artifact.value == (*BalanceSnapshot)(nil) {
+			continue
+		}
+		if err := writeReportJSON(e.reportDir, artifact.name, artifact.value); err != nil {
+			e.logger.Errorf("Failed to write report: %v", err)
+		}
+	}
+	if orders, err := e.Orders(); err != nil {
+		e.logger.Errorf("Failed to query the final order book for the report: %v", err)
+	} else if err := writeReportJSON(e.reportDir, "orders.json", orders); err != nil {
+		e.logger.Errorf("Failed to write report: %v", err)
+	}
+
+	e.logger.Infof("Wrote run report to %s", e.reportDir)
+	e.logger = lib.NewDefaultLogger()
+	if e.ethClient != nil {
+		e.ethClient.logger = e.logger
+	}
+	if err := e.reportLog.Close(); err != nil {
+		fmt.Printf("Failed to close %s: %v\n", reportLogName, err)
+	}
+	e.reportDir, e.reportLog = "", nil
+}
+
+// writeReportJSON writes value as indented JSON to name in dir
+func writeReportJSON(dir, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
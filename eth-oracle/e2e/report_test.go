@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportDir(t *testing.T) {
+	e := newTestE2E(unlockedOrder)
+	e.sellerPass = "secret"
+	root := filepath.Join(t.TempDir(), "reports")
+	dir, err := e.startReport(root, "ws://eth:8545", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Dir(dir) != root || !strings.HasPrefix(filepath.Base(dir), "run-") {
+		t.Errorf("report directory %s is not a run- subdirectory of %s", dir, root)
+	}
+
+	e.logger.Infof("logged during the run")
+	before := &BalanceSnapshot{Taken: time.Now(), USDC: map[string]*big.Int{"0xaa": big.NewInt(5)}, CNPY: map[string]uint64{}}
+	// the run ended before the closing snapshot
+	e.finishReport(before, nil)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	want := "balances-before.json config.json orders.json run.log summary.json"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("report files = %s, want %s", got, want)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return string(data)
+	}
+	if log := read("run.log"); !strings.Contains(log, "logged during the run") {
+		t.Errorf("run.log = %q, want the run's log lines", log)
+	}
+	var config EffectiveConfig
+	if err := json.Unmarshal([]byte(read("config.json")), &config); err != nil || config.Password != redacted {
+		t.Errorf("config.json password = %q, err %v; want it redacted", config.Password, err)
+	}
+	if orders := read("orders.json"); !strings.Contains(orders, `"01"`) {
+		t.Errorf("orders.json doesn't hold the final order book:\n%s", orders)
+	}
+
+	// the log goes back to stdout only
+	if e.reportLog != nil || e.reportDir != "" {
+		t.Error("report still open after finishReport")
+	}
+	e.logger.Infof("logged after the run")
+	if log := read("run.log"); strings.Contains(log, "after the run") {
+		t.Error("run.log kept receiving lines after the report was finished")
+	}
+}
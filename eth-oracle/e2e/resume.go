@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/canopy-network/canopy/lib"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// resumeTestCases rebuilds a test case for every order left in the order books by an earlier run,
+// so the suite continues their flow instead of deleting them
+func (e *EthOracleE2E) resumeTestCases() ([]*TestCase, error) {
+	orders, err := e.Orders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing orders: %w", err)
+	}
+
+	var testCases []*TestCase
+	for _, book := range orders.OrderBooks {
+		for _, order := range book.Orders {
+			testCases = append(testCases, e.resumeTestCase(order))
+		}
+	}
+	return testCases, nil
+}
+
+// resumeTestCase reconstructs the test case of an order from its status. Unlocked orders are locked
+// by the default buyer; locked orders are closed by the buyer that locked them
+func (e *EthOracleE2E) resumeTestCase(order *lib.SellOrder) *TestCase {
+	orderID := lib.BytesToString(order.Id)
+	testCase := &TestCase{
+		Name:                 fmt.Sprintf("Resume_%s", orderID),
+		OrderAmount:          order.AmountForSale,
+		ExpectedUSDCTransfer: order.RequestedAmount,
+		ExpectedCNPYTransfer: order.AmountForSale,
+		BuyerAddress:         ethAccounts[0],
+		BuyerPrivateKey:      ethPrivateKeys[0],
+		SellerAddress:        common.BytesToAddress(order.SellerReceiveAddress).Hex(),
+		CanopyReceiveAddress: canopyAccounts[1],
+		CanopySendAddress:    lib.BytesToString(order.SellersSendAddress),
+		Committee:            order.Committee,
+		TokenContract:        e.tokenContract,
+		OrderID:              orderID,
+		Status:               StatusCreated,
+	}
+
+	if isLocked(order) {
+		testCase.Status = StatusLocked
+		testCase.BuyerAddress = common.BytesToAddress(order.BuyerSendAddress).Hex()
+		testCase.BuyerPrivateKey = ethPrivateKeyFor(testCase.BuyerAddress)
+		testCase.CanopyReceiveAddress = lib.BytesToString(order.BuyerReceiveAddress)
+	}
+	return testCase
+}
+
+// ethPrivateKeyFor returns the private key of a known anvil account, empty if unknown
+func ethPrivateKeyFor(address string) string {
+	for i, account := range ethAccounts {
+		if account != "" && strings.EqualFold(account, address) {
+			return ethPrivateKeys[i]
+		}
+	}
+	return ""
+}
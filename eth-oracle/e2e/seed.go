@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// SeedOrders creates n sell orders on the first committee, for populating an order book without
+// running the suite. Amounts are distinct: consecutive from minAmount, or random in
+// [minAmount, maxAmount] when maxAmount is set. It prints each created order id and returns the
+// ids created before any error
+func (e *EthOracleE2E) SeedOrders(n int, minAmount, maxAmount uint64, sellerAddress, canopyAddress string) ([]string, error) {
+	amounts, err := seedAmounts(n, minAmount, maxAmount, rand.New(rand.NewSource(rand.Int63())))
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIDs []string
+	for i, amount := range amounts {
+		orderID, err := e.createSellOrder(e.committees[0], amount, amount, sellerAddress, "", canopyAddress, e.tokenContract, e.sellerNick, e.sellerPass)
+		if err != nil {
+			return orderIDs, fmt.Errorf("failed to create order %d (%d): %w", i+1, amount, err)
+		}
+		orderIDs = append(orderIDs, orderID)
+		fmt.Printf("%d/%d %s %d\n", i+1, n, orderID, amount)
+	}
+	return orderIDs, nil
+}
+
+// seedAmounts returns n distinct order amounts: minAmount, minAmount+1, ... when maxAmount is 0,
+// otherwise random amounts in [minAmount, maxAmount]
+func seedAmounts(n int, minAmount, maxAmount uint64, rng *rand.Rand) ([]uint64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("can't seed %d orders", n)
+	}
+	if minAmount == 0 {
+		return nil, fmt.Errorf("seed amounts must be positive")
+	}
+	amounts := make([]uint64, 0, n)
+	if maxAmount == 0 {
+		for i := 0; i < n; i++ {
+			amounts = append(amounts, minAmount+uint64(i))
+		}
+		return amounts, nil
+	}
+
+	if maxAmount < minAmount {
+		return nil, fmt.Errorf("seed amount max %d is below min %d", maxAmount, minAmount)
+	}
+	if span := maxAmount - minAmount; span < uint64(n-1) {
+		return nil, fmt.Errorf("%d distinct amounts don't fit in [%d, %d]", n, minAmount, maxAmount)
+	}
+	used := make(map[uint64]bool, n)
+	for len(amounts) < n {
+		// minAmount is positive, so the span size can't overflow even with maxAmount at MaxUint64
+		amount := minAmount + rng.Uint64()%(maxAmount-minAmount+1)
+		if used[amount] {
+			continue
+		}
+		used[amount] = true
+		amounts = append(amounts, amount)
+	}
+	return amounts, nil
+}
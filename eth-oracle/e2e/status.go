@@ -0,0 +1,50 @@
+package main
+
+import "github.com/canopy-network/canopy/lib"
+
+// OrderStatus is the lifecycle state of a sell order, and of the test case driving it
+type OrderStatus string
+
+const (
+	// StatusCreated is an order in the book that no buyer has locked
+	StatusCreated OrderStatus = "created"
+	// StatusLocked is an order locked by a buyer whose deadline hasn't passed
+	StatusLocked OrderStatus = "locked"
+	// StatusExpired is a locked order whose buyer deadline passed before it was closed
+	StatusExpired OrderStatus = "expired"
+	// StatusClosed is an order that has left the order book
+	StatusClosed OrderStatus = "closed"
+	// StatusVerified is a closed order whose balance changes were verified; test cases only
+	StatusVerified OrderStatus = "verified"
+)
+
+// GetOrderStatus derives the lifecycle state of an order from its fields. A nil order is no longer
+// in the order book. height is the current canopy height the buyer deadline is compared with; 0
+// skips the deadline check
+func GetOrderStatus(order *lib.SellOrder, height uint64) OrderStatus {
+	switch {
+	case order == nil:
+		return StatusClosed
+	case len(order.BuyerSendAddress) == 0:
+		return StatusCreated
+	case height != 0 && order.BuyerChainDeadline != 0 && height > order.BuyerChainDeadline:
+		return StatusExpired
+	default:
+		return StatusLocked
+	}
+}
+
+// isLocked reports whether a buyer has locked an order, whether or not its deadline has passed
+func isLocked(order *lib.SellOrder) bool {
+	return GetOrderStatus(order, 0) == StatusLocked
+}
+
+// canopyHeight returns the current canopy height, or 0 when it can't be queried so deadlines are
+// not checked
+func (e *EthOracleE2E) canopyHeight() uint64 {
+	height, err := e.client.Height()
+	if err != nil || height == nil {
+		return 0
+	}
+	return *height
+}
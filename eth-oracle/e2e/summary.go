@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// SuiteSummary is the machine-readable result of a test suite run written by --summary-json
+type SuiteSummary struct {
+	Finished    time.Time     `json:"finished"`
+	Total       int           `json:"total"`
+	Passed      int           `json:"passed"`
+	Failed      int           `json:"failed"`
+	SuccessRate float64       `json:"successRate"` // percent of total
+	Tests       []TestSummary `json:"tests"`
+}
+
+// TestSummary is the result of a single test case
+type TestSummary struct {
+	Name       string   `json:"name"`
+	Committee  uint64   `json:"committee"`
+	OrderID    string   `json:"orderId,omitempty"`
+	OrderIDs   []string `json:"orderIds,omitempty"` // the orders of a batch test case
+	Status     string   `json:"status"`
+	Passed     bool     `json:"passed"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int64    `json:"durationMs"`
+}
+
+// summary builds the summary of the test results so far, with the tests sorted by name
+func (e *EthOracleE2E) summary() *SuiteSummary {
+	e.testResults.mutex.RLock()
+	defer e.testResults.mutex.RUnlock()
+
+	summary := &SuiteSummary{
+		Finished: time.Now().UTC(),
+		Total:    e.testResults.total,
+		Passed:   e.testResults.passed,
+		Failed:   e.testResults.failed,
+		Tests:    []TestSummary{},
+	}
+	if summary.Total > 0 {
+		summary.SuccessRate = float64(summary.Passed) / float64(summary.Total) * 100
+	}
+
+	for _, testCase := range e.testResults.testCases {
+		test := TestSummary{
+			Name:       testCase.Name,
+			Committee:  testCase.Committee,
+			OrderID:    testCase.OrderID,
+			OrderIDs:   testCase.BatchOrderIDs,
+			Status:     string(testCase.Status),
+			Passed:     testCase.Error == nil,
+			DurationMs: testCase.Duration.Milliseconds(),
+		}
+		if testCase.Error != nil {
+			test.Error = testCase.Error.Error()
+		}
+		summary.Tests = append(summary.Tests, test)
+	}
+	sort.Slice(summary.Tests, func(i, j int) bool { return summary.Tests[i].Name < summary.Tests[j].Name })
+
+	return summary
+}
+
+// writeSummary writes the JSON summary of the test results to path
+func (e *EthOracleE2E) writeSummary(path string) error {
+	data, err := json.MarshalIndent(e.summary(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write summary to %s: %w", path, err)
+	}
+	return nil
+}
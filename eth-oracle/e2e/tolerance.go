@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/canopy-network/canopy/lib"
+)
+
+// amountTolerance is how far an order's amount for sale may be from a test case's order amount
+// and still match, for chains that net fees into order amounts. It is either an absolute amount
+// or basis points of the expected amount; the zero value matches exactly
+type amountTolerance struct {
+	absolute uint64
+	bps      uint64
+}
+
+// parseAmountTolerance parses an --amount-tolerance, an absolute uCNPY amount like "500" or basis
+// points like "25bps"
+func parseAmountTolerance(value string) (amountTolerance, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return amountTolerance{}, nil
+	}
+	if bps, ok := strings.CutSuffix(value, "bps"); ok {
+		n, err := strconv.ParseUint(strings.TrimSpace(bps), 10, 64)
+		if err != nil || n > 10000 {
+			return amountTolerance{}, fmt.Errorf("%q is not 0 to 10000 basis points", value)
+		}
+		return amountTolerance{bps: n}, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return amountTolerance{}, fmt.Errorf("%q is neither an amount nor basis points like 25bps", value)
+	}
+	return amountTolerance{absolute: n}, nil
+}
+
+// delta returns the largest difference from expected that still matches
+func (t amountTolerance) delta(expected uint64) uint64 {
+	if t.bps == 0 {
+		return t.absolute
+	}
+	delta := new(big.Int).Mul(new(big.Int).SetUint64(expected), new(big.Int).SetUint64(t.bps))
+	return delta.Div(delta, big.NewInt(10000)).Uint64()
+}
+
+// matches reports whether actual is within the tolerance of expected
+func (t amountTolerance) matches(actual, expected uint64) bool {
+	delta := t.delta(expected)
+	if actual > expected {
+		return actual-expected <= delta
+	}
+	return expected-actual <= delta
+}
+
+func (t amountTolerance) String() string {
+	switch {
+	case t.bps != 0:
+		return fmt.Sprintf("%dbps", t.bps)
+	case t.absolute != 0:
+		return strconv.FormatUint(t.absolute, 10)
+	default:
+		return "exact"
+	}
+}
+
+// matchTestOrder reports whether an order has the committee and requested amount of a test case
+// and an amount for sale within the tolerance of its order amount. A match records the amount the
+// order sells, which is what closing it releases
+func (e *EthOracleE2E) matchTestOrder(testCase *TestCase, order *lib.SellOrder) bool {
+	if order.Committee != testCase.Committee || order.RequestedAmount != testCase.ExpectedUSDCTransfer ||
+		!e.amountTolerance.matches(order.AmountForSale, testCase.OrderAmount) {
+		return false
+	}
+	testCase.MatchedAmount = order.AmountForSale
+	return true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/canopy-network/canopy/lib"
+)
+
+func TestAmountTolerance(t *testing.T) {
+	tests := []struct {
+		value    string
+		actual   uint64
+		expected uint64
+		want     bool
+	}{
+		{value: "", actual: 1000000, expected: 1000000, want: true},
+		{value: "", actual: 999000, expected: 1000000},
+		{value: "1000", actual: 999000, expected: 1000000, want: true},
+		{value: "1000", actual: 1001000, expected: 1000000, want: true},
+		{value: "1000", actual: 998999, expected: 1000000},
+		{value: "10bps", actual: 999000, expected: 1000000, want: true},
+		{value: "10bps", actual: 998999, expected: 1000000},
+		{value: "10000bps", actual: 0, expected: 1000000, want: true},
+	}
+	for _, test := range tests {
+		tolerance, err := parseAmountTolerance(test.value)
+		if err != nil {
+			t.Fatalf("parseAmountTolerance(%q): unexpected error: %v", test.value, err)
+		}
+		if got := tolerance.matches(test.actual, test.expected); got != test.want {
+			t.Errorf("%s tolerance matches(%d, %d) = %t, want %t", tolerance, test.actual, test.expected, got, test.want)
+		}
+	}
+
+	for _, value := range []string{"-5", "1.5", "10001bps", "bps", "5%"} {
+		if _, err := parseAmountTolerance(value); err == nil {
+			t.Errorf("expected error for --amount-tolerance %q", value)
+		}
+	}
+}
+
+func TestToleranceMatchedOrderCompletes(t *testing.T) {
+	receive := "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"
+	// the chain netted a 400 uCNPY fee out of the order's amount for sale
+	netted := &lib.SellOrder{Id: []byte{0x21}, Committee: chainId, AmountForSale: 999600, RequestedAmount: 1000000,
+		BuyerSendAddress: []byte{0xaa}}
+	testCase := &TestCase{
+		Committee:            chainId,
+		OrderAmount:          1000000,
+		ExpectedUSDCTransfer: 1000000,
+		ExpectedCNPYTransfer: 1000000,
+		CanopyReceiveAddress: receive,
+		InitialCNPYBalance:   5000,
+	}
+
+	e := newTestE2E(netted)
+	if e.matchTestOrder(testCase, netted) {
+		t.Fatal("matchTestOrder() matched a fee-netted order without a tolerance")
+	}
+	e.amountTolerance = amountTolerance{absolute: 500}
+	if !e.matchTestOrder(testCase, netted) || testCase.MatchedAmount != 999600 {
+		t.Fatalf("matchTestOrder() recorded %d, want a match selling 999600", testCase.MatchedAmount)
+	}
+	testCase.OrderID = lib.BytesToString(netted.Id)
+
+	// the close releases what the order sells, not the test case's order amount
+	e.client.(*fakeCanopyClient).orders.OrderBooks[0].Orders = nil
+	e.client.(*fakeCanopyClient).amounts = map[string]uint64{receive: 5000 + 999600}
+	if completed, err := e.orderCompleted(testCase); !completed || err != nil {
+		t.Errorf("orderCompleted() = %t, %v, want the fee-netted order completed", completed, err)
+	}
+	if _, _, cnpy := expectedBalanceDeltas(testCase); cnpy != 999600 {
+		t.Errorf("expected CNPY change = %d, want 999600", cnpy)
+	}
+}
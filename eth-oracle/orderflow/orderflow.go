@@ -0,0 +1,167 @@
+// Package orderflow drives the canopy <-> ethereum sell order flow: creating an order on canopy,
+// locking it and closing it with transactions on the ethereum side.
+package orderflow
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/canopy-network/canopy/cmd/rpc"
+	"github.com/canopy-network/canopy/fsm"
+	"github.com/canopy-network/canopy/lib"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// ERC20TransferMethodID is the selector of the ERC20 transfer(address,uint256) method
+	ERC20TransferMethodID = "a9059cbb"
+	// ERC20BalanceOfMethodID is the selector of the ERC20 balanceOf(address) method
+	ERC20BalanceOfMethodID = "70a08231"
+	// CreateOrderFee is the fee paid for a create order transaction
+	CreateOrderFee = uint64(100000)
+
+	// orderIDLength is the number of tx hash bytes canopy uses as the order id
+	orderIDLength = 20
+)
+
+// CanopyClient interface defines the canopy rpc methods used by the order flow
+type CanopyClient interface {
+	Orders(height, chainId uint64) (*lib.OrderBooks, lib.ErrorI)
+	Height() (*uint64, lib.ErrorI)
+	Account(height uint64, address string) (*fsm.Account, lib.ErrorI)
+	TxCreateOrder(from rpc.AddrOrNickname, sellAmount, receiveAmount, chainId uint64, receiveAddress string,
+		pwd string, data lib.HexBytes, submit bool, optFee uint64) (*string, json.RawMessage, lib.ErrorI)
+	TxDeleteOrder(from rpc.AddrOrNickname, orderId string, chainId uint64,
+		pwd string, submit bool, optFee uint64) (*string, json.RawMessage, lib.ErrorI)
+	TxSend(from rpc.AddrOrNickname, rec string, amt uint64, pwd string, submit bool, optFee uint64) (*string, json.RawMessage, lib.ErrorI)
+}
+
+var _ CanopyClient = (*rpc.Client)(nil)
+
+// CreateOrder creates a sell order on a committee offering sellAmount uCNPY for receiveAmount of
+// the ERC20 token at contract, paid to receiveAddress. The receive address is an address on the
+// ethereum side of the committee and is checked before anything is submitted, since an order with
+// an address the buyer can't pay is dead. It returns the id of the new order and the hash of the
+// create order transaction
+func CreateOrder(client CanopyClient, from rpc.AddrOrNickname, password string, committee, sellAmount, receiveAmount uint64,
+	receiveAddress, contract string) (orderID, txHash string, err error) {
+	if _, err := DecodeAddress(receiveAddress); err != nil {
+		return "", "", fmt.Errorf("invalid receive address for committee %d: %w", committee, err)
+	}
+
+	data, err := lib.NewHexBytesFromString(strings.TrimPrefix(contract, "0x"))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create contract data: %w", err)
+	}
+
+	hash, _, e := client.TxCreateOrder(from, sellAmount, receiveAmount, committee, strings.TrimPrefix(receiveAddress, "0x"),
+		password, data, true, CreateOrderFee)
+	if e != nil {
+		return "", "", fmt.Errorf("failed to create order: %w", e)
+	}
+	if hash == nil {
+		return "", "", fmt.Errorf("create order returned no transaction hash")
+	}
+
+	orderID, err = OrderIDFromTxHash(*hash)
+	if err != nil {
+		return "", "", err
+	}
+	return orderID, *hash, nil
+}
+
+// OrderIDFromTxHash derives the id of an order from the hash of the transaction that created it
+func OrderIDFromTxHash(txHash string) (string, error) {
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction hash %q: %w", txHash, err)
+	}
+	if len(hash) < orderIDLength {
+		return "", fmt.Errorf("transaction hash %q is shorter than %d bytes", txHash, orderIDLength)
+	}
+	return lib.BytesToString(hash[:orderIDLength]), nil
+}
+
+// DecodeAddress decodes a hex eth or canopy address, with or without a 0x prefix, and checks it is
+// 20 bytes long
+func DecodeAddress(address string) ([]byte, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X")
+	decoded, err := hex.DecodeString(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("address %q is not hex: %w", address, err)
+	}
+	if len(decoded) != common.AddressLength {
+		return nil, fmt.Errorf("address %q is %d bytes, expected %d", address, len(decoded), common.AddressLength)
+	}
+	return decoded, nil
+}
+
+// LockOrder locks an order for the buyer by sending a LockOrder payload from buyerAddress to
+// itself. The buyer receives the order's CNPY at canopyAddress and must close the order before
+// the canopy height deadline. It returns the hash of the lock transaction
+func (o Options) LockOrder(client EthereumClient, order *lib.SellOrder, buyerAddress, buyerPrivateKey, canopyAddress string, deadline uint64) (common.Hash, error) {
+	buyerSendAddress, err := DecodeAddress(buyerAddress)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid buyer address: %w", err)
+	}
+	buyerReceiveAddress, err := DecodeAddress(canopyAddress)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid canopy address: %w", err)
+	}
+
+	lockOrder := &lib.LockOrder{
+		OrderId:             order.Id,
+		BuyerSendAddress:    buyerSendAddress,
+		BuyerReceiveAddress: buyerReceiveAddress,
+		BuyerChainDeadline:  deadline,
+		ChainId:             order.Committee,
+	}
+
+	data, err := json.Marshal(lockOrder)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to marshal lock order: %w", err)
+	}
+
+	hash, err := o.SendTransactionWithGasLimit(client, common.BytesToAddress(buyerSendAddress), buyerPrivateKey, new(big.Int).SetUint64(0), data, o.LockGasLimit)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to send lock transaction: %w", err)
+	}
+	return hash, nil
+}
+
+// CloseOrder closes a locked order by transferring transferAmount of the ERC20 token at contract
+// to the order's seller receive address, with a CloseOrder payload appended to the transfer. It
+// returns the hash of the transfer transaction
+func (o Options) CloseOrder(client EthereumClient, order *lib.SellOrder, contract common.Address, buyerPrivateKey string, transferAmount uint64) (common.Hash, error) {
+	return o.CloseOrderTo(client, order, common.BytesToAddress(order.SellerReceiveAddress), contract, buyerPrivateKey, transferAmount)
+}
+
+// CloseOrderTo is CloseOrder paying recipient instead of the order's seller receive address. Any
+// other recipient must not release the order; it exists for negative tests of the oracle
+func (o Options) CloseOrderTo(client EthereumClient, order *lib.SellOrder, recipient, contract common.Address, buyerPrivateKey string, transferAmount uint64) (common.Hash, error) {
+	// Create the ERC20 transfer call
+	transferDataBytes := o.TransferData(recipient, new(big.Int).SetUint64(transferAmount).Bytes())
+
+	closeOrder := &lib.CloseOrder{
+		OrderId:    order.Id,
+		ChainId:    order.Committee,
+		CloseOrder: true,
+	}
+
+	closeOrderBytes, err := json.Marshal(closeOrder)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to marshal close order: %w", err)
+	}
+
+	// Append the close order bytes to the transfer data
+	data := append(transferDataBytes, closeOrderBytes...)
+
+	hash, err := o.SendTransactionWithGasLimit(client, contract, buyerPrivateKey, new(big.Int).SetUint64(0), data, o.CloseGasLimit)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to send transfer: %w", err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,264 @@
+package orderflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/canopy-network/canopy/cmd/rpc"
+	"github.com/canopy-network/canopy/fsm"
+	"github.com/canopy-network/canopy/lib"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeCanopyClient records create order requests and answers with a fixed tx hash
+type fakeCanopyClient struct {
+	hash           *string
+	receiveAddress string
+	data           lib.HexBytes
+	submitted      bool
+}
+
+func (f *fakeCanopyClient) Orders(height, chainId uint64) (*lib.OrderBooks, lib.ErrorI) {
+	return &lib.OrderBooks{}, nil
+}
+
+func (f *fakeCanopyClient) Height() (*uint64, lib.ErrorI) {
+	height := uint64(1)
+	return &height, nil
+}
+
+func (f *fakeCanopyClient) Account(height uint64, address string) (*fsm.Account, lib.ErrorI) {
+	return &fsm.Account{}, nil
+}
+
+func (f *fakeCanopyClient) TxCreateOrder(from rpc.AddrOrNickname, sellAmount, receiveAmount, chainId uint64, receiveAddress string,
+	pwd string, data lib.HexBytes, submit bool, optFee uint64) (*string, json.RawMessage, lib.ErrorI) {
+	f.receiveAddress, f.data, f.submitted = receiveAddress, data, true
+	return f.hash, nil, nil
+}
+
+func (f *fakeCanopyClient) TxDeleteOrder(from rpc.AddrOrNickname, orderId string, chainId uint64,
+	pwd string, submit bool, optFee uint64) (*string, json.RawMessage, lib.ErrorI) {
+	return nil, nil, nil
+}
+
+func (f *fakeCanopyClient) TxSend(from rpc.AddrOrNickname, rec string, amt uint64, pwd string, submit bool,
+	optFee uint64) (*string, json.RawMessage, lib.ErrorI) {
+	return nil, nil, nil
+}
+
+func TestOrderIDFromTxHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "32 byte hash",
+			hash: "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff",
+			want: "00112233445566778899aabbccddeeff00112233",
+		},
+		{
+			name:    "not hex",
+			hash:    "zz",
+			wantErr: true,
+		},
+		{
+			name:    "too short",
+			hash:    "0011",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := OrderIDFromTxHash(test.hash)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	hash := "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+	client := &fakeCanopyClient{hash: &hash}
+
+	orderID, txHash, err := CreateOrder(client, rpc.AddrOrNickname{Nickname: "nick"}, "test", 2, 1000000, 1000000,
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8", "0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if txHash != hash {
+		t.Errorf("tx hash %q, want %q", txHash, hash)
+	}
+	if orderID != hash[:40] {
+		t.Errorf("order id %q, want %q", orderID, hash[:40])
+	}
+	if client.receiveAddress != "70997970C51812dc3A010C7d01b50e0d17dc79C8" {
+		t.Errorf("receive address %q was not stripped of 0x", client.receiveAddress)
+	}
+	if client.data.String() != "5fbdb2315678afecb367f032d93f642f64180aa3" {
+		t.Errorf("contract data %s", client.data)
+	}
+
+	client.hash = nil
+	if _, _, err := CreateOrder(client, rpc.AddrOrNickname{}, "", 2, 1, 1, "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", ""); err == nil {
+		t.Error("expected error for missing tx hash")
+	}
+}
+
+func TestCreateOrderReceiveAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+	}{
+		{name: "empty", address: ""},
+		{name: "wrong length", address: "0x70997970C51812dc3A010C7d01b50e0d17dc79"},
+		{name: "canopy-length plus a byte", address: "70997970C51812dc3A010C7d01b50e0d17dc79C800"},
+		{name: "non-hex", address: "0x70997970C51812dc3A010C7d01b50e0d17dc79ZZ"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hash := "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+			client := &fakeCanopyClient{hash: &hash}
+			_, _, err := CreateOrder(client, rpc.AddrOrNickname{Nickname: "nick"}, "test", 2, 1, 1, test.address, "")
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), fmt.Sprintf("%q", test.address)) {
+				t.Errorf("error %q doesn't name the address", err)
+			}
+			if client.submitted {
+				t.Error("create order was submitted with an invalid receive address")
+			}
+		})
+	}
+}
+
+func TestCloseOrderTo(t *testing.T) {
+	seller := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	other := common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")
+	contract := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	order := &lib.SellOrder{Id: []byte{1, 2, 3}, Committee: 2, SellerReceiveAddress: seller.Bytes()}
+
+	tests := []struct {
+		name  string
+		close func(client EthereumClient) (common.Hash, error)
+		want  common.Address
+	}{
+		{
+			name: "seller",
+			close: func(client EthereumClient) (common.Hash, error) {
+				return Options{}.CloseOrder(client, order, contract, testKey, 1000000)
+			},
+			want: seller,
+		},
+		{
+			name: "override recipient",
+			close: func(client EthereumClient) (common.Hash, error) {
+				return Options{}.CloseOrderTo(client, order, other, contract, testKey, 1000000)
+			},
+			want: other,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newFakeEthereumClient()
+			if _, err := test.close(client); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tx := client.sent[0]
+			if *tx.To() != contract {
+				t.Errorf("sent to %s, want the token contract %s", tx.To(), contract)
+			}
+			// selector (4 bytes) followed by the left padded recipient
+			data := tx.Data()
+			if got := common.BytesToAddress(data[4:36]); got != test.want {
+				t.Errorf("transfer recipient %s, want %s", got, test.want)
+			}
+			if !strings.Contains(string(data[68:]), `"closeOrder":true`) {
+				t.Errorf("close order payload missing: %s", data[68:])
+			}
+		})
+	}
+}
+
+func TestDecodeAddress(t *testing.T) {
+	want := "70997970c51812dc3a010c7d01b50e0d17dc79c8"
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "no prefix", address: "70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+		{name: "0x prefix", address: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+		{name: "0X prefix", address: "0X70997970c51812dc3a010c7d01b50e0d17dc79c8"},
+		{name: "too short", address: "0x70997970c51812dc3a010c7d01b50e0d17dc79", wantErr: true},
+		{name: "too long", address: "70997970c51812dc3a010c7d01b50e0d17dc79c800", wantErr: true},
+		{name: "not hex", address: "0xzz997970c51812dc3a010c7d01b50e0d17dc79c8", wantErr: true},
+		{name: "empty", address: "", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := DecodeAddress(test.address)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %x", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if lib.BytesToString(got) != want {
+				t.Errorf("got %x, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLockOrderAddresses(t *testing.T) {
+	canopyAddress := "a0fd5a5dcb6da1bbac3ad7fe6ab1c9b06e5f6d5e"
+	order := &lib.SellOrder{Id: []byte{1}, Committee: 2}
+	for _, prefix := range []string{"", "0x"} {
+		client := newFakeEthereumClient()
+		if _, err := (Options{}).LockOrder(client, order, prefix+testAddress.Hex()[2:], testKey, prefix+canopyAddress, 10); err != nil {
+			t.Fatalf("prefix %q: unexpected error: %v", prefix, err)
+		}
+
+		var lockOrder lib.LockOrder
+		if err := json.Unmarshal(client.sent[0].Data(), &lockOrder); err != nil {
+			t.Fatalf("prefix %q: lock payload: %v", prefix, err)
+		}
+		if got := lib.BytesToString(lockOrder.BuyerReceiveAddress); got != canopyAddress {
+			t.Errorf("prefix %q: buyer receive address %s, want %s", prefix, got, canopyAddress)
+		}
+		if got := common.BytesToAddress(lockOrder.BuyerSendAddress); got != testAddress {
+			t.Errorf("prefix %q: buyer send address %s, want %s", prefix, got, testAddress)
+		}
+		if lockOrder.ChainId != order.Committee {
+			t.Errorf("prefix %q: lock chain id %d, want the order's committee %d", prefix, lockOrder.ChainId, order.Committee)
+		}
+		if *client.sent[0].To() != testAddress {
+			t.Errorf("prefix %q: lock sent to %s, want the buyer %s", prefix, client.sent[0].To(), testAddress)
+		}
+	}
+
+	if _, err := (Options{}).LockOrder(newFakeEthereumClient(), order, testAddress.Hex(), testKey, "0xabcd", 10); err == nil {
+		t.Error("expected error for a short canopy address")
+	}
+}
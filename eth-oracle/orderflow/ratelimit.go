@@ -0,0 +1,58 @@
+package orderflow
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that spaces out transactions so they don't reach the node faster
+// than its mempool accepts them
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // maximum number of tokens in the bucket
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a token bucket allowing rate transactions per second with bursts of up
+// to burst transactions
+func NewRateLimiter(rate float64, burst int) (*RateLimiter, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("rate must be positive, got %g", rate)
+	}
+	if burst < 1 {
+		return nil, fmt.Errorf("burst must be at least 1, got %d", burst)
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}, nil
+}
+
+// Wait blocks until a token is available and takes it
+func (r *RateLimiter) Wait() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+
+	if r.tokens < 1 {
+		// sleep while holding the lock so concurrent callers queue up behind this one
+		wait := time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+		time.Sleep(wait)
+		r.tokens = 1
+		r.last = now.Add(wait)
+	}
+	r.tokens--
+}
+
+// waitForTxSlot blocks until the options' rate limiter allows another transaction
+func (o Options) waitForTxSlot() {
+	if o.Limiter != nil {
+		o.Limiter.Wait()
+	}
+}
@@ -0,0 +1,64 @@
+package orderflow
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		burst   int
+		wantErr bool
+	}{
+		{name: "valid", rate: 10, burst: 1},
+		{name: "zero rate", rate: 0, burst: 1, wantErr: true},
+		{name: "negative rate", rate: -1, burst: 1, wantErr: true},
+		{name: "zero burst", rate: 10, burst: 0, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewRateLimiter(test.rate, test.burst); (err != nil) != test.wantErr {
+				t.Errorf("NewRateLimiter() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter, err := NewRateLimiter(50, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the first token is available immediately, the next three are 20ms apart
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		limiter.Wait()
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("4 waits at 50/s took %s, want at least 60ms", elapsed)
+	}
+}
+
+func TestOptionsLimiter(t *testing.T) {
+	limiter, err := NewRateLimiter(50, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limited := Options{Limiter: limiter}
+	client := newFakeEthereumClient()
+
+	// copies of the options share the limiter, and options without one aren't paced
+	start := time.Now()
+	for _, options := range []Options{limited, limited, {}, limited} {
+		if _, err := options.SendTransaction(client, testTo, testKey, big.NewInt(0), nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("3 limited sends at 50/s took %s, want at least 40ms", elapsed)
+	}
+}
@@ -0,0 +1,60 @@
+package orderflow
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// selectorLength is the number of bytes of an ABI method selector
+const selectorLength = 4
+
+var (
+	// erc20TransferSelector and erc20BalanceOfSelector are the standard ERC20 methods token calldata
+	// is built with unless the options override them
+	erc20TransferSelector  = mustParseSelector(ERC20TransferMethodID)
+	erc20BalanceOfSelector = mustParseSelector(ERC20BalanceOfMethodID)
+)
+
+// ParseSelector decodes a 4 byte hex method selector, with or without a 0x prefix
+func ParseSelector(selector string) ([]byte, error) {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(selector, "0x"), "0X"))
+	if err != nil {
+		return nil, fmt.Errorf("selector %q is not hex: %w", selector, err)
+	}
+	if len(decoded) != selectorLength {
+		return nil, fmt.Errorf("selector %q is %d bytes, expected %d", selector, len(decoded), selectorLength)
+	}
+	return decoded, nil
+}
+
+func mustParseSelector(selector string) []byte {
+	decoded, err := ParseSelector(selector)
+	if err != nil {
+		panic(err)
+	}
+	return decoded
+}
+
+// TransferData builds the calldata of a token transfer of amount to recipient
+func (o Options) TransferData(recipient common.Address, amount []byte) []byte {
+	selector := o.TransferSelector
+	if len(selector) == 0 {
+		selector = erc20TransferSelector
+	}
+	data := append([]byte{}, selector...)
+	data = append(data, common.LeftPadBytes(recipient.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount, 32)...)
+}
+
+// BalanceOfData builds the calldata of a token balance query for account
+func (o Options) BalanceOfData(account common.Address) []byte {
+	selector := o.BalanceOfSelector
+	if len(selector) == 0 {
+		selector = erc20BalanceOfSelector
+	}
+	data := append([]byte{}, selector...)
+	return append(data, common.LeftPadBytes(account.Bytes(), 32)...)
+}
@@ -0,0 +1,70 @@
+package orderflow
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/canopy-network/canopy/lib"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		wantErr  bool
+	}{
+		{name: "standard transfer", selector: "a9059cbb"},
+		{name: "0x prefix", selector: "0xe3ee160e"},
+		{name: "too short", selector: "a9059c", wantErr: true},
+		{name: "too long", selector: "a9059cbb00", wantErr: true},
+		{name: "not hex", selector: "transfer", wantErr: true},
+		{name: "empty", selector: "", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSelector(test.selector)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %x", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != selectorLength {
+				t.Errorf("got %d bytes, want %d", len(got), selectorLength)
+			}
+		})
+	}
+}
+
+func TestOptionsSelectors(t *testing.T) {
+	account := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	if got := hex.EncodeToString(Options{}.BalanceOfData(account)[:4]); got != ERC20BalanceOfMethodID {
+		t.Errorf("default balanceOf selector %s, want %s", got, ERC20BalanceOfMethodID)
+	}
+	if got := hex.EncodeToString(Options{}.TransferData(account, []byte{1})[:4]); got != ERC20TransferMethodID {
+		t.Errorf("default transfer selector %s, want %s", got, ERC20TransferMethodID)
+	}
+
+	options := Options{TransferSelector: mustParseSelector("0xe3ee160e"), BalanceOfSelector: mustParseSelector("12345678")}
+	client := newFakeEthereumClient()
+	order := &lib.SellOrder{Id: []byte{1}, Committee: 2, SellerReceiveAddress: account.Bytes()}
+	if _, err := options.CloseOrder(client, order, common.Address{}, testKey, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.sent[0].Data()[:4]; !bytes.Equal(got, []byte{0xe3, 0xee, 0x16, 0x0e}) {
+		t.Errorf("close calldata selector %x, want e3ee160e", got)
+	}
+	if got := options.BalanceOfData(account)[:4]; !bytes.Equal(got, []byte{0x12, 0x34, 0x56, 0x78}) {
+		t.Errorf("balanceOf selector %x, want 12345678", got)
+	}
+	// the calldata is a copy, appending to it leaves the selector alone
+	_ = append(options.BalanceOfData(account)[:2], 0xff, 0xff)
+	if !bytes.Equal(options.BalanceOfSelector, []byte{0x12, 0x34, 0x56, 0x78}) {
+		t.Errorf("balanceOf selector changed to %x", options.BalanceOfSelector)
+	}
+}
@@ -0,0 +1,213 @@
+package orderflow
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// gasLimitDefault is the default gas limit for ethereum transactions
+	gasLimitDefault = uint64(21000)
+	// gasLimitWithData is the gas limit for ethereum transactions with data
+	gasLimitWithData = uint64(100000)
+)
+
+// Options configures how the order flow builds, signs and sends ethereum transactions. The zero
+// value signs for the node's network id with the default gas limits and the standard ERC20 methods,
+// and sends without a rate limit
+type Options struct {
+	// ChainID is the chain id transactions are signed for instead of the node's reported network
+	// id, for nodes whose network id differs from their chain id. 0 asks the node
+	ChainID uint64
+	// LockGasLimit and CloseGasLimit override the gas limit of lock and close transactions, for
+	// close payloads that run out of gas under the default limit. 0 keeps the default
+	LockGasLimit  uint64
+	CloseGasLimit uint64
+	// TransferSelector and BalanceOfSelector replace the ERC20 transfer and balanceOf methods, for
+	// token contracts that don't follow the standard. Empty keeps the standard method
+	TransferSelector  []byte
+	BalanceOfSelector []byte
+	// Limiter paces every transaction sent with these options and their copies; nil means unlimited
+	Limiter *RateLimiter
+	// TxDump receives each signed transaction's hash and raw hex encoding before it is sent, so it
+	// can be replayed with eth_sendRawTransaction. With TxDumpOnly set the transaction isn't sent
+	// and ErrTxNotSent is returned
+	TxDump     io.Writer
+	TxDumpOnly bool
+}
+
+// chainIDFor returns the chain id to sign for, the override if one is set or client's network id
+func (o Options) chainIDFor(client EthereumClient) (*big.Int, error) {
+	if o.ChainID != 0 {
+		return new(big.Int).SetUint64(o.ChainID), nil
+	}
+	return client.NetworkID(context.Background())
+}
+
+// MaxTxFee returns the most gas a transaction carrying data can cost at gasPrice, at the highest of
+// the default and overridden gas limits
+func (o Options) MaxTxFee(gasPrice *big.Int) *big.Int {
+	limit := gasLimitWithData
+	for _, override := range []uint64{o.LockGasLimit, o.CloseGasLimit} {
+		if override > limit {
+			limit = override
+		}
+	}
+	return new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(limit))
+}
+
+// ErrTxNotSent is returned for a transaction that was dumped instead of sent, see Options.TxDump
+var ErrTxNotSent = errors.New("transaction dumped, not sent")
+
+// dumpTx prints a signed transaction if dumping is enabled and reports whether to skip sending it
+func (o Options) dumpTx(tx *types.Transaction) (bool, error) {
+	if o.TxDump == nil {
+		return false, nil
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return false, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+	fmt.Fprintf(o.TxDump, "Signed tx %s: 0x%s\n", tx.Hash().Hex(), hex.EncodeToString(raw))
+	return o.TxDumpOnly, nil
+}
+
+// EthereumClient interface defines methods for interacting with ethereum blockchain
+type EthereumClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	NetworkID(ctx context.Context) (*big.Int, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// // SendTransaction sends an ethereum transaction, appending any data
+// func SendTransaction(client EthereumClient, to common.Address, key string, value *big.Int, data []byte) error {
+// 	// create context for ethereum client operations
+// 	ctx := context.Background()
+// 	// parse the private key from hex string
+// 	privateKey, err := crypto.HexToECDSA(key)
+// 	if err != nil {
+// 		return fmt.Errorf("failed to parse private key: %w", err)
+// 	}
+// 	// derive public key from private key
+// 	publicKey := privateKey.Public()
+// 	// cast public key to ecdsa public key
+// 	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
+// 	if !ok {
+// 		return fmt.Errorf("failed to cast public key to ECDSA")
+// 	}
+// 	// get ethereum address from public key
+// 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+// 	// get pending nonce for the sender address
+// 	nonce, err := client.PendingNonceAt(ctx, fromAddress)
+// 	if err != nil {
+// 		return fmt.Errorf("failed to get pending nonce: %w", err)
+// 	}
+// 	// get suggested gas price from network
+// 	gasPrice, err := client.SuggestGasPrice(ctx)
+// 	if err != nil {
+// 		return fmt.Errorf("failed to get suggested gas price: %w", err)
+// 	}
+// 	// calculate gas limit based on whether data is included
+// 	gasLimit := gasLimitDefault
+// 	if len(data) > 0 {
+// 		// increase gas limit for contract interactions with data
+// 		gasLimit = gasLimitDefault + uint64(len(data)*68)
+// 	}
+// 	// create new ethereum transaction
+// 	tx := types.NewTransaction(nonce, to, value, gasLimit, gasPrice, data)
+// 	// get network chain id for transaction signing
+// 	chainID, err := client.NetworkID(ctx)
+// 	if err != nil {
+// 		return fmt.Errorf("failed to get network ID: %w", err)
+// 	}
+// 	// sign the transaction with private key and chain id
+// 	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+// 	if err != nil {
+// 		return fmt.Errorf("failed to sign transaction: %w", err)
+// 	}
+// 	// send the signed transaction to the network
+// 	err = client.SendTransaction(ctx, signedTx)
+// 	if err != nil {
+// 		return fmt.Errorf("failed to send transaction: %w", err)
+// 	}
+// 	return nil
+// }
+
+// SendTransaction sends an ethereum transaction, optionally appending data, and returns its hash
+func (o Options) SendTransaction(client EthereumClient, to common.Address, key string, value *big.Int, data []byte) (common.Hash, error) {
+	return o.SendTransactionWithGasLimit(client, to, key, value, data, 0)
+}
+
+// SendTransactionWithGasLimit is SendTransaction with an explicit gas limit. A gasLimit of 0 picks
+// the default for the transaction, depending on whether it carries data
+func (o Options) SendTransactionWithGasLimit(client EthereumClient, to common.Address, key string, value *big.Int, data []byte, gasLimit uint64) (common.Hash, error) {
+	// parse the private key from hex string
+	privateKey, err := crypto.HexToECDSA(key)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	// get the public key from private key
+	publicKey := privateKey.Public()
+	// cast public key to ecdsa public key
+	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return common.Hash{}, fmt.Errorf("failed to cast public key to ecdsa")
+	}
+	// get the from address from public key
+	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+	// wait for the rate limit before reading the nonce, so the nonce is fresh when the tx is sent
+	o.waitForTxSlot()
+	// get the nonce for the from address
+	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	// get the suggested gas price
+	gasPrice, err := client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	// determine gas limit based on whether data is present, unless one was given
+	if gasLimit == 0 {
+		gasLimit = gasLimitDefault
+		if len(data) > 0 {
+			gasLimit = gasLimitWithData
+		}
+	}
+	// create the transaction
+	tx := types.NewTransaction(nonce, to, value, gasLimit, gasPrice, data)
+	// get the chain id
+	chainID, err := o.chainIDFor(client)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get chain id: %w", err)
+	}
+	// sign the transaction
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	// print the raw transaction for replaying it elsewhere, if asked to
+	skip, err := o.dumpTx(signedTx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if skip {
+		return signedTx.Hash(), fmt.Errorf("%w: %s", ErrTxNotSent, signedTx.Hash().Hex())
+	}
+	// send the transaction
+	err = client.SendTransaction(context.Background(), signedTx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	return signedTx.Hash(), nil
+}
@@ -0,0 +1,239 @@
+package orderflow
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/canopy-network/canopy/lib"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeEthereumClient is an EthereumClient that records the transactions it is sent
+type fakeEthereumClient struct {
+	nonce     uint64
+	gasPrice  *big.Int
+	networkID *big.Int
+
+	nonceErr     error
+	gasPriceErr  error
+	networkIDErr error
+	sendErr      error
+
+	nonceAccount common.Address
+	sent         []*types.Transaction
+}
+
+func newFakeEthereumClient() *fakeEthereumClient {
+	return &fakeEthereumClient{
+		nonce:     7,
+		gasPrice:  big.NewInt(1000000000),
+		networkID: big.NewInt(31337),
+	}
+}
+
+func (f *fakeEthereumClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	f.nonceAccount = account
+	return f.nonce, f.nonceErr
+}
+
+func (f *fakeEthereumClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return f.gasPrice, f.gasPriceErr
+}
+
+func (f *fakeEthereumClient) NetworkID(ctx context.Context) (*big.Int, error) {
+	return f.networkID, f.networkIDErr
+}
+
+func (f *fakeEthereumClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if f.sendErr != nil {
+		return f.sendErr
+	}
+	f.sent = append(f.sent, tx)
+	return nil
+}
+
+// anvil accounts 0 and 1
+var (
+	testKey     = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	testAddress = common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	testTo      = common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+)
+
+func TestSendTransaction(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		wantGasLimit uint64
+	}{
+		{name: "no data", wantGasLimit: gasLimitDefault},
+		{name: "with data", data: []byte{0xa9, 0x05, 0x9c, 0xbb}, wantGasLimit: gasLimitWithData},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newFakeEthereumClient()
+			hash, err := Options{}.SendTransaction(client, testTo, testKey, big.NewInt(5), test.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(client.sent) != 1 {
+				t.Fatalf("expected 1 sent transaction, got %d", len(client.sent))
+			}
+			tx := client.sent[0]
+
+			if hash != tx.Hash() {
+				t.Errorf("returned hash %s, want %s", hash, tx.Hash())
+			}
+			if client.nonceAccount != testAddress {
+				t.Errorf("nonce queried for %s, want %s", client.nonceAccount, testAddress)
+			}
+			if tx.Nonce() != client.nonce {
+				t.Errorf("nonce = %d, want %d", tx.Nonce(), client.nonce)
+			}
+			if tx.Gas() != test.wantGasLimit {
+				t.Errorf("gas limit = %d, want %d", tx.Gas(), test.wantGasLimit)
+			}
+			if tx.GasPrice().Cmp(client.gasPrice) != 0 {
+				t.Errorf("gas price = %s, want %s", tx.GasPrice(), client.gasPrice)
+			}
+			if *tx.To() != testTo {
+				t.Errorf("to = %s, want %s", tx.To(), testTo)
+			}
+			if tx.ChainId().Cmp(client.networkID) != 0 {
+				t.Errorf("chain id = %s, want %s", tx.ChainId(), client.networkID)
+			}
+			sender, err := types.Sender(types.NewEIP155Signer(client.networkID), tx)
+			if err != nil {
+				t.Fatalf("failed to recover sender: %v", err)
+			}
+			if sender != testAddress {
+				t.Errorf("signed by %s, want %s", sender, testAddress)
+			}
+		})
+	}
+}
+
+func TestSendTransactionErrors(t *testing.T) {
+	injected := errors.New("injected")
+	tests := []struct {
+		name    string
+		key     string
+		inject  func(*fakeEthereumClient)
+		wantErr string
+	}{
+		{name: "invalid key", key: "not-a-key", inject: func(*fakeEthereumClient) {}, wantErr: "failed to parse private key"},
+		{name: "nonce", inject: func(f *fakeEthereumClient) { f.nonceErr = injected }, wantErr: "failed to get nonce"},
+		{name: "gas price", inject: func(f *fakeEthereumClient) { f.gasPriceErr = injected }, wantErr: "failed to get gas price"},
+		{name: "network id", inject: func(f *fakeEthereumClient) { f.networkIDErr = injected }, wantErr: "failed to get chain id"},
+		{name: "send", inject: func(f *fakeEthereumClient) { f.sendErr = injected }, wantErr: "failed to send transaction"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newFakeEthereumClient()
+			test.inject(client)
+			key := testKey
+			if test.key != "" {
+				key = test.key
+			}
+			_, err := Options{}.SendTransaction(client, testTo, key, big.NewInt(0), nil)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("error = %v, want %q", err, test.wantErr)
+			}
+			if test.key == "" && !errors.Is(err, injected) {
+				t.Errorf("error %v does not wrap the injected error", err)
+			}
+			if len(client.sent) != 0 {
+				t.Errorf("expected no sent transactions, got %d", len(client.sent))
+			}
+		})
+	}
+}
+
+func TestOptionsChainID(t *testing.T) {
+	client := newFakeEthereumClient()
+	client.networkIDErr = errors.New("network id should not be queried")
+	if _, err := (Options{ChainID: 1}).SendTransaction(client, testTo, testKey, big.NewInt(0), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.sent[0].ChainId(); got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("chain id = %s, want 1", got)
+	}
+
+	// 0 uses the node's network id
+	client.networkIDErr = nil
+	if _, err := (Options{}).SendTransaction(client, testTo, testKey, big.NewInt(0), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.sent[1].ChainId(); got.Cmp(client.networkID) != 0 {
+		t.Errorf("chain id = %s, want %s", got, client.networkID)
+	}
+}
+
+func TestOptionsTxDump(t *testing.T) {
+	var dump strings.Builder
+	client := newFakeEthereumClient()
+	hash, err := Options{TxDump: &dump}.SendTransaction(client, testTo, testKey, big.NewInt(0), []byte{0x01})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(client.sent))
+	}
+	prefix := "Signed tx " + hash.Hex() + ": 0x"
+	line := strings.TrimSpace(dump.String())
+	if !strings.HasPrefix(line, prefix) {
+		t.Fatalf("dump = %q, want it to start with %q", line, prefix)
+	}
+	var replayed types.Transaction
+	if err := replayed.UnmarshalBinary(common.FromHex(strings.TrimPrefix(line, prefix))); err != nil {
+		t.Fatalf("dumped tx doesn't decode: %v", err)
+	}
+	if replayed.Hash() != client.sent[0].Hash() {
+		t.Errorf("dumped tx %s, sent %s", replayed.Hash(), client.sent[0].Hash())
+	}
+
+	// dump only never reaches the node
+	dump.Reset()
+	if _, err := (Options{TxDump: &dump, TxDumpOnly: true}).SendTransaction(client, testTo, testKey, big.NewInt(0), nil); !errors.Is(err, ErrTxNotSent) {
+		t.Errorf("SendTransaction() = %v, want %v", err, ErrTxNotSent)
+	}
+	if len(client.sent) != 1 || dump.Len() == 0 {
+		t.Errorf("dump only sent %d transactions and dumped %q", len(client.sent)-1, dump.String())
+	}
+}
+
+func TestOptionsGasLimits(t *testing.T) {
+	order := &lib.SellOrder{Id: []byte{1}, Committee: 2, SellerReceiveAddress: testTo.Bytes()}
+	options := Options{LockGasLimit: 150000, CloseGasLimit: 250000}
+	client := newFakeEthereumClient()
+	if _, err := options.LockOrder(client, order, testAddress.Hex(), testKey, testTo.Hex(), 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := options.CloseOrder(client, order, common.Address{}, testKey, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.sent[0].Gas(); got != 150000 {
+		t.Errorf("lock gas limit = %d, want 150000", got)
+	}
+	if got := client.sent[1].Gas(); got != 250000 {
+		t.Errorf("close gas limit = %d, want 250000", got)
+	}
+	if got := options.MaxTxFee(big.NewInt(2)); got.Cmp(big.NewInt(500000)) != 0 {
+		t.Errorf("MaxTxFee() = %s, want the close limit at 500000", got)
+	}
+
+	// other transactions keep the default
+	if _, err := options.SendTransaction(client, testTo, testKey, big.NewInt(0), []byte{1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.sent[2].Gas(); got != gasLimitWithData {
+		t.Errorf("gas limit = %d, want %d", got, gasLimitWithData)
+	}
+
+	if got := (Options{}).MaxTxFee(big.NewInt(2)); got.Cmp(big.NewInt(2*int64(gasLimitWithData))) != 0 {
+		t.Errorf("MaxTxFee() = %s, want the default limit", got)
+	}
+}
@@ -3,6 +3,7 @@ module canopy-testing
 go 1.24.4
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/canopy-network/canopy v0.0.0-20250723172104-c8424e681bd5
 	github.com/ethereum/go-ethereum v1.16.1
 	gopkg.in/yaml.v3 v3.0.1
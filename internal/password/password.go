@@ -0,0 +1,22 @@
+// Package password reads keystore passwords the canopy tools are given on disk
+package password
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Read reads a keystore password from path, dropping the trailing newline editors and
+// echo add
+func Read(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %w", err)
+	}
+	password := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if password == "" {
+		return "", fmt.Errorf("password file %s is empty", path)
+	}
+	return password, nil
+}
@@ -0,0 +1,43 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "trailing newline", content: "secret\n", want: "secret"},
+		{name: "crlf", content: "secret\r\n", want: "secret"},
+		{name: "no newline", content: "secret", want: "secret"},
+		// only the one newline an editor adds is dropped
+		{name: "inner whitespace kept", content: " se cret \n\n", want: " se cret \n"},
+		{name: "empty", content: "\n", wantErr: true},
+		{name: "missing", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_"))
+			if test.name != "missing" {
+				if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := Read(path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("Read() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}